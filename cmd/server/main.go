@@ -1,7 +1,6 @@
 package main
 
 import (
-	"database/sql"
 	"flag"
 	"fmt"
 	"net/http"
@@ -9,14 +8,20 @@ import (
 	"strings"
 	"time"
 
+	"rim-router-service-ver-cgo/internal/audit"
+	"rim-router-service-ver-cgo/internal/authn"
+	"rim-router-service-ver-cgo/internal/config"
 	database "rim-router-service-ver-cgo/internal/db"
 	"rim-router-service-ver-cgo/internal/handlers"
+	"rim-router-service-ver-cgo/internal/i18n"
+	"rim-router-service-ver-cgo/internal/loglevel"
+	"rim-router-service-ver-cgo/internal/metrics"
 	myMiddleware "rim-router-service-ver-cgo/internal/middleware"
 	"rim-router-service-ver-cgo/internal/models"
+	"rim-router-service-ver-cgo/internal/sysinfo"
 	"rim-router-service-ver-cgo/internal/utils"
+	"rim-router-service-ver-cgo/internal/version"
 
-	"github.com/go-chi/chi/v5"
-	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
@@ -24,71 +29,164 @@ import (
 var (
 	configPath = flag.String("config", "", "path to config file")
 	logLevel   = flag.String("log-level", "info", "log level")
+	devMode    = flag.Bool("dev", false, "allow insecure development defaults (e.g. the default JWT secret)")
+	showVer    = flag.Bool("version", false, "print build information and exit")
 )
 
 func main() {
 	flag.Parse()
 
+	build := version.Get()
+	if *showVer {
+		fmt.Printf("%s %s (commit %s, built %s, %s, cgo=%t, %s)\n", os.Args[0], build.Version,
+			build.Commit, build.BuildDate, build.GoVersion, build.CGO, build.Platform)
+		return
+	}
+
 	logger := setupLogger()
 	defer logger.Info().Msg("Server shutdown")
 
+	logger.Info().
+		Str("module", "system").
+		Str("version", build.Version).
+		Str("commit", build.Commit).
+		Str("build_date", build.BuildDate).
+		Str("go", build.GoVersion).
+		Bool("cgo", build.CGO).
+		Msg("Starting router service")
+
 	if err := loadConfig(*configPath, logger); err != nil {
 		logger.Fatal().Err(err).Msg("Failed to load config")
 	}
 
-	dbConn, err := database.OpenSQLite("./data.db")
+	jwtCfg := config.GetJWTConfig()
+	if err := jwtCfg.Validate(*devMode); err != nil {
+		logger.Fatal().Err(err).Msg("Refusing to start with insecure JWT configuration")
+	}
+
+	if err := config.GetMFAConfig().Validate(jwtCfg.AccessExpiration); err != nil {
+		logger.Fatal().Err(err).Msg("Invalid MFA configuration")
+	}
+
+	ldapCfg := config.GetLDAPConfig()
+	if err := ldapCfg.Validate(); err != nil {
+		logger.Fatal().Err(err).Msg("Invalid LDAP configuration")
+	}
+
+	for _, w := range config.PasswordConfigWarnings() {
+		logger.Warn().Msg("Password hashing: " + w)
+	}
+
+	sessionCfg := config.GetSessionConfig()
+	if err := sessionCfg.Validate(); err != nil {
+		logger.Fatal().Err(err).Msg("Invalid session cookie configuration")
+	}
+
+	trustedProxies, err := config.GetProxyConfig().Prefixes()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Invalid trusted proxy configuration")
+	}
+	myMiddleware.SetTrustedProxies(trustedProxies)
+
+	localeCfg := config.GetLocaleConfig()
+	if err := localeCfg.Validate(); err != nil {
+		logger.Fatal().Err(err).Msg("Invalid locale configuration")
+	}
+	i18n.SetDefault(i18n.Lang(localeCfg.Default))
+
+	const dbPath = "./data.db"
+	dbConn, err := database.OpenSQLite(dbPath)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("Failed to open database")
 	}
 	defer dbConn.Close()
 
-	if err := runMigrations(dbConn); err != nil {
+	if err := database.Migrate(dbConn); err != nil {
 		logger.Fatal().Err(err).Msg("Migrations failed")
 	}
 
 	userRepo := models.NewUserRepository(dbConn)
 	tokenRepo := models.NewTokenRepository(dbConn)
+	attemptRepo := models.NewLoginAttemptRepository(dbConn)
+	inviteRepo := models.NewInviteRepository(dbConn)
+	auditRepo := models.NewAuditRepository(dbConn)
+	apiKeyRepo := models.NewAPIKeyRepository(dbConn)
+	mfaRepo := models.NewMFARepository(dbConn)
+	audit.SetRecorder(auditRepo)
+	auditCfg := config.GetAuditConfig()
+	audit.SetRetention(auditCfg.Retention, auditCfg.MaxEvents)
+
+	keyManager, err := utils.NewKeyManager(models.NewSigningKeyRepository(dbConn, jwtCfg.KeyDir), jwtCfg)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to initialize JWT signing keys")
+	}
+	utils.SetKeyManager(keyManager)
+	keyManager.Start()
+
+	revocations, err := utils.NewRevocationList(models.NewRevocationRepository(dbConn), jwtCfg)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to load access token revocation list")
+	}
+	utils.SetRevocationList(revocations)
+
+	if revalCfg := config.GetRevalidationConfig(); revalCfg.Enabled {
+		myMiddleware.EnableUserRevalidation(userRepo.GetUserByID, revalCfg.CacheTTL)
+		logger.Info().Dur("cache_ttl", revalCfg.CacheTTL).Msg("Per-request user revalidation enabled")
+	}
+	myMiddleware.EnableAPIKeys(apiKeyRepo, userRepo.GetUserByID)
+	myMiddleware.EnableCookieSessions(sessionCfg)
+	if sessionCfg.CookieMode {
+		logger.Info().Bool("secure", sessionCfg.Secure).Str("domain", sessionCfg.Domain).Msg("Cookie session mode enabled")
+	}
 
 	database.SeedAdmin(userRepo)
 
+	loginGuard := handlers.NewLoginGuard(attemptRepo, config.GetLockoutConfig())
+
 	authHandler := handlers.NewAuthHandler(userRepo, tokenRepo)
+	authHandler.Guard = loginGuard
+	authHandler.Invites = inviteRepo
+	authHandler.MFA = mfaRepo
+	if ldapCfg.Backend == config.AuthBackendLDAP {
+		// учётные записи из LDAP_BREAK_GLASS_USERS входят по локальному паролю
+		authHandler.Authenticator = authn.WithBreakGlass(
+			authn.NewLDAP(ldapCfg, userRepo), authHandler.Authenticator, ldapCfg.BreakGlassUsers)
+		logger.Info().Str("url", ldapCfg.URL).Strs("break_glass", ldapCfg.BreakGlassUsers).Msg("LDAP authentication enabled")
+	}
 	adminHandler := handlers.NewAdminHandler(userRepo)
+	adminHandler.Guard = loginGuard
+	adminHandler.Invites = inviteRepo
+	adminHandler.APIKeys = apiKeyRepo
+	adminHandler.MFA = mfaRepo
+	auditHandler := handlers.NewAuditHandler(auditRepo)
+	sysCollector := sysinfo.NewCollector(dbPath)
+	systemHandler := handlers.NewSystemHandler(sysCollector)
+	diagnosticsHandler := handlers.NewDiagnosticsHandler(dbConn, userRepo, auditRepo, sysCollector)
+	readiness := handlers.NewReadinessHandler(
+		handlers.DatabaseCheck(dbConn),
+		handlers.MigrationsCheck(dbConn),
+		handlers.LogDirCheck(),
+		handlers.TirCheck(),
+	)
+
+	metricsCfg := config.GetMetricsConfig()
+	if err := metricsCfg.Validate(); err != nil {
+		logger.Fatal().Err(err).Msg("Invalid metrics configuration")
+	}
+	if metricsCfg.Enabled {
+		metrics.RegisterSystemCollectors(dbConn, handlers.TirRunning)
+	}
 
-	r := chi.NewRouter()
-
-	r.Use(chimiddleware.RequestID)
-	r.Use(chimiddleware.RealIP)
-	r.Use(chimiddleware.Recoverer)
-	r.Use(chimiddleware.Timeout(60 * time.Second))
-	r.Use(zerologMiddleware(logger))
-
-	// --- Public endpoints ---
-	r.Get("/health", handlers.HealthHandler)
-	r.Post("/api/v1/register", authHandler.Register)
-	r.Post("/api/v1/login", authHandler.Login)
-	r.Post("/api/v1/refresh", authHandler.Refresh)
-	r.Post("/api/v1/logout", authHandler.Logout)
-
-	// --- Authenticated v1 ---
-	r.Route("/api/v1", func(r chi.Router) {
-		r.Use(myMiddleware.AuthMiddleware)
-		r.Get("/softwareVer", handlers.GetSoftwareVer)
-	})
-
-	// --- Admin-only v2 (logs, user management, etc.) ---
-	r.Route("/api/v2", func(r chi.Router) {
-		r.Use(myMiddleware.AuthMiddleware)
-		r.With(myMiddleware.RoleMiddleware(1)).Group(func(r chi.Router) {
-			// --- System logs ---
-			r.Get("/logs", handlers.ListAllLogs)
-			r.Get("/logs/download-all", handlers.DownloadAllLogs)
-			r.Get("/logs/download", handlers.DownloadSelectedLogs)
-			r.Get("/logs/tail", handlers.TailUnified)
-
-			// --- User management (admin panel) ---
-			r.Get("/admin/users", adminHandler.ListUsers)
-			r.Post("/admin/users/{id}/role", adminHandler.UpdateUserRole)
-		})
+	r := newRouter(routerDeps{
+		logger:      logger,
+		auth:        authHandler,
+		admin:       adminHandler,
+		audit:       auditHandler,
+		system:      systemHandler,
+		diagnostics: diagnosticsHandler,
+		readiness:   readiness,
+		rate:        config.GetRateLimitConfig(),
+		metrics:     metricsCfg,
 	})
 
 	port := os.Getenv("PORT")
@@ -121,6 +219,8 @@ func setupLogger() zerolog.Logger {
 	}
 
 	zerolog.TimeFieldFormat = time.RFC3339
+	// стартовый уровень; дальше его можно менять через /api/v2/admin/loglevel
+	loglevel.Init(level)
 
 	logger := zerolog.New(writer).With().Timestamp().Logger().Level(level)
 	log.Logger = logger
@@ -134,54 +234,6 @@ func setupLogger() zerolog.Logger {
 	return logger
 }
 
-// -----------------------------
-// Middleware for request logging
-// -----------------------------
-func zerologMiddleware(logger zerolog.Logger) func(next http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			start := time.Now()
-			ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
-			next.ServeHTTP(ww, r)
-			logger.Info().
-				Str("method", r.Method).
-				Str("url", r.URL.Path).
-				Int("status", ww.Status()).
-				Int("size", ww.BytesWritten()).
-				Dur("duration", time.Since(start)).
-				Msg("request")
-		})
-	}
-}
-
-// -----------------------------
-// DB Migrations
-// -----------------------------
-func runMigrations(db *sql.DB) error {
-	_, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS users (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			username TEXT UNIQUE NOT NULL,
-			password_hash TEXT NOT NULL,
-			role INTEGER NOT NULL DEFAULT 0,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		);
-		CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);
-
-		CREATE TABLE IF NOT EXISTS refresh_tokens (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			user_id INTEGER NOT NULL,
-			token TEXT NOT NULL UNIQUE,
-			expires_at DATETIME NOT NULL,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
-		);
-		CREATE INDEX IF NOT EXISTS idx_refresh_user_id ON refresh_tokens(user_id);
-		CREATE INDEX IF NOT EXISTS idx_refresh_token ON refresh_tokens(token);
-	`)
-	return err
-}
-
 // -----------------------------
 // Config loader
 // -----------------------------
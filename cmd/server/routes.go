@@ -0,0 +1,146 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/config"
+	"rim-router-service-ver-cgo/internal/handlers"
+	"rim-router-service-ver-cgo/internal/metrics"
+	myMiddleware "rim-router-service-ver-cgo/internal/middleware"
+	"rim-router-service-ver-cgo/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog"
+)
+
+// routerDeps — обработчики и настройки, из которых собирается роутер
+type routerDeps struct {
+	logger      zerolog.Logger
+	auth        *handlers.AuthHandler
+	admin       *handlers.AdminHandler
+	audit       *handlers.AuditHandler
+	system      *handlers.SystemHandler
+	diagnostics *handlers.DiagnosticsHandler
+	readiness   *handlers.ReadinessHandler
+	rate        config.RateLimitConfig
+	metrics     config.MetricsConfig
+}
+
+// newRouter регистрирует middleware и маршруты API. Каждый маршрут должен
+// быть описан в internal/openapi/openapi.json — это проверяет routes_test.go.
+func newRouter(d routerDeps) chi.Router {
+	globalLimiter := myMiddleware.NewRateLimiter("global", d.rate.Global, myMiddleware.KeyByIP)
+	authLimiter := myMiddleware.NewRateLimiter("auth", d.rate.Auth, myMiddleware.KeyByIP)
+	downloadLimiter := myMiddleware.NewRateLimiter("downloads", d.rate.Downloads, myMiddleware.KeyByUser)
+
+	r := chi.NewRouter()
+
+	r.Use(chimiddleware.RequestID)
+	r.Use(chimiddleware.Recoverer)
+	r.Use(chimiddleware.Timeout(60 * time.Second))
+	r.Use(myMiddleware.RequestLogger(d.logger))
+	r.Use(myMiddleware.Language)
+	r.Use(metrics.Middleware)
+	r.Use(globalLimiter.Handler)
+	r.NotFound(handlers.NotFound)
+	r.MethodNotAllowed(handlers.MethodNotAllowed)
+
+	// --- Public endpoints ---
+	r.Get("/health", handlers.HealthHandler)
+	r.Get("/health/live", handlers.LivenessHandler)
+	r.Get("/health/ready", d.readiness.Ready)
+	if d.metrics.Enabled {
+		r.Method(http.MethodGet, "/metrics", metrics.Handler(d.metrics.Token))
+	}
+	r.Group(func(r chi.Router) {
+		r.Use(authLimiter.Handler)
+		r.Post("/api/v1/register", d.auth.Register)
+		r.Post("/api/v1/login", d.auth.Login)
+		// refresh and logout rely on cookies: CSRF-checked in cookie session mode
+		r.With(myMiddleware.CSRFProtect).Post("/api/v1/refresh", d.auth.Refresh)
+		r.Post("/api/v1/login/mfa", d.auth.VerifyMFA)
+	})
+	r.With(myMiddleware.CSRFProtect).Post("/api/v1/logout", d.auth.Logout)
+
+	// --- Identity provider for other local services ---
+	r.Get("/.well-known/jwks.json", handlers.JWKS)
+	r.Post("/api/v1/introspect", handlers.Introspect)
+
+	// --- API documentation ---
+	r.Get("/api/openapi.json", handlers.OpenAPI)
+
+	// --- Password change (allowed while a change is pending) ---
+	r.With(myMiddleware.PasswordChangeAuthMiddleware).Post("/api/v1/password", d.auth.ChangePassword)
+
+	// --- TOTP enrollment (also accepts the enrollment token issued by login) ---
+	r.With(myMiddleware.MFAEnrollmentAuthMiddleware).Post("/api/v1/mfa/enroll", d.auth.EnrollMFA)
+	r.With(myMiddleware.MFAEnrollmentAuthMiddleware).Post("/api/v1/mfa/confirm", d.auth.ConfirmMFA)
+
+	// --- Authenticated v1 (own account settings, user sessions only) ---
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Use(myMiddleware.RejectAPIKeys)
+		r.Use(myMiddleware.AuthMiddleware)
+		r.Get("/softwareVer", handlers.GetSoftwareVer)
+		r.Get("/version", handlers.GetVersion)
+		r.Get("/mfa", d.auth.MFAStatus)
+		r.Post("/mfa/disable", d.auth.DisableMFA)
+		r.Get("/locale", d.auth.GetLocale)
+		r.Put("/locale", d.auth.SetLocale)
+	})
+
+	// --- Admin-only v2 (logs, user management, etc.) ---
+	r.Route("/api/v2", func(r chi.Router) {
+		r.Use(myMiddleware.AuthMiddleware)
+		r.With(myMiddleware.RoleMiddleware(1)).Group(func(r chi.Router) {
+			// --- System logs ---
+			r.Group(func(r chi.Router) {
+				r.Use(myMiddleware.RequireScope(models.ScopeLogsRead))
+				r.Get("/logs", handlers.ListAllLogs)
+				r.Get("/logs/tail", handlers.TailUnified)
+				r.Group(func(r chi.Router) {
+					r.Use(downloadLimiter.Handler)
+					r.Use(chimiddleware.Throttle(d.rate.MaxDownloads))
+					r.Get("/logs/download-all", handlers.DownloadAllLogs)
+					r.Get("/logs/download", handlers.DownloadSelectedLogs)
+				})
+			})
+
+			// --- Audit trail ---
+			r.Group(func(r chi.Router) {
+				r.Use(myMiddleware.RequireScope(models.ScopeAuditRead))
+				r.Get("/audit", d.audit.List)
+				r.Get("/audit/export", d.audit.Export)
+			})
+
+			// --- User management (admin panel) ---
+			r.Group(func(r chi.Router) {
+				r.Use(myMiddleware.RequireScope(models.ScopeAdmin))
+				r.Get("/admin/users", d.admin.ListUsers)
+				r.Post("/admin/users/{id}/role", d.admin.UpdateUserRole)
+				r.Post("/admin/users/{id}/unlock", d.admin.UnlockUser)
+				r.Post("/admin/users/{id}/approve", d.admin.ApproveUser)
+				r.Delete("/admin/users/{id}/mfa", d.admin.ResetUserMFA)
+				r.Get("/admin/invites", d.admin.ListInvites)
+				r.Post("/admin/invites", d.admin.CreateInvite)
+				r.Delete("/admin/invites/{id}", d.admin.DeleteInvite)
+				r.Get("/admin/apikeys", d.admin.ListAPIKeys)
+				r.Post("/admin/apikeys", d.admin.CreateAPIKey)
+				r.Delete("/admin/apikeys/{id}", d.admin.RevokeAPIKey)
+				r.Get("/admin/ratelimits", handlers.GetRateLimitStats)
+				r.Get("/admin/loglevel", handlers.GetLogLevels)
+				r.Put("/admin/loglevel", handlers.SetLogLevel)
+				r.Delete("/admin/loglevel/{module}", handlers.ResetLogLevel)
+				r.Get("/system", d.system.Get)
+				r.Group(func(r chi.Router) {
+					r.Use(downloadLimiter.Handler)
+					r.Use(chimiddleware.Throttle(d.rate.MaxDownloads))
+					r.Get("/diagnostics/bundle", d.diagnostics.Bundle)
+				})
+			})
+		})
+	})
+
+	return r
+}
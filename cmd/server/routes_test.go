@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"testing"
+
+	"rim-router-service-ver-cgo/internal/config"
+	"rim-router-service-ver-cgo/internal/handlers"
+	"rim-router-service-ver-cgo/internal/openapi"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRouter собирает роутер со всеми необязательными маршрутами
+func testRouter() chi.Router {
+	return newRouter(routerDeps{
+		logger:      zerolog.Nop(),
+		auth:        &handlers.AuthHandler{},
+		admin:       &handlers.AdminHandler{},
+		audit:       &handlers.AuditHandler{},
+		system:      &handlers.SystemHandler{},
+		diagnostics: &handlers.DiagnosticsHandler{},
+		readiness:   handlers.NewReadinessHandler(),
+		rate:        config.GetRateLimitConfig(),
+		metrics:     config.MetricsConfig{Enabled: true},
+	})
+}
+
+// Каждый маршрут роутера описан в спецификации, и наоборот
+func TestRoutes_MatchOpenAPI(t *testing.T) {
+	var routes []string
+	err := chi.Walk(testRouter(), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		routes = append(routes, method+" "+route)
+		return nil
+	})
+	require.NoError(t, err)
+	sort.Strings(routes)
+
+	ops, err := openapi.Operations()
+	require.NoError(t, err)
+
+	described := map[string]bool{}
+	for _, op := range ops {
+		described[op] = true
+	}
+	registered := map[string]bool{}
+	for _, route := range routes {
+		registered[route] = true
+		assert.True(t, described[route], "маршрут %s не описан в internal/openapi/openapi.json", route)
+	}
+	for _, op := range ops {
+		assert.True(t, registered[op], "операция %s из спецификации не зарегистрирована", op)
+	}
+}
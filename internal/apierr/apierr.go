@@ -0,0 +1,192 @@
+// Package apierr — единая модель ошибок API. Обработчики и middleware
+// отвечают через Write, клиент получает стабильный код ошибки в поле
+// "error" (или "code" в формате RFC 7807) и, для ошибок валидации, список
+// полей.
+//
+// По умолчанию ответ — привычный конверт {code, message, data} с
+// дополнительными полями error, errors и request_id. Клиент, приславший
+// Accept: application/problem+json, получает application/problem+json.
+package apierr
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"rim-router-service-ver-cgo/internal/i18n"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// ProblemContentType — тип содержимого ответа RFC 7807
+const ProblemContentType = "application/problem+json"
+
+// problemTypePrefix — префикс URI типа проблемы; к нему дописывается код
+const problemTypePrefix = "urn:rim-router:error:"
+
+// FieldError — ошибка конкретного поля запроса
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// Error — ошибка API: HTTP-статус, код и текст для человека.
+// Значения неизменяемы: With* возвращают копию.
+type Error struct {
+	Status  int
+	Code    Code
+	Message string
+	Fields  []FieldError
+	Data    interface{} // дополнительные данные ответа (для совместимости)
+}
+
+// New создаёт ошибку API
+func New(status int, code Code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return string(e.Code) + ": " + e.Message
+}
+
+// WithFields возвращает копию ошибки с ошибками полей
+func (e *Error) WithFields(fields ...FieldError) *Error {
+	c := *e
+	c.Fields = append(append([]FieldError(nil), e.Fields...), fields...)
+	return &c
+}
+
+// WithData возвращает копию ошибки с данными ответа
+func (e *Error) WithData(data interface{}) *Error {
+	c := *e
+	c.Data = data
+	return &c
+}
+
+// WithMessage возвращает копию ошибки с другим текстом
+func (e *Error) WithMessage(message string) *Error {
+	c := *e
+	c.Message = message
+	return &c
+}
+
+// Field — короткая запись FieldError
+func Field(field, code, message string) FieldError {
+	return FieldError{Field: field, Code: code, Message: message}
+}
+
+// Validation — 400 validation_failed с ошибками полей
+func Validation(message string, fields ...FieldError) *Error {
+	return New(http.StatusBadRequest, CodeValidation, message).WithFields(fields...)
+}
+
+// Часто встречающиеся ошибки
+var (
+	ErrInvalidJSON    = New(http.StatusBadRequest, CodeInvalidJSON, "Invalid JSON")
+	ErrInvalidRequest = New(http.StatusBadRequest, CodeInvalidRequest, "Invalid request")
+	ErrAuthRequired   = New(http.StatusUnauthorized, CodeAuthRequired, "Authentication required")
+	ErrForbidden      = New(http.StatusForbidden, CodeInsufficientRole, "Insufficient permissions")
+	ErrRateLimited    = New(http.StatusTooManyRequests, CodeRateLimited, "Too many requests")
+	ErrDatabase       = New(http.StatusInternalServerError, CodeDatabase, "Database error")
+	ErrInternal       = New(http.StatusInternalServerError, CodeInternal, "Internal server error")
+)
+
+// envelope — стандартный ответ с ошибкой, совместимый с {code, message, data}
+type envelope struct {
+	Code      int          `json:"code"`
+	Message   string       `json:"message"`
+	Error     Code         `json:"error"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	Data      interface{}  `json:"data,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+// Problem — тело application/problem+json (RFC 7807) с расширениями
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      Code         `json:"code"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	Data      interface{}  `json:"data,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+// localize выбирает текст ошибки: для английского — исходный текст вызова
+// (он точнее), для остальных языков — перевод по коду из каталога
+func localize(e *Error, lang i18n.Lang) string {
+	if lang == i18n.EN && e.Message != "" {
+		return e.Message
+	}
+	if s, ok := i18n.Lookup(lang, i18n.Key(e.Code)); ok {
+		return s
+	}
+	return e.Message
+}
+
+// From приводит произвольную ошибку к *Error; всё, что не *Error, — 500
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return ErrInternal
+}
+
+// WantsProblem сообщает, просил ли клиент application/problem+json
+func WantsProblem(r *http.Request) bool {
+	return r != nil && strings.Contains(r.Header.Get("Accept"), ProblemContentType)
+}
+
+// Write отправляет ошибку в формате, который выбрал клиент
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	e := From(err)
+
+	var reqID string
+	lang := i18n.Default()
+	if r != nil {
+		reqID = chimiddleware.GetReqID(r.Context())
+		lang = i18n.FromContext(r.Context())
+	}
+	message := localize(e, lang)
+	w.Header().Set("Content-Language", string(lang))
+
+	var body interface{}
+	if WantsProblem(r) {
+		w.Header().Set("Content-Type", ProblemContentType)
+		body = Problem{
+			Type:      problemTypePrefix + string(e.Code),
+			Title:     http.StatusText(e.Status),
+			Status:    e.Status,
+			Detail:    message,
+			Instance:  r.URL.Path,
+			Code:      e.Code,
+			Errors:    e.Fields,
+			Data:      e.Data,
+			RequestID: reqID,
+		}
+	} else {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		body = envelope{
+			Code:      e.Status,
+			Message:   message,
+			Error:     e.Code,
+			Errors:    e.Fields,
+			Data:      e.Data,
+			RequestID: reqID,
+		}
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(e.Status)
+
+	b, mErr := json.Marshal(body)
+	if mErr != nil {
+		_, _ = w.Write([]byte(`{"code": 500, "message": "JSON encoding error", "error": "internal_error"}`))
+		return
+	}
+	_, _ = w.Write(b)
+}
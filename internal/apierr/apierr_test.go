@@ -0,0 +1,112 @@
+package apierr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"rim-router-service-ver-cgo/internal/i18n"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrite_Envelope(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/register", nil)
+	req = req.WithContext(context.WithValue(req.Context(), chimiddleware.RequestIDKey, "req-1"))
+	w := httptest.NewRecorder()
+
+	Write(w, req, Validation("Username must be 3-20 characters", Field("username", "length", "")))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+
+	var body map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, float64(400), body["code"])
+	assert.Equal(t, "Username must be 3-20 characters", body["message"])
+	assert.Equal(t, "validation_failed", body["error"])
+	assert.Equal(t, "req-1", body["request_id"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"field": "username", "code": "length"}}, body["errors"])
+}
+
+func TestWrite_Problem(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/admin/users/7", nil)
+	req.Header.Set("Accept", "application/problem+json, application/json")
+	w := httptest.NewRecorder()
+
+	Write(w, req, New(http.StatusNotFound, CodeUserNotFound, "User not found"))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+
+	var p Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	assert.Equal(t, "urn:rim-router:error:user_not_found", p.Type)
+	assert.Equal(t, "Not Found", p.Title)
+	assert.Equal(t, 404, p.Status)
+	assert.Equal(t, "User not found", p.Detail)
+	assert.Equal(t, "/api/v2/admin/users/7", p.Instance)
+	assert.Equal(t, CodeUserNotFound, p.Code)
+}
+
+func TestWrite_PlainErrorIsInternal(t *testing.T) {
+	w := httptest.NewRecorder()
+	Write(w, httptest.NewRequest(http.MethodGet, "/", nil), errors.New("boom"))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), `"error":"internal_error"`)
+	assert.NotContains(t, w.Body.String(), "boom")
+}
+
+func TestWith_DoesNotMutateShared(t *testing.T) {
+	e := ErrInvalidJSON.WithMessage("invalid body").WithFields(Field("files", "required", ""))
+
+	assert.Equal(t, "invalid body", e.Message)
+	assert.Len(t, e.Fields, 1)
+	assert.Equal(t, "Invalid JSON", ErrInvalidJSON.Message)
+	assert.Empty(t, ErrInvalidJSON.Fields)
+
+	var target *Error
+	assert.True(t, errors.As(error(e), &target))
+	assert.Equal(t, CodeInvalidJSON, From(e).Code)
+}
+
+func TestWrite_Localized(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/startTir", nil)
+	req = req.WithContext(i18n.WithLang(req.Context(), i18n.RU))
+	w := httptest.NewRecorder()
+
+	Write(w, req, New(http.StatusBadRequest, CodeTirAlreadyRunning, "TIR is already running"))
+
+	assert.Equal(t, "ru", w.Header().Get("Content-Language"))
+	assert.Contains(t, w.Body.String(), `"message":"ТИР уже запущен"`)
+	assert.Contains(t, w.Body.String(), `"error":"tir_already_running"`)
+}
+
+// Каждый код из codes.go должен быть в каталоге сообщений
+func TestCodes_HaveTranslations(t *testing.T) {
+	f, err := parser.ParseFile(token.NewFileSet(), "codes.go", nil, 0)
+	assert.NoError(t, err)
+
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			for _, v := range spec.(*ast.ValueSpec).Values {
+				code, _ := strconv.Unquote(v.(*ast.BasicLit).Value)
+				_, ok := i18n.Lookup(i18n.RU, i18n.Key(code))
+				assert.True(t, ok, "нет перевода для кода %s", code)
+			}
+		}
+	}
+}
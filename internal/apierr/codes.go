@@ -0,0 +1,81 @@
+package apierr
+
+// Code — машинно-читаемый код ошибки. Значения стабильны: клиенты
+// принимают решения по коду, а не по тексту сообщения.
+type Code string
+
+// Общие
+const (
+	CodeInvalidJSON      Code = "invalid_json"
+	CodeInvalidRequest   Code = "invalid_request"
+	CodeValidation       Code = "validation_failed"
+	CodeInvalidID        Code = "invalid_id"
+	CodeNotFound         Code = "not_found"
+	CodeMethodNotAllowed Code = "method_not_allowed"
+	CodeRateLimited      Code = "rate_limited"
+	CodeDatabase         Code = "database_error"
+	CodeInternal         Code = "internal_error"
+	CodeFeatureDisabled  Code = "feature_disabled"
+)
+
+// Аутентификация и доступ
+const (
+	CodeAuthRequired           Code = "auth_required"
+	CodeInvalidAuthFormat      Code = "invalid_auth_format"
+	CodeInvalidToken           Code = "invalid_token"
+	CodeTokenRevoked           Code = "token_revoked"
+	CodeInvalidCredentials     Code = "invalid_credentials"
+	CodeTooManyAttempts        Code = "too_many_attempts"
+	CodeAccountPending         Code = "account_pending"
+	CodeAccountDisabled        Code = "account_disabled"
+	CodeAccountInactive        Code = "account_inactive"
+	CodeUserNotFound           Code = "user_not_found"
+	CodeInsufficientRole       Code = "insufficient_role"
+	CodeInsufficientScope      Code = "insufficient_scope"
+	CodePasswordChangeRequired Code = "password_change_required"
+	CodeCSRFFailed             Code = "csrf_failed"
+	CodeInvalidRefreshToken    Code = "invalid_refresh_token"
+	CodeInvalidAPIKey          Code = "invalid_api_key"
+	CodeAPIKeyNotAllowed       Code = "api_key_not_allowed"
+	CodeInvalidClient          Code = "invalid_client"
+	CodeAuthBackendUnavailable Code = "auth_backend_unavailable"
+	CodeNoDirectoryRole        Code = "no_directory_role"
+	CodeLocalAccountConflict   Code = "local_account_conflict"
+	CodeSigningUnavailable     Code = "signing_keys_unavailable"
+)
+
+// Регистрация и пароли
+const (
+	CodeRegistrationDisabled      Code = "registration_disabled"
+	CodeInviteRequired            Code = "invite_required"
+	CodeInvalidInvite             Code = "invalid_invite"
+	CodeUserExists                Code = "user_exists"
+	CodePasswordPolicy            Code = "password_policy"
+	CodePasswordReused            Code = "password_reused"
+	CodePasswordManagedExternally Code = "password_managed_externally"
+)
+
+// Двухфакторная аутентификация
+const (
+	CodeMFARequired       Code = "mfa_required"
+	CodeInvalidMFAToken   Code = "invalid_mfa_token"
+	CodeInvalidMFACode    Code = "invalid_mfa_code"
+	CodeMFANotEnabled     Code = "mfa_not_enabled"
+	CodeMFAAlreadyEnabled Code = "mfa_already_enabled"
+	CodeMFANotEnrolling   Code = "mfa_enrollment_not_started"
+)
+
+// Администрирование
+const (
+	CodeUserNotPending Code = "user_not_pending"
+	CodeInviteNotFound Code = "invite_not_found"
+	CodeAPIKeyNotFound Code = "api_key_not_found"
+)
+
+// Логи и ТИР
+const (
+	CodeLogNotFound       Code = "log_not_found"
+	CodeLogAccessDenied   Code = "log_access_denied"
+	CodeTirAlreadyRunning Code = "tir_already_running"
+	CodeTirNotRunning     Code = "tir_not_running"
+)
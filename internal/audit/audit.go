@@ -0,0 +1,168 @@
+// Package audit записывает действия, важные для безопасности, в таблицу
+// audit_events. В отличие от логов журнал аудита не ротируется, но старые
+// события удаляются по SetRetention.
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/middleware"
+	"rim-router-service-ver-cgo/internal/models"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// Действия, попадающие в журнал
+const (
+	ActionLogin             = "auth.login"
+	ActionLoginFailed       = "auth.login_failed"
+	ActionPasswordChange    = "auth.password_change"
+	ActionMFAEnable         = "auth.mfa_enable"
+	ActionMFADisable        = "auth.mfa_disable"
+	ActionRoleChange        = "user.role_change"
+	ActionUserUnlock        = "user.unlock"
+	ActionUserApprove       = "user.approve"
+	ActionInviteCreate      = "invite.create"
+	ActionInviteDelete      = "invite.delete"
+	ActionAPIKeyCreate      = "apikey.create"
+	ActionAPIKeyRevoke      = "apikey.revoke"
+	ActionLogsDownload      = "logs.download"
+	ActionLogLevelChange    = "logs.level_change"
+	ActionAuditExport       = "audit.export"
+	ActionDiagnosticsBundle = "diagnostics.bundle"
+	ActionTirStart          = "tir.start"
+	ActionTirStop           = "tir.stop"
+	ActionTirRestart        = "tir.restart"
+)
+
+// Recorder сохраняет событие (в приложении — *models.AuditRepository).
+type Recorder interface {
+	Insert(e models.AuditEvent) (int64, error)
+}
+
+// Pruner удаляет старые события (в приложении — *models.AuditRepository).
+// Нулевой before — без ограничения по сроку.
+type Pruner interface {
+	Prune(before time.Time, keep int) (int64, error)
+}
+
+// pruneInterval — как часто Record запускает удаление старых событий
+const pruneInterval = 10 * time.Minute
+
+var (
+	mu       sync.RWMutex
+	recorder Recorder
+
+	pruneMu   sync.Mutex
+	maxAge    time.Duration
+	maxEvents int
+	lastPrune time.Time
+)
+
+// SetRetention задаёт срок хранения и предельное число событий (0 — без
+// ограничения). Если Recorder умеет Prune, Record не чаще pruneInterval
+// удаляет лишнее.
+func SetRetention(age time.Duration, events int) {
+	pruneMu.Lock()
+	maxAge, maxEvents = age, events
+	lastPrune = time.Time{}
+	pruneMu.Unlock()
+}
+
+// SetRecorder включает журнал аудита; nil — выключает.
+func SetRecorder(r Recorder) {
+	mu.Lock()
+	recorder = r
+	mu.Unlock()
+}
+
+// Entry — то, что знает обработчик о действии. Request ID, IP и время
+// подставляются из запроса.
+type Entry struct {
+	Action  string
+	Target  string
+	Actor   string // если пусто — пользователь из JWT
+	ActorID int64  // для входа, когда JWT ещё нет
+	Before  interface{}
+	After   interface{}
+}
+
+// Record сохраняет событие. Ошибки записи не прерывают запрос, а пишутся в лог.
+func Record(r *http.Request, e Entry) {
+	mu.RLock()
+	rec := recorder
+	mu.RUnlock()
+	if rec == nil {
+		return
+	}
+
+	ev := models.AuditEvent{
+		Timestamp: time.Now(),
+		Actor:     e.Actor,
+		Action:    e.Action,
+		Target:    e.Target,
+		RequestID: chimiddleware.GetReqID(r.Context()),
+		IP:        middleware.ClientIP(r),
+		Before:    marshal(e.Before),
+		After:     marshal(e.After),
+	}
+	if e.ActorID != 0 {
+		ev.ActorID = &e.ActorID
+	} else if claims := middleware.GetUserFromContext(r.Context()); claims != nil {
+		id := claims.UserID
+		ev.ActorID = &id
+		if ev.Actor == "" {
+			ev.Actor = claims.Username
+		}
+	}
+
+	if _, err := rec.Insert(ev); err != nil {
+		logger := middleware.Log(r, "audit").With().Str("action", e.Action).Logger()
+		(&logger).Error().Err(err).Msg("Failed to record audit event")
+	}
+	if p, ok := rec.(Pruner); ok {
+		pruneIfDue(r, p, ev.Timestamp)
+	}
+}
+
+// pruneIfDue удаляет события старше срока хранения и сверх предела. Ошибка
+// только пишется в лог: следующая попытка будет через pruneInterval.
+func pruneIfDue(r *http.Request, p Pruner, now time.Time) {
+	pruneMu.Lock()
+	if (maxAge <= 0 && maxEvents <= 0) || now.Sub(lastPrune) < pruneInterval {
+		pruneMu.Unlock()
+		return
+	}
+	lastPrune = now
+	age, keep := maxAge, maxEvents
+	pruneMu.Unlock()
+
+	// нулевая граница — срок не ограничен, остаётся только предел по числу
+	var before time.Time
+	if age > 0 {
+		before = now.Add(-age)
+	}
+	logger := middleware.Log(r, "audit")
+	n, err := p.Prune(before, keep)
+	if err != nil {
+		(&logger).Error().Err(err).Msg("Failed to prune audit events")
+		return
+	}
+	if n > 0 {
+		(&logger).Info().Int64("deleted", n).Msg("Pruned old audit events")
+	}
+}
+
+func marshal(v interface{}) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return b
+}
@@ -0,0 +1,105 @@
+package audit
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/middleware"
+	"rim-router-service-ver-cgo/internal/models"
+	"rim-router-service-ver-cgo/internal/utils"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRecorder struct {
+	events []models.AuditEvent
+}
+
+func (f *fakeRecorder) Insert(e models.AuditEvent) (int64, error) {
+	f.events = append(f.events, e)
+	return int64(len(f.events)), nil
+}
+
+func TestRecord_FillsRequestContext(t *testing.T) {
+	rec := &fakeRecorder{}
+	SetRecorder(rec)
+	defer SetRecorder(nil)
+
+	req := httptest.NewRequest("POST", "/api/v2/startTir", nil)
+	req.RemoteAddr = "192.0.2.7:5555"
+	ctx := context.WithValue(req.Context(), chimiddleware.RequestIDKey, "req-42")
+	ctx = context.WithValue(ctx, middleware.UserContextKey, &utils.Claims{UserID: 3, Username: "admin", Role: 1})
+
+	Record(req.WithContext(ctx), Entry{Action: ActionTirStart, Target: "tir", After: map[string]bool{"running": true}})
+
+	if assert.Len(t, rec.events, 1) {
+		e := rec.events[0]
+		assert.Equal(t, "admin", e.Actor)
+		assert.Equal(t, int64(3), *e.ActorID)
+		assert.Equal(t, "req-42", e.RequestID)
+		assert.Equal(t, "192.0.2.7", e.IP)
+		assert.JSONEq(t, `{"running":true}`, string(e.After))
+		assert.Nil(t, e.Before)
+	}
+}
+
+func TestRecord_NoRecorder(t *testing.T) {
+	SetRecorder(nil)
+	// не должно паниковать
+	Record(httptest.NewRequest("GET", "/", nil), Entry{Action: ActionLogin})
+}
+
+// pruningRecorder запоминает вызовы Prune
+type pruningRecorder struct {
+	fakeRecorder
+	prunes []time.Time
+	keep   int
+}
+
+func (p *pruningRecorder) Prune(before time.Time, keep int) (int64, error) {
+	p.prunes = append(p.prunes, before)
+	p.keep = keep
+	return 0, nil
+}
+
+func TestRecord_PrunesOldEvents(t *testing.T) {
+	rec := &pruningRecorder{}
+	SetRecorder(rec)
+	SetRetention(24*time.Hour, 1000)
+	defer func() {
+		SetRecorder(nil)
+		SetRetention(0, 0)
+	}()
+
+	start := time.Now()
+	req := httptest.NewRequest("POST", "/api/v1/login", nil)
+	Record(req, Entry{Action: ActionLoginFailed, Actor: "ghost"})
+	Record(req, Entry{Action: ActionLoginFailed, Actor: "ghost"})
+
+	assert.Len(t, rec.events, 2)
+	// второе событие пришло раньше pruneInterval — повторной чистки нет
+	if assert.Len(t, rec.prunes, 1) {
+		assert.WithinDuration(t, start.Add(-24*time.Hour), rec.prunes[0], time.Minute)
+		assert.Equal(t, 1000, rec.keep)
+	}
+}
+
+func TestRecord_PrunesByCountWithoutAgeLimit(t *testing.T) {
+	rec := &pruningRecorder{}
+	SetRecorder(rec)
+	SetRetention(0, 500)
+	defer func() {
+		SetRecorder(nil)
+		SetRetention(0, 0)
+	}()
+
+	Record(httptest.NewRequest("POST", "/api/v1/login", nil), Entry{Action: ActionLoginFailed, Actor: "ghost"})
+
+	if assert.Len(t, rec.prunes, 1) {
+		assert.True(t, rec.prunes[0].IsZero(), "срок хранения не ограничен")
+		assert.Equal(t, 500, rec.keep)
+	}
+}
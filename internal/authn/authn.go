@@ -0,0 +1,121 @@
+// Package authn проверяет логин и пароль при входе. Источник учётных записей
+// выбирается настройкой AUTH_BACKEND: локальная таблица users или каталог LDAP.
+package authn
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"rim-router-service-ver-cgo/internal/loglevel"
+	"rim-router-service-ver-cgo/internal/models"
+	"rim-router-service-ver-cgo/internal/utils"
+)
+
+var (
+	// ErrUnknownUser — такой учётной записи нет.
+	ErrUnknownUser = errors.New("unknown user")
+	// ErrInvalidCredentials — учётная запись есть, пароль неверный.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrNoMappedRole — пароль верный, но ни одна группа каталога не даёт роли.
+	ErrNoMappedRole = errors.New("no role mapped for directory groups")
+	// ErrLocalAccount — пароль в каталоге верный, но под тем же именем уже
+	// есть локальная учётная запись со своим паролем; связывать их молча нельзя.
+	ErrLocalAccount = errors.New("local account with the same name exists")
+)
+
+// Authenticator проверяет пароль и возвращает локальную запись пользователя.
+// Состояние учётной записи (status, must_change_password) проверяет вызывающий код.
+// Прочие ошибки означают недоступность источника.
+type Authenticator interface {
+	Name() string
+	Authenticate(username, password string) (*models.User, error)
+}
+
+// Rechecker — источник, который без пароля подтверждает, что учётная запись
+// ещё существует и получает роль: так продление сессии пользователя каталога
+// видит удаление или исключение из групп. Ошибки — как у Authenticate.
+type Rechecker interface {
+	Recheck(username string) (*models.User, error)
+}
+
+// UserStore — локальные пользователи (в приложении — *models.UserRepository).
+type UserStore interface {
+	GetUserByUsername(username string) (*models.User, error)
+	CreateUser(username, passwordHash string, role int) error
+	UpdateUserRole(id int, role int) error
+	UpdatePasswordHash(id int64, passwordHash string) error
+}
+
+// Local — пароль проверяется по хэшу из таблицы users.
+type Local struct {
+	Users UserStore
+}
+
+func NewLocal(users UserStore) *Local {
+	return &Local{Users: users}
+}
+
+func (a *Local) Name() string { return "local" }
+
+func (a *Local) Authenticate(username, password string) (*models.User, error) {
+	user, err := a.Users.GetUserByUsername(username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUnknownUser
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !utils.CheckPassword(user.PasswordHash, password) {
+		return nil, ErrInvalidCredentials
+	}
+
+	// Параметры хэширования сменились — перехэшируем, пока пароль известен
+	if utils.NeedsRehash(user.PasswordHash) {
+		logger := loglevel.Logger("auth").With().Str("user", username).Logger()
+		if hash, err := utils.HashPassword(password); err != nil {
+			(&logger).Error().Err(err).Msg("Password rehash failed")
+		} else if err := a.Users.UpdatePasswordHash(user.ID, hash); err != nil {
+			(&logger).Error().Err(err).Msg("Failed to store rehashed password")
+		} else {
+			user.PasswordHash = hash
+			(&logger).Info().Msg("Password rehashed with current parameters")
+		}
+	}
+	return user, nil
+}
+
+// breakGlass направляет аварийные учётные записи в локальную базу, остальных — в основной источник.
+type breakGlass struct {
+	primary Authenticator
+	local   Authenticator
+	users   map[string]bool
+}
+
+// WithBreakGlass — вход через primary, но перечисленные пользователи всегда
+// проверяются локально, чтобы администратор мог войти при недоступном каталоге.
+func WithBreakGlass(primary, local Authenticator, usernames []string) Authenticator {
+	users := map[string]bool{}
+	for _, u := range usernames {
+		users[strings.ToLower(u)] = true
+	}
+	return &breakGlass{primary: primary, local: local, users: users}
+}
+
+func (a *breakGlass) Name() string { return a.primary.Name() }
+
+func (a *breakGlass) Authenticate(username, password string) (*models.User, error) {
+	if a.users[strings.ToLower(username)] {
+		return a.local.Authenticate(username, password)
+	}
+	return a.primary.Authenticate(username, password)
+}
+
+// Recheck передаёт проверку основному источнику; если он так не умеет,
+// учётная запись считается неизвестной.
+func (a *breakGlass) Recheck(username string) (*models.User, error) {
+	if r, ok := a.primary.(Rechecker); ok {
+		return r.Recheck(username)
+	}
+	return nil, ErrUnknownUser
+}
@@ -0,0 +1,120 @@
+package authn
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/models"
+	"rim-router-service-ver-cgo/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memUsers — таблица users в памяти вместо SQLite
+type memUsers struct {
+	users  map[string]*models.User
+	nextID int64
+}
+
+func newMemUsers() *memUsers {
+	return &memUsers{users: map[string]*models.User{}, nextID: 1}
+}
+
+func (s *memUsers) GetUserByUsername(username string) (*models.User, error) {
+	u, ok := s.users[username]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	c := *u
+	return &c, nil
+}
+
+func (s *memUsers) CreateUser(username, passwordHash string, role int) error {
+	if _, ok := s.users[username]; ok {
+		return errors.New("UNIQUE constraint failed: users.username")
+	}
+	s.users[username] = &models.User{ID: s.nextID, Username: username, PasswordHash: passwordHash, Role: role,
+		Status: models.UserStatusActive, CreatedAt: time.Now()}
+	s.nextID++
+	return nil
+}
+
+func (s *memUsers) UpdateUserRole(id int, role int) error {
+	for _, u := range s.users {
+		if u.ID == int64(id) {
+			u.Role = role
+		}
+	}
+	return nil
+}
+
+func (s *memUsers) UpdatePasswordHash(id int64, hash string) error {
+	for _, u := range s.users {
+		if u.ID == id {
+			u.PasswordHash = hash
+		}
+	}
+	return nil
+}
+
+func TestLocal_Authenticate(t *testing.T) {
+	users := newMemUsers()
+	hash, _ := utils.HashPassword("s3cret-Passw0rd")
+	_ = users.CreateUser("admin", hash, 1)
+	local := NewLocal(users)
+
+	user, err := local.Authenticate("admin", "s3cret-Passw0rd")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, user.Role)
+	}
+
+	_, err = local.Authenticate("admin", "wrong")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = local.Authenticate("ghost", "wrong")
+	assert.ErrorIs(t, err, ErrUnknownUser)
+}
+
+func TestLocal_RejectsExternalUsers(t *testing.T) {
+	users := newMemUsers()
+	_ = users.CreateUser("alice", models.ExternalPasswordHash, 0)
+
+	_, err := NewLocal(users).Authenticate("alice", models.ExternalPasswordHash)
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+// stubAuthenticator запоминает, к кому обратились
+type stubAuthenticator struct {
+	name  string
+	calls []string
+}
+
+func (s *stubAuthenticator) Name() string { return s.name }
+
+func (s *stubAuthenticator) Authenticate(username, password string) (*models.User, error) {
+	s.calls = append(s.calls, username)
+	return &models.User{Username: username}, nil
+}
+
+func TestWithBreakGlass_RoutesByUsername(t *testing.T) {
+	primary := &stubAuthenticator{name: "ldap"}
+	local := &stubAuthenticator{name: "local"}
+	a := WithBreakGlass(primary, local, []string{"Admin"})
+
+	_, _ = a.Authenticate("admin", "x")
+	_, _ = a.Authenticate("alice", "x")
+
+	assert.Equal(t, []string{"admin"}, local.calls)
+	assert.Equal(t, []string{"alice"}, primary.calls)
+	assert.Equal(t, "ldap", a.Name())
+}
+
+func mustHash(t *testing.T, password string) string {
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	return hash
+}
@@ -0,0 +1,211 @@
+package authn
+
+import (
+	"crypto/tls"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"rim-router-service-ver-cgo/internal/config"
+	"rim-router-service-ver-cgo/internal/loglevel"
+	"rim-router-service-ver-cgo/internal/middleware"
+	"rim-router-service-ver-cgo/internal/models"
+	"rim-router-service-ver-cgo/internal/utils"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// LDAP — вход по учётной записи каталога. При первом входе пользователь
+// заводится в таблице users (без локального пароля), роль при каждом входе
+// берётся из групп каталога по LDAP_ROLE_MAP.
+type LDAP struct {
+	cfg   config.LDAPConfig
+	users UserStore
+}
+
+func NewLDAP(cfg config.LDAPConfig, users UserStore) *LDAP {
+	return &LDAP{cfg: cfg, users: users}
+}
+
+func (a *LDAP) Name() string { return "ldap" }
+
+func (a *LDAP) Authenticate(username, password string) (*models.User, error) {
+	// пустой пароль в LDAP означает анонимный bind, который «успешен» всегда
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := a.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	entry, err := a.findUser(conn, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("ldap user bind: %w", err)
+	}
+
+	return a.syncUser(conn, username, entry)
+}
+
+// Recheck ищет учётную запись под сервисной записью и заново вычисляет роль
+// по группам — без пароля пользователя, при продлении сессии.
+func (a *LDAP) Recheck(username string) (*models.User, error) {
+	conn, err := a.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	entry, err := a.findUser(conn, username)
+	if err != nil {
+		return nil, err
+	}
+	return a.syncUser(conn, username, entry)
+}
+
+// findUser находит единственную запись пользователя под сервисной учётной записью.
+func (a *LDAP) findUser(conn *ldap.Conn, username string) (*ldap.Entry, error) {
+	if err := a.serviceBind(conn); err != nil {
+		return nil, err
+	}
+
+	res, err := conn.Search(ldap.NewSearchRequest(
+		a.cfg.UserBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		fmt.Sprintf(a.cfg.UserFilter, ldap.EscapeFilter(username)),
+		[]string{"dn", "memberOf"}, nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("ldap user search: %w", err)
+	}
+	switch len(res.Entries) {
+	case 0:
+		return nil, ErrUnknownUser
+	case 1:
+		return res.Entries[0], nil
+	default:
+		return nil, fmt.Errorf("ldap user search: %d entries match %q", len(res.Entries), username)
+	}
+}
+
+// syncUser вычисляет роль по группам записи и синхронизирует локального пользователя.
+func (a *LDAP) syncUser(conn *ldap.Conn, username string, entry *ldap.Entry) (*models.User, error) {
+	groups := entry.GetAttributeValues("memberOf")
+	if a.cfg.GroupBaseDN != "" {
+		// искать группы под сервисной учётной записью: у пользователя может не быть прав
+		if err := a.serviceBind(conn); err != nil {
+			return nil, err
+		}
+		gres, err := conn.Search(ldap.NewSearchRequest(
+			a.cfg.GroupBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+			fmt.Sprintf(a.cfg.GroupFilter, ldap.EscapeFilter(entry.DN)),
+			[]string{"dn"}, nil,
+		))
+		if err != nil {
+			return nil, fmt.Errorf("ldap group search: %w", err)
+		}
+		for _, g := range gres.Entries {
+			groups = append(groups, g.DN)
+		}
+	}
+
+	role, ok := a.mapRole(groups)
+	if !ok {
+		return nil, ErrNoMappedRole
+	}
+	return a.provision(strings.ToLower(username), role)
+}
+
+func (a *LDAP) connect() (*ldap.Conn, error) {
+	conn, err := ldap.DialURL(a.cfg.URL, ldap.DialWithDialer(&net.Dialer{Timeout: a.cfg.Timeout}))
+	if err != nil {
+		return nil, fmt.Errorf("ldap dial: %w", err)
+	}
+	conn.SetTimeout(a.cfg.Timeout)
+
+	if a.cfg.StartTLS {
+		host := a.cfg.URL
+		if u, err := url.Parse(a.cfg.URL); err == nil {
+			host = u.Hostname()
+		}
+		if err := conn.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("ldap starttls: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// serviceBind входит под сервисной учётной записью; без неё поиск анонимный.
+func (a *LDAP) serviceBind(conn *ldap.Conn) error {
+	if a.cfg.BindDN == "" {
+		return nil
+	}
+	if err := conn.Bind(a.cfg.BindDN, a.cfg.BindPassword); err != nil {
+		return fmt.Errorf("ldap service bind: %w", err)
+	}
+	return nil
+}
+
+// mapRole выбирает наибольшую роль среди групп пользователя.
+func (a *LDAP) mapRole(groups []string) (int, bool) {
+	role, ok := 0, false
+	for _, g := range groups {
+		if r, found := a.cfg.RoleMap[config.NormalizeDN(g)]; found && (!ok || r > role) {
+			role, ok = r, true
+		}
+	}
+	return role, ok
+}
+
+// provision заводит пользователя при первом входе и синхронизирует роль.
+func (a *LDAP) provision(username string, role int) (*models.User, error) {
+	logger := loglevel.Logger("auth").With().Str("user", username).Str("backend", "ldap").Logger()
+
+	user, err := a.users.GetUserByUsername(username)
+	if errors.Is(err, sql.ErrNoRows) {
+		if err := a.users.CreateUser(username, models.ExternalPasswordHash, role); err != nil {
+			// параллельный первый вход мог уже создать запись
+			if user, err2 := a.users.GetUserByUsername(username); err2 == nil {
+				return user, nil
+			}
+			return nil, err
+		}
+		(&logger).Info().Int("role", role).Msg("Directory user provisioned")
+		return a.users.GetUserByUsername(username)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.PasswordHash != models.ExternalPasswordHash {
+		// иначе вход через каталог захватил бы локальную (в том числе
+		// самостоятельно зарегистрированную) учётную запись и переписал её роль
+		(&logger).Warn().Int64("user_id", user.ID).Msg("Directory login refused: local account with the same name exists")
+		return nil, ErrLocalAccount
+	}
+
+	if user.Role != role {
+		if err := a.users.UpdateUserRole(int(user.ID), role); err != nil {
+			return nil, err
+		}
+		// прежние токены несут старую роль
+		if err := utils.RevokeUserTokens(user.ID); err != nil {
+			(&logger).Error().Err(err).Msg("Failed to revoke access tokens")
+		}
+		middleware.InvalidateUser(user.ID)
+		(&logger).Info().Int("old_role", user.Role).Int("new_role", role).Msg("Role synced from directory groups")
+		user.Role = role
+	}
+	return user, nil
+}
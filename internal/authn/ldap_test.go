@@ -0,0 +1,300 @@
+package authn
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/config"
+	"rim-router-service-ver-cgo/internal/models"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// =============================
+//   Заглушка LDAP-сервера
+// =============================
+
+type fakeEntry struct {
+	dn    string
+	attrs map[string][]string
+}
+
+// fakeDirectory — минимальный LDAP-сервер для тестов: simple bind и поиск
+// по фильтру вида (attr=value) внутри базового DN.
+type fakeDirectory struct {
+	passwords map[string]string // DN → пароль
+	entries   []fakeEntry
+
+	mu    sync.Mutex
+	binds []string
+}
+
+func startFakeLDAP(t *testing.T, d *fakeDirectory) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go d.serve(c)
+		}
+	}()
+	return "ldap://" + ln.Addr().String()
+}
+
+func (d *fakeDirectory) serve(c net.Conn) {
+	defer c.Close()
+	for {
+		p, err := ber.ReadPacket(c)
+		if err != nil || len(p.Children) < 2 {
+			return
+		}
+		id, _ := p.Children[0].Value.(int64)
+		op := p.Children[1]
+
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			dn, password := op.Children[1].Data.String(), op.Children[2].Data.String()
+			d.mu.Lock()
+			d.binds = append(d.binds, dn)
+			d.mu.Unlock()
+
+			code := uint16(ldap.LDAPResultInvalidCredentials)
+			if want, ok := d.passwords[dn]; ok && want == password {
+				code = ldap.LDAPResultSuccess
+			}
+			_, _ = c.Write(ldapMessage(id, ldapResult(ldap.ApplicationBindResponse, code)))
+
+		case ldap.ApplicationSearchRequest:
+			base := strings.ToLower(op.Children[0].Data.String())
+			filter, _ := ldap.DecompileFilter(op.Children[6])
+			for _, e := range d.entries {
+				if strings.HasSuffix(strings.ToLower(e.dn), base) && e.matches(filter) {
+					_, _ = c.Write(ldapMessage(id, e.packet()))
+				}
+			}
+			_, _ = c.Write(ldapMessage(id, ldapResult(ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess)))
+
+		case ldap.ApplicationUnbindRequest:
+			return
+		}
+	}
+}
+
+// matches понимает только фильтр равенства (attr=value)
+func (e fakeEntry) matches(filter string) bool {
+	f := strings.TrimSuffix(strings.TrimPrefix(filter, "("), ")")
+	attr, value, ok := strings.Cut(f, "=")
+	if !ok {
+		return false
+	}
+	for _, v := range e.attrs[attr] {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e fakeEntry) packet() *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.dn, "Object Name"))
+	attrs := ber.NewSequence("Attributes")
+	for name, values := range e.attrs {
+		a := ber.NewSequence("Attribute")
+		a.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+		for _, v := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, "Value"))
+		}
+		a.AppendChild(set)
+		attrs.AppendChild(a)
+	}
+	op.AppendChild(attrs)
+	return op
+}
+
+func ldapResult(tag ber.Tag, code uint16) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "Result Code"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Diagnostic Message"))
+	return op
+}
+
+func ldapMessage(id int64, op *ber.Packet) []byte {
+	p := ber.NewSequence("LDAP Message")
+	p.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, "Message ID"))
+	p.AppendChild(op)
+	return p.Bytes()
+}
+
+// =============================
+//   Тесты
+// =============================
+
+const (
+	adminsDN  = "cn=admins,ou=groups,dc=example,dc=org"
+	techsDN   = "cn=techs,ou=groups,dc=example,dc=org"
+	serviceDN = "cn=router,ou=services,dc=example,dc=org"
+)
+
+func testDirectory() *fakeDirectory {
+	return &fakeDirectory{
+		passwords: map[string]string{
+			serviceDN:                               "service-pass",
+			"uid=alice,ou=people,dc=example,dc=org": "alice-pass",
+			"uid=bob,ou=people,dc=example,dc=org":   "bob-pass",
+			"uid=carol,ou=people,dc=example,dc=org": "carol-pass",
+		},
+		entries: []fakeEntry{
+			{dn: "uid=alice,ou=people,dc=example,dc=org", attrs: map[string][]string{
+				"uid": {"alice"}, "memberOf": {techsDN, "CN=Admins, OU=Groups, DC=Example, DC=Org"}}},
+			{dn: "uid=bob,ou=people,dc=example,dc=org", attrs: map[string][]string{
+				"uid": {"bob"}}},
+			{dn: "uid=carol,ou=people,dc=example,dc=org", attrs: map[string][]string{
+				"uid": {"carol"}, "memberOf": {"cn=printers,ou=groups,dc=example,dc=org"}}},
+			{dn: techsDN, attrs: map[string][]string{
+				"member": {"uid=bob,ou=people,dc=example,dc=org"}}},
+		},
+	}
+}
+
+func testLDAPConfig(url string) config.LDAPConfig {
+	return config.LDAPConfig{
+		Backend:      config.AuthBackendLDAP,
+		URL:          url,
+		BindDN:       serviceDN,
+		BindPassword: "service-pass",
+		Timeout:      2 * time.Second,
+		UserBaseDN:   "ou=people,dc=example,dc=org",
+		UserFilter:   "(uid=%s)",
+		GroupFilter:  "(member=%s)",
+		RoleMap:      map[string]int{adminsDN: 1, techsDN: 0},
+	}
+}
+
+func TestLDAP_ProvisionsUserWithMappedRole(t *testing.T) {
+	dir := testDirectory()
+	users := newMemUsers()
+	a := NewLDAP(testLDAPConfig(startFakeLDAP(t, dir)), users)
+
+	user, err := a.Authenticate("Alice", "alice-pass")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "alice", user.Username)
+	assert.Equal(t, 1, user.Role, "наибольшая роль среди групп")
+	assert.Equal(t, models.ExternalPasswordHash, users.users["alice"].PasswordHash)
+
+	// повторный вход не создаёт второго пользователя
+	again, err := a.Authenticate("alice", "alice-pass")
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, again.ID)
+	assert.Len(t, users.users, 1)
+
+	assert.Contains(t, dir.binds, serviceDN)
+	assert.Contains(t, dir.binds, "uid=alice,ou=people,dc=example,dc=org")
+}
+
+func TestLDAP_Errors(t *testing.T) {
+	a := NewLDAP(testLDAPConfig(startFakeLDAP(t, testDirectory())), newMemUsers())
+
+	_, err := a.Authenticate("alice", "wrong")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = a.Authenticate("alice", "")
+	assert.ErrorIs(t, err, ErrInvalidCredentials, "пустой пароль — анонимный bind")
+
+	_, err = a.Authenticate("mallory", "x")
+	assert.ErrorIs(t, err, ErrUnknownUser)
+
+	_, err = a.Authenticate("carol", "carol-pass")
+	assert.ErrorIs(t, err, ErrNoMappedRole)
+}
+
+func TestLDAP_RefusesLocalAccount(t *testing.T) {
+	users := newMemUsers()
+	_ = users.CreateUser("alice", "$2a$10$localhash", 0)
+
+	_, err := NewLDAP(testLDAPConfig(startFakeLDAP(t, testDirectory())), users).Authenticate("alice", "alice-pass")
+	assert.ErrorIs(t, err, ErrLocalAccount)
+	assert.Equal(t, 0, users.users["alice"].Role, "роль локальной записи не меняется")
+	assert.Equal(t, "$2a$10$localhash", users.users["alice"].PasswordHash)
+}
+
+func TestLDAP_GroupSearchSyncsRole(t *testing.T) {
+	cfg := testLDAPConfig(startFakeLDAP(t, testDirectory()))
+	cfg.GroupBaseDN = "ou=groups,dc=example,dc=org"
+
+	users := newMemUsers()
+	_ = users.CreateUser("bob", models.ExternalPasswordHash, 1)
+
+	user, err := NewLDAP(cfg, users).Authenticate("bob", "bob-pass")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, user.Role, "роль понижена по группам каталога")
+		assert.Equal(t, 0, users.users["bob"].Role)
+	}
+}
+
+func TestLDAP_BackendUnavailable(t *testing.T) {
+	cfg := testLDAPConfig(startFakeLDAP(t, testDirectory()))
+	cfg.BindPassword = "wrong"
+	_, err := NewLDAP(cfg, newMemUsers()).Authenticate("alice", "alice-pass")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrInvalidCredentials, "ошибка сервисной записи — не ошибка пользователя")
+
+	// каталог недоступен — аварийный администратор входит локально
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := ln.Addr().String()
+	ln.Close()
+
+	users := newMemUsers()
+	_ = users.CreateUser("admin", mustHash(t, "local-Passw0rd"), 1)
+	a := WithBreakGlass(NewLDAP(testLDAPConfig("ldap://"+addr), users), NewLocal(users), []string{"admin"})
+
+	_, err = a.Authenticate("alice", "alice-pass")
+	assert.Error(t, err)
+
+	user, err := a.Authenticate("admin", "local-Passw0rd")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, user.Role)
+	}
+}
+
+func TestLDAP_Recheck(t *testing.T) {
+	dir := testDirectory()
+	users := newMemUsers()
+	a := NewLDAP(testLDAPConfig(startFakeLDAP(t, dir)), users)
+
+	_, err := a.Authenticate("alice", "alice-pass")
+	assert.NoError(t, err)
+
+	// без пароля: только сервисная учётная запись
+	dir.mu.Lock()
+	dir.binds = nil
+	dir.mu.Unlock()
+	user, err := a.Recheck("alice")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, user.Role)
+	}
+	assert.Equal(t, []string{serviceDN}, dir.binds)
+
+	// запись удалена из каталога или лишилась групп
+	dir.entries = dir.entries[1:]
+	_, err = a.Recheck("alice")
+	assert.ErrorIs(t, err, ErrUnknownUser)
+	_, err = a.Recheck("carol")
+	assert.ErrorIs(t, err, ErrNoMappedRole)
+}
@@ -0,0 +1,21 @@
+package config
+
+import "time"
+
+// AuditConfig — сколько хранить журнал аудита. Старые события удаляются,
+// чтобы поток неудачных входов не раздувал таблицу audit_events.
+type AuditConfig struct {
+	Retention time.Duration // события старше удаляются
+	MaxEvents int           // сколько последних событий хранить; 0 — без ограничения
+}
+
+func GetAuditConfig() AuditConfig {
+	maxEvents := envInt("AUDIT_MAX_EVENTS", 1000000)
+	if maxEvents < 0 {
+		maxEvents = 0
+	}
+	return AuditConfig{
+		Retention: envDuration("AUDIT_RETENTION", 365*24*time.Hour),
+		MaxEvents: maxEvents,
+	}
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"reflect"
+	"time"
+)
+
+// Redacted — значение секрета в выгрузке конфигурации
+const Redacted = "[redacted]"
+
+func redact(s string) string {
+	if s == "" {
+		return ""
+	}
+	return Redacted
+}
+
+// Effective — действующая конфигурация по разделам для диагностики.
+// Секреты заменены на Redacted, длительности записаны строкой ("15m0s").
+func Effective() map[string]interface{} {
+	jwt := GetJWTConfig()
+	jwt.Secret = redact(jwt.Secret)
+
+	ldap := GetLDAPConfig()
+	ldap.BindPassword = redact(ldap.BindPassword)
+
+	metrics := GetMetricsConfig()
+	metrics.Token = redact(metrics.Token)
+
+	// секреты клиентов интроспекции не выводятся, только их идентификаторы
+	clients := []string{}
+	for id := range GetIntrospectionConfig().Clients {
+		clients = append(clients, id)
+	}
+
+	sections := map[string]interface{}{
+		"audit":         GetAuditConfig(),
+		"jwt":           jwt,
+		"ldap":          ldap,
+		"locale":        GetLocaleConfig(),
+		"lockout":       GetLockoutConfig(),
+		"metrics":       metrics,
+		"mfa":           GetMFAConfig(),
+		"password":      GetPasswordConfig(),
+		"proxy":         GetProxyConfig(),
+		"rate_limit":    GetRateLimitConfig(),
+		"registration":  GetRegistrationConfig(),
+		"revalidation":  GetRevalidationConfig(),
+		"session":       GetSessionConfig(),
+		"introspection": map[string]interface{}{"clients": clients},
+	}
+	for name, v := range sections {
+		sections[name] = plain(reflect.ValueOf(v))
+	}
+	return sections
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// plain переводит структуру в map, чтобы длительности стали читаемыми строками
+func plain(v reflect.Value) interface{} {
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		out := map[string]interface{}{}
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Type().Field(i); f.IsExported() {
+				out[f.Name] = plain(v.Field(i))
+			}
+		}
+		return out
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return plain(v.Elem())
+	}
+	return v.Interface()
+}
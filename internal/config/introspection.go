@@ -0,0 +1,26 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// IntrospectionConfig — клиенты, которым разрешено вызывать /api/v1/introspect
+// (локальные сервисы: веб-интерфейс ТИР, Modbus-шлюз и т.п.).
+type IntrospectionConfig struct {
+	Clients map[string]string // client_id → секрет
+}
+
+// GetIntrospectionConfig читает INTROSPECTION_CLIENTS в формате
+// "tir-ui:secret1,modbus:secret2". Пустой список — интроспекция выключена.
+func GetIntrospectionConfig() IntrospectionConfig {
+	cfg := IntrospectionConfig{Clients: map[string]string{}}
+	for _, pair := range strings.Split(os.Getenv("INTROSPECTION_CLIENTS"), ",") {
+		id, secret, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || id == "" || secret == "" {
+			continue
+		}
+		cfg.Clients[id] = secret
+	}
+	return cfg
+}
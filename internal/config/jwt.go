@@ -1,25 +1,64 @@
 package config
 
 import (
+	"errors"
 	"os"
+	"strings"
 	"time"
 )
 
+// LegacyDefaultJWTSecret — секрет, который раньше подставлялся, если JWT_SECRET не задан.
+// Он есть в открытом коде, поэтому запуск с ним разрешён только в режиме разработки.
+const LegacyDefaultJWTSecret = "your-default-super-secret-key-change-in-production"
+
+// Алгоритмы подписи access-токенов
+const (
+	JWTAlgHS256 = "HS256"
+	JWTAlgRS256 = "RS256"
+	JWTAlgEdDSA = "EdDSA"
+)
+
 type JWTConfig struct {
+	// Secret — HMAC-секрет из JWT_SECRET. Нужен только для проверки токенов без kid,
+	// выданных до появления ротации ключей; подписываются токены ключами из БД.
 	Secret            string
+	Algorithm         string
+	KeyDir            string        // каталог файлов ключей подписи; в БД — только метаданные
+	RotationInterval  time.Duration // 0 — ключи не ротируются
 	AccessExpiration  time.Duration
 	RefreshExpiration time.Duration
 }
 
 func GetJWTConfig() JWTConfig {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "your-default-super-secret-key-change-in-production" // Заменить в продакшене!
+	alg := envString("JWT_ALGORITHM", JWTAlgEdDSA)
+	switch strings.ToUpper(alg) {
+	case "HS256":
+		alg = JWTAlgHS256
+	case "RS256":
+		alg = JWTAlgRS256
+	default:
+		alg = JWTAlgEdDSA
+	}
+
+	rotation := envDuration("JWT_ROTATION_INTERVAL", 30*24*time.Hour)
+	if strings.TrimSpace(os.Getenv("JWT_ROTATION_INTERVAL")) == "0" {
+		rotation = 0
 	}
 
 	return JWTConfig{
-		Secret:            secret,
+		Secret:            os.Getenv("JWT_SECRET"),
+		Algorithm:         alg,
+		KeyDir:            envString("JWT_KEY_DIR", "./keys"),
+		RotationInterval:  rotation,
 		AccessExpiration:  15 * time.Minute,   // access token: 15 минут
 		RefreshExpiration: 7 * 24 * time.Hour, // refresh token: 7 дней
 	}
 }
+
+// Validate запрещает запуск с секретом по умолчанию, если не включён режим разработки.
+func (c JWTConfig) Validate(dev bool) error {
+	if c.Secret == LegacyDefaultJWTSecret && !dev {
+		return errors.New("JWT_SECRET is set to the public default value; unset it or start with -dev")
+	}
+	return nil
+}
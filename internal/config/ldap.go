@@ -0,0 +1,135 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Источники учётных записей для входа по паролю
+const (
+	AuthBackendLocal = "local" // таблица users
+	AuthBackendLDAP  = "ldap"  // каталог LDAP/AD с локальным аварийным администратором
+)
+
+// LDAPConfig — вход через каталог. Пользователь ищется под сервисной учётной
+// записью (или анонимно), затем проверяется bind'ом под его DN.
+type LDAPConfig struct {
+	Backend string
+
+	URL      string // ldap://host:389 или ldaps://host:636
+	StartTLS bool
+	// AllowInsecure разрешает ldap:// без StartTLS: пароли пользователей и
+	// сервисной учётной записи пойдут по сети открытым текстом
+	AllowInsecure bool
+	BindDN        string // сервисная учётная запись для поиска; пусто — анонимный поиск
+	BindPassword  string
+	Timeout       time.Duration
+
+	UserBaseDN string
+	UserFilter string // %s заменяется экранированным логином
+
+	// Группы берутся из memberOf записи пользователя, а если задан GroupBaseDN —
+	// поиском групп по GroupFilter (%s — DN пользователя).
+	GroupBaseDN string
+	GroupFilter string
+
+	// RoleMap — DN группы (см. NormalizeDN) → роль. Пользователь получает
+	// наибольшую роль среди своих групп; без подходящей группы вход запрещён.
+	RoleMap map[string]int
+
+	// BreakGlassUsers всегда входят по локальному паролю — на случай, когда каталог недоступен.
+	BreakGlassUsers []string
+}
+
+func GetLDAPConfig() LDAPConfig {
+	backend := strings.ToLower(envString("AUTH_BACKEND", AuthBackendLocal))
+	if backend != AuthBackendLDAP {
+		backend = AuthBackendLocal
+	}
+
+	breakGlass := envString("LDAP_BREAK_GLASS_USERS", envString("ADMIN_USERNAME", "admin"))
+
+	return LDAPConfig{
+		Backend:         backend,
+		URL:             envString("LDAP_URL", ""),
+		StartTLS:        envBool("LDAP_START_TLS", false),
+		AllowInsecure:   envBool("LDAP_ALLOW_INSECURE", false),
+		BindDN:          envString("LDAP_BIND_DN", ""),
+		BindPassword:    os.Getenv("LDAP_BIND_PASSWORD"),
+		Timeout:         envDuration("LDAP_TIMEOUT", 5*time.Second),
+		UserBaseDN:      envString("LDAP_USER_BASE_DN", ""),
+		UserFilter:      envString("LDAP_USER_FILTER", "(uid=%s)"),
+		GroupBaseDN:     envString("LDAP_GROUP_BASE_DN", ""),
+		GroupFilter:     envString("LDAP_GROUP_FILTER", "(member=%s)"),
+		RoleMap:         parseRoleMap(os.Getenv("LDAP_ROLE_MAP")),
+		BreakGlassUsers: splitList(breakGlass),
+	}
+}
+
+// Validate проверяет настройки, если включён вход через LDAP.
+func (c LDAPConfig) Validate() error {
+	if c.Backend != AuthBackendLDAP {
+		return nil
+	}
+	if c.URL == "" || c.UserBaseDN == "" {
+		return errors.New("AUTH_BACKEND=ldap requires LDAP_URL and LDAP_USER_BASE_DN")
+	}
+	if strings.HasPrefix(strings.ToLower(c.URL), "ldap://") && !c.StartTLS && !c.AllowInsecure {
+		return errors.New("LDAP_URL uses plain ldap:// without LDAP_START_TLS; passwords would be sent in clear text (set LDAP_ALLOW_INSECURE=true to allow)")
+	}
+	if !strings.Contains(c.UserFilter, "%s") {
+		return errors.New("LDAP_USER_FILTER must contain %s")
+	}
+	if len(c.RoleMap) == 0 {
+		return errors.New("LDAP_ROLE_MAP is empty: no directory user could log in")
+	}
+	// роли те же, что может назначить администратор
+	for dn, role := range c.RoleMap {
+		if role > 2 {
+			return fmt.Errorf("LDAP_ROLE_MAP: role %d for %q is out of range 0..2", role, dn)
+		}
+	}
+	return nil
+}
+
+// parseRoleMap разбирает "cn=admins,ou=groups,dc=example,dc=org:1;cn=techs,...:0".
+// DN содержит запятые, поэтому записи разделяются ';', а роль — последним ':'.
+func parseRoleMap(s string) map[string]int {
+	m := map[string]int{}
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		i := strings.LastIndex(entry, ":")
+		if i <= 0 {
+			continue
+		}
+		role, err := strconv.Atoi(strings.TrimSpace(entry[i+1:]))
+		if err != nil || role < 0 {
+			continue
+		}
+		m[NormalizeDN(entry[:i])] = role
+	}
+	return m
+}
+
+// NormalizeDN приводит DN к виду для сравнения: нижний регистр, без пробелов вокруг запятых.
+func NormalizeDN(dn string) string {
+	parts := strings.Split(strings.ToLower(dn), ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return strings.Join(parts, ",")
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
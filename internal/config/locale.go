@@ -0,0 +1,22 @@
+package config
+
+import "fmt"
+
+// LocaleConfig — язык сообщений API для запросов без Accept-Language и без
+// настройки пользователя.
+type LocaleConfig struct {
+	Default string // ru | en
+}
+
+func (c LocaleConfig) Validate() error {
+	if c.Default != "ru" && c.Default != "en" {
+		return fmt.Errorf("API_DEFAULT_LOCALE must be ru or en, got %q", c.Default)
+	}
+	return nil
+}
+
+func GetLocaleConfig() LocaleConfig {
+	return LocaleConfig{
+		Default: envString("API_DEFAULT_LOCALE", "en"),
+	}
+}
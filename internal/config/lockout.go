@@ -0,0 +1,22 @@
+package config
+
+import "time"
+
+// LockoutConfig — параметры защиты входа от перебора паролей.
+type LockoutConfig struct {
+	MaxUserFailures int           // неудачных попыток на логин до блокировки
+	MaxIPFailures   int           // неудачных попыток с одного IP до блокировки
+	BaseLockout     time.Duration // первая блокировка, далее удваивается
+	MaxLockout      time.Duration // верхняя граница блокировки
+	FailureWindow   time.Duration // через сколько без ошибок счётчик сбрасывается
+}
+
+func GetLockoutConfig() LockoutConfig {
+	return LockoutConfig{
+		MaxUserFailures: envInt("LOGIN_MAX_USER_FAILURES", 5),
+		MaxIPFailures:   envInt("LOGIN_MAX_IP_FAILURES", 20),
+		BaseLockout:     envDuration("LOGIN_BASE_LOCKOUT", time.Minute),
+		MaxLockout:      envDuration("LOGIN_MAX_LOCKOUT", time.Hour),
+		FailureWindow:   envDuration("LOGIN_FAILURE_WINDOW", 24*time.Hour),
+	}
+}
@@ -0,0 +1,25 @@
+package config
+
+import "errors"
+
+// MetricsConfig — эндпоинт /metrics для Prometheus. По умолчанию выключен.
+type MetricsConfig struct {
+	Enabled bool
+	Token   string // опрос требует Authorization: Bearer <token>
+}
+
+func GetMetricsConfig() MetricsConfig {
+	return MetricsConfig{
+		Enabled: envBool("METRICS_ENABLED", false),
+		Token:   envString("METRICS_TOKEN", ""),
+	}
+}
+
+// Validate не даёт открыть /metrics без токена: метрики раскрывают
+// состояние устройства и число неудачных входов.
+func (c MetricsConfig) Validate() error {
+	if c.Enabled && c.Token == "" {
+		return errors.New("METRICS_ENABLED=true requires METRICS_TOKEN")
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// MFAConfig — двухфакторная аутентификация (TOTP).
+type MFAConfig struct {
+	Issuer           string        // имя сервиса в приложении-аутентификаторе
+	RequireForAdmins bool          // вход с ролью >= 1 только с подключённой MFA
+	ChallengeTTL     time.Duration // срок жизни токена второго шага входа
+}
+
+func GetMFAConfig() MFAConfig {
+	return MFAConfig{
+		Issuer:           envString("MFA_ISSUER", "RIM Router"),
+		RequireForAdmins: envBool("MFA_REQUIRE_ADMINS", false),
+		ChallengeTTL:     envDuration("MFA_CHALLENGE_TTL", 5*time.Minute),
+	}
+}
+
+// Validate проверяет, что токен второго шага живёт не дольше access-токена:
+// отзыв всех токенов пользователя хранится ровно столько, сколько живёт
+// access-токен, и более долгий mfa_token после этого снова стал бы действительным.
+func (c MFAConfig) Validate(accessTTL time.Duration) error {
+	if c.ChallengeTTL <= 0 || c.ChallengeTTL > accessTTL {
+		return fmt.Errorf("MFA_CHALLENGE_TTL must be between 0 and the access token lifetime (%s)", accessTTL)
+	}
+	return nil
+}
+
+// Required сообщает, обязательна ли MFA для роли.
+func (c MFAConfig) Required(role int) bool {
+	return c.RequireForAdmins && role >= 1
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	HashBcrypt   = "bcrypt"
+	HashArgon2id = "argon2id"
+)
+
+// PasswordConfig — политика паролей и параметры хэширования.
+type PasswordConfig struct {
+	MinLength        int  // минимальная длина
+	MaxLength        int  // bcrypt учитывает только первые 72 байта
+	MinClasses       int  // сколько классов символов (строчные, заглавные, цифры, прочие) нужно
+	CheckDenylist    bool // запрет распространённых паролей (встроенный список)
+	DisallowUsername bool // пароль не должен совпадать с логином
+
+	Algorithm     string // bcrypt | argon2id
+	BcryptCost    int
+	Argon2Memory  uint32 // KiB
+	Argon2Time    uint32
+	Argon2Threads uint8
+}
+
+// Допустимые параметры хэширования. Вне диапазона argon2.IDKey паникует
+// (time или threads = 0), а bcrypt не строит хэш вовсе.
+const (
+	minBcryptCost     = 4 // bcrypt.MinCost
+	maxBcryptCost     = 31
+	minArgon2MemoryKB = 1024
+	maxArgon2MemoryKB = 4 * 1024 * 1024
+	maxArgon2Time     = 100
+	maxArgon2Threads  = 255
+)
+
+func GetPasswordConfig() PasswordConfig {
+	cfg, _ := readPasswordConfig()
+	return cfg
+}
+
+// PasswordConfigWarnings перечисляет параметры хэширования, заменённые
+// значениями по умолчанию; main пишет их в лог при старте.
+func PasswordConfigWarnings() []string {
+	_, warnings := readPasswordConfig()
+	return warnings
+}
+
+func readPasswordConfig() (PasswordConfig, []string) {
+	var warnings []string
+	// intInRange возвращает значение переменной или def, если оно вне [min, max]
+	intInRange := func(key string, def, min, max int) int {
+		v := envInt(key, def)
+		if v < min || v > max {
+			warnings = append(warnings, fmt.Sprintf("%s=%d is out of range [%d, %d], using %d", key, v, min, max, def))
+			return def
+		}
+		return v
+	}
+
+	cfg := PasswordConfig{
+		MinLength:        envInt("PASSWORD_MIN_LENGTH", 8),
+		MaxLength:        envInt("PASSWORD_MAX_LENGTH", 72),
+		MinClasses:       envInt("PASSWORD_MIN_CLASSES", 3),
+		CheckDenylist:    envBool("PASSWORD_DENYLIST", true),
+		DisallowUsername: true,
+
+		Algorithm:     strings.ToLower(envString("PASSWORD_HASH", HashBcrypt)),
+		BcryptCost:    intInRange("PASSWORD_BCRYPT_COST", 10, minBcryptCost, maxBcryptCost),
+		Argon2Memory:  uint32(intInRange("PASSWORD_ARGON2_MEMORY_KB", 19*1024, minArgon2MemoryKB, maxArgon2MemoryKB)),
+		Argon2Time:    uint32(intInRange("PASSWORD_ARGON2_TIME", 2, 1, maxArgon2Time)),
+		Argon2Threads: uint8(intInRange("PASSWORD_ARGON2_THREADS", 1, 1, maxArgon2Threads)),
+	}
+	if cfg.Algorithm != HashArgon2id {
+		cfg.Algorithm = HashBcrypt
+	}
+	return cfg, warnings
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// ProxyConfig — обратные прокси, которым доверяются X-Forwarded-For и
+// X-Real-IP. Без списка адрес клиента — всегда адрес TCP-соединения, иначе
+// любой клиент подменит заголовком свой IP для блокировок и лимитов.
+type ProxyConfig struct {
+	TrustedProxies []string // IP или CIDR: "127.0.0.1,10.0.0.0/8"
+}
+
+func GetProxyConfig() ProxyConfig {
+	return ProxyConfig{
+		TrustedProxies: splitList(envString("TRUSTED_PROXIES", "")),
+	}
+}
+
+// Prefixes разбирает TrustedProxies; одиночный адрес — сеть /32 (/128)
+func (c ProxyConfig) Prefixes() ([]netip.Prefix, error) {
+	var out []netip.Prefix
+	for _, s := range c.TrustedProxies {
+		if strings.Contains(s, "/") {
+			p, err := netip.ParsePrefix(s)
+			if err != nil {
+				return nil, fmt.Errorf("TRUSTED_PROXIES: invalid CIDR %q", s)
+			}
+			out = append(out, p.Masked())
+			continue
+		}
+		a, err := netip.ParseAddr(s)
+		if err != nil {
+			return nil, fmt.Errorf("TRUSTED_PROXIES: invalid address %q", s)
+		}
+		a = a.Unmap()
+		out = append(out, netip.PrefixFrom(a, a.BitLen()))
+	}
+	return out, nil
+}
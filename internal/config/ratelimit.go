@@ -0,0 +1,38 @@
+package config
+
+// RateLimit — параметры token bucket: Rate токенов в секунду, не более Burst
+// в запасе. Rate = 0 отключает ограничение.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitConfig — лимиты для групп маршрутов.
+type RateLimitConfig struct {
+	Global       RateLimit // все запросы, по IP
+	Auth         RateLimit // register/login/refresh, по IP
+	Downloads    RateLimit // скачивание архивов логов, по пользователю
+	MaxDownloads int       // одновременно собираемых архивов на весь сервис
+}
+
+func GetRateLimitConfig() RateLimitConfig {
+	cfg := RateLimitConfig{
+		Global: RateLimit{
+			Rate:  envFloat("RATE_LIMIT_GLOBAL_RPS", 20),
+			Burst: envInt("RATE_LIMIT_GLOBAL_BURST", 50),
+		},
+		Auth: RateLimit{
+			Rate:  envFloat("RATE_LIMIT_AUTH_RPS", 0.2), // ~12 в минуту
+			Burst: envInt("RATE_LIMIT_AUTH_BURST", 10),
+		},
+		Downloads: RateLimit{
+			Rate:  envFloat("RATE_LIMIT_DOWNLOAD_RPS", 0.1), // раз в 10 секунд
+			Burst: envInt("RATE_LIMIT_DOWNLOAD_BURST", 2),
+		},
+		MaxDownloads: envInt("RATE_LIMIT_MAX_DOWNLOADS", 2),
+	}
+	if cfg.MaxDownloads < 1 {
+		cfg.MaxDownloads = 1
+	}
+	return cfg
+}
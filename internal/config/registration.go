@@ -0,0 +1,34 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	RegistrationOpen     = "open"     // любой может зарегистрироваться
+	RegistrationDisabled = "disabled" // регистрация закрыта
+	RegistrationApproval = "approval" // новый пользователь ждёт одобрения администратора
+	RegistrationInvite   = "invite"   // только по одноразовому коду приглашения
+)
+
+// RegistrationConfig — режим работы /api/v1/register.
+type RegistrationConfig struct {
+	Mode      string
+	InviteTTL time.Duration // срок действия кода приглашения по умолчанию
+}
+
+// GetRegistrationConfig читает REGISTRATION_MODE. По умолчанию регистрация
+// закрыта: полевые устройства не должны принимать анонимные регистрации.
+func GetRegistrationConfig() RegistrationConfig {
+	mode := strings.ToLower(envString("REGISTRATION_MODE", RegistrationDisabled))
+	switch mode {
+	case RegistrationOpen, RegistrationApproval, RegistrationInvite:
+	default:
+		mode = RegistrationDisabled
+	}
+	return RegistrationConfig{
+		Mode:      mode,
+		InviteTTL: envDuration("REGISTRATION_INVITE_TTL", 72*time.Hour),
+	}
+}
@@ -0,0 +1,16 @@
+package config
+
+import "time"
+
+// RevalidationConfig — повторная проверка пользователя по БД на каждый запрос.
+type RevalidationConfig struct {
+	Enabled  bool
+	CacheTTL time.Duration // сколько держать пользователя в кэше между запросами
+}
+
+func GetRevalidationConfig() RevalidationConfig {
+	return RevalidationConfig{
+		Enabled:  envBool("AUTH_REVALIDATE_USER", false),
+		CacheTTL: envDuration("AUTH_REVALIDATE_TTL", 5*time.Second),
+	}
+}
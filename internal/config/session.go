@@ -0,0 +1,48 @@
+package config
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// SessionConfig — атрибуты cookie сессии и режим cookie-сессий для браузера.
+type SessionConfig struct {
+	// CookieMode: access-токен выдаётся в HttpOnly cookie и не попадает в тело
+	// ответа, поэтому XSS в интерфейсе не может его украсть. Запросы с cookie,
+	// меняющие состояние, требуют CSRF-токен (double-submit) и проверку Origin.
+	CookieMode bool
+
+	Secure   bool
+	Domain   string // пусто — cookie только для текущего хоста
+	SameSite http.SameSite
+
+	// TrustedOrigins — дополнительные Origin (scheme://host[:port]), кроме собственного хоста.
+	TrustedOrigins []string
+}
+
+func GetSessionConfig() SessionConfig {
+	sameSite := http.SameSiteStrictMode
+	switch strings.ToLower(envString("COOKIE_SAMESITE", "strict")) {
+	case "lax":
+		sameSite = http.SameSiteLaxMode
+	case "none":
+		sameSite = http.SameSiteNoneMode
+	}
+
+	return SessionConfig{
+		CookieMode:     envBool("SESSION_COOKIE_MODE", false),
+		Secure:         envBool("COOKIE_SECURE", false),
+		Domain:         envString("COOKIE_DOMAIN", ""),
+		SameSite:       sameSite,
+		TrustedOrigins: splitList(envString("CSRF_TRUSTED_ORIGINS", "")),
+	}
+}
+
+// Validate: браузеры отбрасывают SameSite=None без Secure.
+func (c SessionConfig) Validate() error {
+	if c.SameSite == http.SameSiteNoneMode && !c.Secure {
+		return errors.New("COOKIE_SAMESITE=none requires COOKIE_SECURE=true")
+	}
+	return nil
+}
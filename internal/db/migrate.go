@@ -0,0 +1,275 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"rim-router-service-ver-cgo/internal/models"
+	"rim-router-service-ver-cgo/internal/utils"
+)
+
+// migration описывает один шаг изменения схемы. Номер версии хранится
+// в PRAGMA user_version, поэтому каждый шаг применяется ровно один раз.
+type migration struct {
+	version int
+	name    string
+	up      func(tx *sql.Tx) error
+}
+
+var migrations = []migration{
+	{version: 1, name: "create users and refresh_tokens", up: execSQL(`
+		CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			username TEXT UNIQUE NOT NULL,
+			password_hash TEXT NOT NULL,
+			role INTEGER NOT NULL DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);
+
+		CREATE TABLE IF NOT EXISTS refresh_tokens (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			token TEXT NOT NULL UNIQUE,
+			expires_at DATETIME NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+		CREATE INDEX IF NOT EXISTS idx_refresh_user_id ON refresh_tokens(user_id);
+		CREATE INDEX IF NOT EXISTS idx_refresh_token ON refresh_tokens(token);
+	`)},
+	{version: 2, name: "hash stored refresh tokens", up: hashRefreshTokens},
+	{version: 3, name: "create login_attempts", up: execSQL(`
+		CREATE TABLE IF NOT EXISTS login_attempts (
+			key TEXT PRIMARY KEY,
+			failures INTEGER NOT NULL DEFAULT 0,
+			last_failure DATETIME NOT NULL,
+			locked_until DATETIME
+		);
+	`)},
+	{version: 4, name: "add users.must_change_password", up: addMustChangePassword},
+	{version: 5, name: "add users.status and invite_codes", up: execSQL(`
+		ALTER TABLE users ADD COLUMN status TEXT NOT NULL DEFAULT 'active';
+
+		CREATE TABLE IF NOT EXISTS invite_codes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			code_hash TEXT NOT NULL UNIQUE,
+			created_by INTEGER,
+			expires_at DATETIME NOT NULL,
+			used_by TEXT,
+			used_at DATETIME,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+		);
+	`)},
+	// actor_id без внешнего ключа: история должна пережить удаление пользователя
+	{version: 6, name: "create audit_events", up: execSQL(`
+		CREATE TABLE IF NOT EXISTS audit_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			ts DATETIME NOT NULL,
+			actor TEXT NOT NULL DEFAULT '',
+			actor_id INTEGER,
+			action TEXT NOT NULL,
+			target TEXT NOT NULL DEFAULT '',
+			request_id TEXT NOT NULL DEFAULT '',
+			ip TEXT NOT NULL DEFAULT '',
+			before TEXT,
+			after TEXT
+		);
+		CREATE INDEX IF NOT EXISTS idx_audit_ts ON audit_events(ts);
+		CREATE INDEX IF NOT EXISTS idx_audit_action ON audit_events(action);
+		CREATE INDEX IF NOT EXISTS idx_audit_actor ON audit_events(actor);
+	`)},
+	{version: 7, name: "create signing_keys", up: execSQL(`
+		CREATE TABLE IF NOT EXISTS signing_keys (
+			kid TEXT PRIMARY KEY,
+			algorithm TEXT NOT NULL,
+			private_key BLOB NOT NULL,
+			created_at DATETIME NOT NULL,
+			retired_at DATETIME
+		);
+	`)},
+	{version: 8, name: "create access token revocation tables", up: execSQL(`
+		CREATE TABLE IF NOT EXISTS revoked_tokens (
+			jti TEXT PRIMARY KEY,
+			expires_at DATETIME NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS revoked_user_tokens (
+			user_id INTEGER PRIMARY KEY,
+			revoked_before DATETIME NOT NULL,
+			expires_at DATETIME NOT NULL
+		);
+	`)},
+	{version: 9, name: "create api_keys", up: execSQL(`
+		CREATE TABLE IF NOT EXISTS api_keys (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			prefix TEXT NOT NULL,
+			key_hash TEXT NOT NULL UNIQUE,
+			user_id INTEGER NOT NULL,
+			scopes TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			expires_at DATETIME,
+			last_used_at DATETIME,
+			revoked_at DATETIME,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+	`)},
+	// Строка user_mfa с enabled = 0 — начатое, но не подтверждённое подключение
+	{version: 10, name: "create user_mfa and mfa_recovery_codes", up: execSQL(`
+		CREATE TABLE IF NOT EXISTS user_mfa (
+			user_id INTEGER PRIMARY KEY,
+			secret TEXT NOT NULL,
+			enabled INTEGER NOT NULL DEFAULT 0,
+			last_step INTEGER NOT NULL DEFAULT 0,
+			created_at DATETIME NOT NULL,
+			confirmed_at DATETIME,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);
+		CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			code_hash TEXT NOT NULL,
+			used_at DATETIME,
+			FOREIGN KEY (user_id) REFERENCES user_mfa(user_id) ON DELETE CASCADE
+		);
+		CREATE INDEX IF NOT EXISTS idx_mfa_recovery_user ON mfa_recovery_codes(user_id);
+	`)},
+	// Пустой locale — язык ответа выбирается по Accept-Language
+	{version: 11, name: "add users.locale", up: execSQL(`
+		ALTER TABLE users ADD COLUMN locale TEXT NOT NULL DEFAULT '';
+	`)},
+	// Ключи подписи переезжают в файлы JWT_KEY_DIR. Старые ключи не переносятся:
+	// их содержимое затирается до удаления, при запуске создаётся новый ключ,
+	// а выданные access-токены (живут 15 минут) обновятся по refresh-токену.
+	{version: 12, name: "drop signing_keys.private_key", up: execSQL(`
+		UPDATE signing_keys SET private_key = zeroblob(length(private_key));
+		DELETE FROM signing_keys;
+		ALTER TABLE signing_keys DROP COLUMN private_key;
+	`)},
+}
+
+// Migrate применяет все миграции, версия которых больше текущей версии схемы.
+func Migrate(db *sql.DB) error {
+	current, err := SchemaVersion(db)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("migration %d: begin: %w", m.version, err)
+		}
+		if err := m.up(tx); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+		// PRAGMA не поддерживает плейсхолдеры, версия — константа из кода
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", m.version)); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("migration %d: set version: %w", m.version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d: commit: %w", m.version, err)
+		}
+	}
+	return nil
+}
+
+// SchemaVersion возвращает текущую версию схемы базы данных.
+func SchemaVersion(db *sql.DB) (int, error) {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// LatestSchemaVersion возвращает версию схемы, которую ожидает код.
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+func execSQL(query string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+// hashRefreshTokens заменяет сохранённые в открытом виде refresh-токены
+// их SHA-256 дайджестами, чтобы активные сессии пережили обновление.
+func hashRefreshTokens(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT id, token FROM refresh_tokens`)
+	if err != nil {
+		return err
+	}
+
+	type row struct {
+		id    int64
+		token string
+	}
+	var existing []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.id, &r.token); err != nil {
+			rows.Close()
+			return err
+		}
+		existing = append(existing, r)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return err
+	}
+	rows.Close()
+
+	for _, r := range existing {
+		if _, err := tx.Exec(`UPDATE refresh_tokens SET token = ? WHERE id = ?`,
+			models.HashToken(r.token), r.id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addMustChangePassword добавляет флаг обязательной смены пароля и сразу
+// выставляет его администраторам, у которых остался заводской пароль.
+func addMustChangePassword(tx *sql.Tx) error {
+	if _, err := tx.Exec(`ALTER TABLE users ADD COLUMN must_change_password INTEGER NOT NULL DEFAULT 0`); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(`SELECT id, password_hash FROM users WHERE role >= 1`)
+	if err != nil {
+		return err
+	}
+	var flagged []int64
+	for rows.Next() {
+		var id int64
+		var hash string
+		if err := rows.Scan(&id, &hash); err != nil {
+			rows.Close()
+			return err
+		}
+		if utils.CheckPassword(hash, defaultAdminPassword) {
+			flagged = append(flagged, id)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return err
+	}
+	rows.Close()
+
+	for _, id := range flagged {
+		if _, err := tx.Exec(`UPDATE users SET must_change_password = 1 WHERE id = ?`, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
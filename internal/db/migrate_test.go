@@ -0,0 +1,113 @@
+//go:build cgo
+
+package db
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/models"
+	"rim-router-service-ver-cgo/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	conn, err := OpenSQLite(filepath.Join(t.TempDir(), "data.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestMigrate_FreshDatabase(t *testing.T) {
+	conn := openTestDB(t)
+
+	require.NoError(t, Migrate(conn))
+	v, err := SchemaVersion(conn)
+	require.NoError(t, err)
+	assert.Equal(t, LatestSchemaVersion(), v)
+
+	// повторный запуск ничего не меняет
+	require.NoError(t, Migrate(conn))
+	v, err = SchemaVersion(conn)
+	require.NoError(t, err)
+	assert.Equal(t, LatestSchemaVersion(), v)
+
+	repo := models.NewUserRepository(conn)
+	require.NoError(t, repo.CreateUser("operator", "hash", 0))
+	user, err := repo.GetUserByUsername("operator")
+	require.NoError(t, err)
+	assert.Equal(t, models.UserStatusActive, user.Status)
+	assert.Empty(t, user.Locale)
+}
+
+// База первой версии: refresh-токены в открытом виде, заводской пароль администратора
+func TestMigrate_FromVersion1(t *testing.T) {
+	conn := openTestDB(t)
+
+	tx, err := conn.Begin()
+	require.NoError(t, err)
+	require.NoError(t, migrations[0].up(tx))
+	_, err = tx.Exec("PRAGMA user_version = 1")
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	hash, err := utils.HashPassword(defaultAdminPassword)
+	require.NoError(t, err)
+	res, err := conn.Exec(`INSERT INTO users (username, password_hash, role) VALUES ('admin', ?, 1)`, hash)
+	require.NoError(t, err)
+	adminID, _ := res.LastInsertId()
+	_, err = conn.Exec(`INSERT INTO refresh_tokens (user_id, token, expires_at) VALUES (?, 'plain-token', ?)`,
+		adminID, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	require.NoError(t, Migrate(conn))
+	v, err := SchemaVersion(conn)
+	require.NoError(t, err)
+	assert.Equal(t, LatestSchemaVersion(), v)
+
+	admin, err := models.NewUserRepository(conn).GetUserByID(adminID)
+	require.NoError(t, err)
+	assert.True(t, admin.MustChangePassword)
+	assert.Equal(t, models.UserStatusActive, admin.Status)
+
+	userID, _, err := models.NewTokenRepository(conn).GetRefreshToken("plain-token")
+	require.NoError(t, err)
+	assert.Equal(t, adminID, userID)
+}
+
+// Ключи подписи из БД (версия 11) не переносятся и удаляются вместе со столбцом
+func TestMigrate_DropsStoredSigningKeys(t *testing.T) {
+	conn := openTestDB(t)
+
+	tx, err := conn.Begin()
+	require.NoError(t, err)
+	for _, m := range migrations {
+		if m.version <= 11 {
+			require.NoError(t, m.up(tx))
+		}
+	}
+	_, err = tx.Exec("PRAGMA user_version = 11")
+	require.NoError(t, err)
+	_, err = tx.Exec(`INSERT INTO signing_keys (kid, algorithm, private_key, created_at) VALUES ('old', 'HS256', X'0102', CURRENT_TIMESTAMP)`)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	require.NoError(t, Migrate(conn))
+
+	repo := models.NewSigningKeyRepository(conn, t.TempDir())
+	keys, err := repo.ListSigningKeys()
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	require.NoError(t, repo.SaveSigningKey(models.SigningKey{KID: "new", Algorithm: "EdDSA", PrivateKey: []byte{3}, CreatedAt: time.Now()}))
+	keys, err = repo.ListSigningKeys()
+	require.NoError(t, err)
+	if assert.Len(t, keys, 1) {
+		assert.Equal(t, []byte{3}, keys[0].PrivateKey)
+	}
+}
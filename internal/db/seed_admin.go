@@ -1,15 +1,21 @@
 package db
 
 import (
+	"crypto/rand"
+	"fmt"
 	"log"
+	"math/big"
 	"os"
 
 	"rim-router-service-ver-cgo/internal/models"
-
-	"golang.org/x/crypto/bcrypt"
+	"rim-router-service-ver-cgo/internal/utils"
 )
 
+// defaultAdminPassword — заводской пароль, если не задан ни ADMIN_PASSWORD, ни ADMIN_PASSWORD_FILE.
+const defaultAdminPassword = "admin123"
+
 // SeedAdmin проверяет, есть ли администратор; если нет — создаёт.
+// Созданный администратор обязан сменить пароль при первом входе.
 func SeedAdmin(userRepo *models.UserRepository) {
 	exists, err := userRepo.AdminExists()
 	if err != nil {
@@ -27,21 +33,106 @@ func SeedAdmin(userRepo *models.UserRepository) {
 	if username == "" {
 		username = "admin"
 	}
+
+	// ADMIN_PASSWORD_FILE: генерируем одноразовый пароль и кладём его в файл,
+	// доступный только владельцу, вместо вывода в лог.
+	passwordFile := os.Getenv("ADMIN_PASSWORD_FILE")
 	password := os.Getenv("ADMIN_PASSWORD")
+	generated := false
+	if password == "" && passwordFile != "" {
+		password, err = generateOneTimePassword(20)
+		if err != nil {
+			log.Printf("❌ Failed to generate admin password: %v", err)
+			return
+		}
+		generated = true
+	}
 	if password == "" {
-		password = "admin123"
+		password = defaultAdminPassword
 	}
 
-	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashed, err := utils.HashPassword(password)
 	if err != nil {
 		log.Printf("❌ Failed to hash admin password: %v", err)
 		return
 	}
 
-	if err := userRepo.CreateUser(username, string(hashed), 1); err != nil {
+	// файл пишем до создания пользователя, иначе при ошибке пароль будет потерян
+	if generated {
+		if err := writeSecretFile(passwordFile, username, password); err != nil {
+			log.Printf("❌ Failed to write admin password file: %v", err)
+			return
+		}
+	}
+
+	if err := userRepo.CreateUser(username, hashed, 1); err != nil {
 		log.Printf("❌ Failed to create admin: %v", err)
 		return
 	}
+	if err := userRepo.MarkMustChangePassword(username); err != nil {
+		log.Printf("❌ Failed to flag admin for password change: %v", err)
+	}
+
+	if generated {
+		log.Printf("✅ Admin user created: username=%s, one-time password written to %s", username, passwordFile)
+		return
+	}
+
+	if password == defaultAdminPassword {
+		log.Printf("⚠️ Admin user created with the default password: username=%s (must be changed at first login)", username)
+		return
+	}
+	log.Printf("✅ Admin user created: username=%s (password from ADMIN_PASSWORD, must be changed at first login)", username)
+}
+
+// generateOneTimePassword создаёт случайный пароль, проходящий политику паролей.
+func generateOneTimePassword(n int) (string, error) {
+	const (
+		lower  = "abcdefghijkmnopqrstuvwxyz"
+		upper  = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+		digits = "23456789"
+		symbol = "-_.!@#%"
+	)
+	sets := []string{lower, upper, digits, symbol}
+	all := lower + upper + digits + symbol
+
+	buf := make([]byte, n)
+	for i := range buf {
+		// первые символы — по одному из каждого класса
+		set := all
+		if i < len(sets) {
+			set = sets[i]
+		}
+		idx, err := rand.Int(rand.Reader, big.NewInt(int64(len(set))))
+		if err != nil {
+			return "", err
+		}
+		buf[i] = set[idx.Int64()]
+	}
+
+	// перемешиваем, чтобы классы не стояли на фиксированных позициях
+	for i := len(buf) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", err
+		}
+		buf[i], buf[j.Int64()] = buf[j.Int64()], buf[i]
+	}
+	return string(buf), nil
+}
+
+// writeSecretFile записывает учётные данные в файл с правами 0600.
+func writeSecretFile(path, username, password string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
 
-	log.Printf("✅ Admin user created successfully! username=%s, password=%s", username, password)
+	// файл мог существовать с более широкими правами
+	if err := f.Chmod(0o600); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(f, "username=%s\npassword=%s\n", username, password)
+	return err
 }
@@ -1,73 +1,67 @@
 package handlers
 
 import (
+	"crypto/rand"
+	"encoding/base32"
 	"encoding/json"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 	"time"
 
+	"rim-router-service-ver-cgo/internal/apierr"
+	"rim-router-service-ver-cgo/internal/audit"
+	"rim-router-service-ver-cgo/internal/config"
+	"rim-router-service-ver-cgo/internal/i18n"
+	"rim-router-service-ver-cgo/internal/middleware"
 	"rim-router-service-ver-cgo/internal/models"
-	"rim-router-service-ver-cgo/internal/utils" // ✅ добавили
+	"rim-router-service-ver-cgo/internal/utils"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/rs/zerolog"
 )
 
 // AdminHandler — обработчик админских запросов
 type AdminHandler struct {
 	UserRepo *models.UserRepository
-	logger   zerolog.Logger
+	Guard    *LoginGuard // для снятия блокировки входа; nil — функция недоступна
+	Invites  *models.InviteRepository
+	APIKeys  *models.APIKeyRepository // nil — API-ключи недоступны
+	MFA      *models.MFARepository    // для сброса MFA пользователя
 }
 
-// NewAdminHandler создаёт отдельный логгер для api.log
+// NewAdminHandler — логи пишутся через логгер запроса (middleware.Log) с module=admin
 func NewAdminHandler(userRepo *models.UserRepository) *AdminHandler {
-	// ✅ используем систему логов проекта
-	logDir := utils.LogDir()
-	logFile := filepath.Join(logDir, "api.log")
-
-	// создаём файл, если его нет
-	file, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
-	if err != nil {
-		panic("cannot open api.log: " + err.Error())
-	}
-
-	logger := zerolog.New(file).With().
-		Timestamp().
-		Str("module", "admin").
-		Logger()
-
-	return &AdminHandler{UserRepo: userRepo, logger: logger}
+	return &AdminHandler{UserRepo: userRepo}
 }
 
 // GET /api/v1/admin/users — список всех пользователей
 func (h *AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
-	h.logger.Info().
+	logger := middleware.Log(r, "admin")
+	logger.Info().
 		Str("endpoint", "/api/v1/admin/users").
 		Msg("Admin requested user list")
 
 	users, err := h.UserRepo.GetAllUsers()
 	if err != nil {
-		h.logger.Error().Err(err).Msg("Failed to fetch user list")
-		sendJSON(w, http.StatusInternalServerError, "Database error", nil)
+		logger.Error().Err(err).Msg("Failed to fetch user list")
+		apierr.Write(w, r, apierr.ErrDatabase)
 		return
 	}
 
-	h.logger.Info().
+	logger.Info().
 		Int("count", len(users)).
 		Msg("Fetched user list successfully")
 
-	sendJSON(w, http.StatusOK, "OK", users)
+	sendJSON(w, r, http.StatusOK, i18n.MsgOK, users)
 }
 
 // POST /api/v1/admin/users/{id}/role — изменить роль пользователя
 func (h *AdminHandler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	logger := middleware.Log(r, "admin")
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil || id <= 0 {
-		h.logger.Warn().Str("user_id", idStr).Msg("Invalid user ID")
-		sendJSON(w, http.StatusBadRequest, "Invalid user ID", nil)
+		logger.Warn().Str("user_id", idStr).Msg("Invalid user ID")
+		apierr.Write(w, r, apierr.New(http.StatusBadRequest, apierr.CodeInvalidID, "Invalid user ID"))
 		return
 	}
 
@@ -75,28 +69,291 @@ func (h *AdminHandler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
 		Role int `json:"role"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		h.logger.Warn().Int("user_id", id).Msg("Invalid JSON payload")
-		sendJSON(w, http.StatusBadRequest, "Invalid JSON", nil)
+		logger.Warn().Int("user_id", id).Msg("Invalid JSON payload")
+		apierr.Write(w, r, apierr.ErrInvalidJSON)
 		return
 	}
 
 	if body.Role < 0 || body.Role > 2 {
-		h.logger.Warn().Int("role", body.Role).Msg("Invalid role value")
-		sendJSON(w, http.StatusBadRequest, "Invalid role value", nil)
+		logger.Warn().Int("role", body.Role).Msg("Invalid role value")
+		apierr.Write(w, r, apierr.Validation("Invalid role value", apierr.Field("role", "invalid", "")))
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByID(int64(id))
+	if err != nil {
+		logger.Warn().Int("user_id", id).Msg("User not found for role change")
+		apierr.Write(w, r, apierr.New(http.StatusNotFound, apierr.CodeUserNotFound, "User not found"))
 		return
 	}
 
 	if err := h.UserRepo.UpdateUserRole(id, body.Role); err != nil {
-		h.logger.Error().Err(err).Int("user_id", id).Msg("Failed to update user role")
-		sendJSON(w, http.StatusInternalServerError, "Failed to update role", nil)
+		logger.Error().Err(err).Int("user_id", id).Msg("Failed to update user role")
+		apierr.Write(w, r, apierr.New(http.StatusInternalServerError, apierr.CodeDatabase, "Failed to update role"))
 		return
 	}
 
-	h.logger.Info().
+	// понижение роли действует сразу, а не после истечения access-токена
+	if err := utils.RevokeUserTokens(user.ID); err != nil {
+		logger.Error().Err(err).Int("user_id", id).Msg("Failed to revoke access tokens")
+	}
+	middleware.InvalidateUser(user.ID)
+
+	logger.Info().
 		Int("user_id", id).
 		Int("new_role", body.Role).
 		Time("ts", time.Now()).
 		Msg("User role updated successfully")
+	audit.Record(r, audit.Entry{
+		Action: audit.ActionRoleChange,
+		Target: user.Username,
+		Before: map[string]int{"role": user.Role},
+		After:  map[string]int{"role": body.Role},
+	})
+
+	sendJSON(w, r, http.StatusOK, i18n.MsgRoleUpdated, nil)
+}
+
+// POST /api/v2/admin/users/{id}/unlock — снять блокировку входа после перебора паролей
+func (h *AdminHandler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	logger := middleware.Log(r, "admin")
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		logger.Warn().Str("user_id", idStr).Msg("Invalid user ID")
+		apierr.Write(w, r, apierr.New(http.StatusBadRequest, apierr.CodeInvalidID, "Invalid user ID"))
+		return
+	}
+
+	if h.Guard == nil {
+		apierr.Write(w, r, apierr.New(http.StatusNotImplemented, apierr.CodeFeatureDisabled, "Login lockout is disabled"))
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByID(int64(id))
+	if err != nil {
+		logger.Warn().Int("user_id", id).Msg("User not found for unlock")
+		apierr.Write(w, r, apierr.New(http.StatusNotFound, apierr.CodeUserNotFound, "User not found"))
+		return
+	}
+
+	if err := h.Guard.Unlock(user.Username); err != nil {
+		logger.Error().Err(err).Int("user_id", id).Msg("Failed to unlock user")
+		apierr.Write(w, r, apierr.New(http.StatusInternalServerError, apierr.CodeDatabase, "Failed to unlock user"))
+		return
+	}
+
+	authLog := middleware.Log(r, "auth")
+	authLog.Info().Str("target", user.Username).Msg("Account unlocked by admin")
+	audit.Record(r, audit.Entry{Action: audit.ActionUserUnlock, Target: user.Username})
+
+	sendJSON(w, r, http.StatusOK, i18n.MsgUserUnlocked, nil)
+}
+
+// POST /api/v2/admin/users/{id}/approve — одобрить регистрацию, ожидающую подтверждения
+func (h *AdminHandler) ApproveUser(w http.ResponseWriter, r *http.Request) {
+	logger := middleware.Log(r, "admin")
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		logger.Warn().Str("user_id", idStr).Msg("Invalid user ID")
+		apierr.Write(w, r, apierr.New(http.StatusBadRequest, apierr.CodeInvalidID, "Invalid user ID"))
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByID(int64(id))
+	if err != nil {
+		logger.Warn().Int("user_id", id).Msg("User not found for approval")
+		apierr.Write(w, r, apierr.New(http.StatusNotFound, apierr.CodeUserNotFound, "User not found"))
+		return
+	}
+
+	ok, err := h.UserRepo.UpdateUserStatus(user.ID, models.UserStatusPending, models.UserStatusActive)
+	if err != nil {
+		logger.Error().Err(err).Int("user_id", id).Msg("Failed to approve user")
+		apierr.Write(w, r, apierr.ErrDatabase)
+		return
+	}
+	if !ok {
+		apierr.Write(w, r, apierr.New(http.StatusConflict, apierr.CodeUserNotPending, "User is not pending approval"))
+		return
+	}
+	middleware.InvalidateUser(user.ID)
+
+	authLog := middleware.Log(r, "auth")
+	authLog.Info().Str("target", user.Username).Msg("Registration approved by admin")
+	audit.Record(r, audit.Entry{
+		Action: audit.ActionUserApprove,
+		Target: user.Username,
+		Before: map[string]string{"status": models.UserStatusPending},
+		After:  map[string]string{"status": models.UserStatusActive},
+	})
+
+	sendJSON(w, r, http.StatusOK, i18n.MsgUserApproved, nil)
+}
+
+// DELETE /api/v2/admin/users/{id}/mfa — сбросить MFA пользователя (потерян телефон
+// и коды восстановления). Если MFA для роли обязательна, при следующем входе
+// пользователь подключит её заново.
+func (h *AdminHandler) ResetUserMFA(w http.ResponseWriter, r *http.Request) {
+	logger := middleware.Log(r, "admin")
+	if h.MFA == nil {
+		apierr.Write(w, r, apierr.New(http.StatusNotImplemented, apierr.CodeFeatureDisabled, "MFA is disabled"))
+		return
+	}
+
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		logger.Warn().Str("user_id", idStr).Msg("Invalid user ID")
+		apierr.Write(w, r, apierr.New(http.StatusBadRequest, apierr.CodeInvalidID, "Invalid user ID"))
+		return
+	}
 
-	sendJSON(w, http.StatusOK, "Role updated successfully", nil)
+	user, err := h.UserRepo.GetUserByID(int64(id))
+	if err != nil {
+		logger.Warn().Int("user_id", id).Msg("User not found for MFA reset")
+		apierr.Write(w, r, apierr.New(http.StatusNotFound, apierr.CodeUserNotFound, "User not found"))
+		return
+	}
+
+	ok, err := h.MFA.DisableMFA(user.ID)
+	if err != nil {
+		logger.Error().Err(err).Int("user_id", id).Msg("Failed to reset MFA")
+		apierr.Write(w, r, apierr.ErrDatabase)
+		return
+	}
+	if !ok {
+		apierr.Write(w, r, apierr.New(http.StatusNotFound, apierr.CodeMFANotEnabled, "MFA is not enabled for this user"))
+		return
+	}
+
+	logger.Info().Int("user_id", id).Str("target", user.Username).Msg("MFA reset by admin")
+	audit.Record(r, audit.Entry{
+		Action: audit.ActionMFADisable,
+		Target: user.Username,
+		After:  map[string]string{"reason": "admin_reset"},
+	})
+	sendJSON(w, r, http.StatusOK, i18n.MsgMFAReset, nil)
+}
+
+// InviteCreated — ответ на создание приглашения. Код показывается один раз.
+type InviteCreated struct {
+	ID        int64     `json:"id"`
+	Code      string    `json:"code"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// POST /api/v2/admin/invites — выпустить одноразовый код приглашения
+func (h *AdminHandler) CreateInvite(w http.ResponseWriter, r *http.Request) {
+	logger := middleware.Log(r, "admin")
+	if h.Invites == nil {
+		apierr.Write(w, r, apierr.New(http.StatusNotImplemented, apierr.CodeFeatureDisabled, "Invites are disabled"))
+		return
+	}
+
+	// Тело необязательно: {"ttl": "24h"} переопределяет срок по умолчанию
+	var body struct {
+		TTL string `json:"ttl"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			apierr.Write(w, r, apierr.ErrInvalidJSON)
+			return
+		}
+	}
+	ttl := config.GetRegistrationConfig().InviteTTL
+	if body.TTL != "" {
+		d, err := time.ParseDuration(body.TTL)
+		if err != nil || d <= 0 {
+			apierr.Write(w, r, apierr.Validation("Invalid ttl", apierr.Field("ttl", "invalid", "")))
+			return
+		}
+		ttl = d
+	}
+
+	code, err := generateInviteCode()
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to generate invite code")
+		apierr.Write(w, r, apierr.New(http.StatusInternalServerError, apierr.CodeInternal, "Failed to generate invite code"))
+		return
+	}
+
+	var createdBy int64
+	if claims := middleware.GetUserFromContext(r.Context()); claims != nil {
+		createdBy = claims.UserID
+	}
+	expiresAt := time.Now().Add(ttl).UTC()
+
+	id, err := h.Invites.CreateInvite(code, createdBy, expiresAt)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to store invite")
+		apierr.Write(w, r, apierr.ErrDatabase)
+		return
+	}
+
+	logger.Info().Int64("invite_id", id).Time("expires_at", expiresAt).Msg("Invite created")
+	audit.Record(r, audit.Entry{
+		Action: audit.ActionInviteCreate,
+		Target: "invite:" + strconv.FormatInt(id, 10),
+		After:  map[string]time.Time{"expires_at": expiresAt},
+	})
+	sendJSON(w, r, http.StatusCreated, i18n.MsgInviteCreated, InviteCreated{ID: id, Code: code, ExpiresAt: expiresAt})
+}
+
+// GET /api/v2/admin/invites — список приглашений (без самих кодов)
+func (h *AdminHandler) ListInvites(w http.ResponseWriter, r *http.Request) {
+	logger := middleware.Log(r, "admin")
+	if h.Invites == nil {
+		apierr.Write(w, r, apierr.New(http.StatusNotImplemented, apierr.CodeFeatureDisabled, "Invites are disabled"))
+		return
+	}
+
+	invites, err := h.Invites.ListInvites()
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to fetch invites")
+		apierr.Write(w, r, apierr.ErrDatabase)
+		return
+	}
+	sendJSON(w, r, http.StatusOK, i18n.MsgOK, invites)
+}
+
+// DELETE /api/v2/admin/invites/{id} — отозвать приглашение
+func (h *AdminHandler) DeleteInvite(w http.ResponseWriter, r *http.Request) {
+	logger := middleware.Log(r, "admin")
+	if h.Invites == nil {
+		apierr.Write(w, r, apierr.New(http.StatusNotImplemented, apierr.CodeFeatureDisabled, "Invites are disabled"))
+		return
+	}
+
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || id <= 0 {
+		apierr.Write(w, r, apierr.New(http.StatusBadRequest, apierr.CodeInvalidID, "Invalid invite ID"))
+		return
+	}
+
+	ok, err := h.Invites.DeleteInvite(id)
+	if err != nil {
+		logger.Error().Err(err).Int64("invite_id", id).Msg("Failed to delete invite")
+		apierr.Write(w, r, apierr.ErrDatabase)
+		return
+	}
+	if !ok {
+		apierr.Write(w, r, apierr.New(http.StatusNotFound, apierr.CodeInviteNotFound, "Invite not found"))
+		return
+	}
+
+	logger.Info().Int64("invite_id", id).Msg("Invite revoked")
+	audit.Record(r, audit.Entry{Action: audit.ActionInviteDelete, Target: "invite:" + strconv.FormatInt(id, 10)})
+	sendJSON(w, r, http.StatusOK, i18n.MsgInviteRevoked, nil)
+}
+
+// generateInviteCode — 16 символов base32 (80 бит случайности), удобно вводить вручную
+func generateInviteCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.EncodeToString(b), nil
 }
@@ -36,11 +36,11 @@ func TestListUsers_Success(t *testing.T) {
 	defer cleanup()
 
 	now := time.Now()
-	rows := sqlmock.NewRows([]string{"id", "username", "role", "created_at"}).
-		AddRow(1, "admin", 1, now).
-		AddRow(2, "user", 0, now)
+	rows := sqlmock.NewRows([]string{"id", "username", "role", "must_change_password", "status", "created_at"}).
+		AddRow(1, "admin", 1, false, "active", now).
+		AddRow(2, "user", 0, false, "active", now)
 
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, username, role, created_at FROM users ORDER BY id ASC")).
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, username, role, must_change_password, status, created_at FROM users ORDER BY id ASC")).
 		WillReturnRows(rows)
 
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/users", nil)
@@ -86,6 +86,10 @@ func TestUpdateUserRole_Success(t *testing.T) {
 	h, mock, cleanup := setupAdminHandler(t)
 	defer cleanup()
 
+	mock.ExpectQuery("SELECT id, username").
+		WithArgs(int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password_hash", "role", "must_change_password", "status", "created_at", "locale"}).
+			AddRow(2, "user", "hash", 0, false, "active", time.Now(), ""))
 	mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET role = ? WHERE id = ?")).
 		WithArgs(1, 2).
 		WillReturnResult(sqlmock.NewResult(0, 1))
@@ -161,6 +165,10 @@ func TestUpdateUserRole_DBError(t *testing.T) {
 	h, mock, cleanup := setupAdminHandler(t)
 	defer cleanup()
 
+	mock.ExpectQuery("SELECT id, username").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password_hash", "role", "must_change_password", "status", "created_at", "locale"}).
+			AddRow(1, "admin", "hash", 1, false, "active", time.Now(), ""))
 	mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET role = ? WHERE id = ?")).
 		WithArgs(2, 1).
 		WillReturnError(sql.ErrConnDone)
@@ -176,3 +184,150 @@ func TestUpdateUserRole_DBError(t *testing.T) {
 
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
+
+func TestUpdateUserRole_UserNotFound(t *testing.T) {
+	h, mock, cleanup := setupAdminHandler(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT id, username").
+		WithArgs(int64(9)).
+		WillReturnError(sql.ErrNoRows)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/9/role", bytes.NewBufferString(`{"role":1}`))
+	w := httptest.NewRecorder()
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "9")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	h.UpdateUserRole(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// ====== TEST: UnlockUser ======
+
+func TestUnlockUser_Success(t *testing.T) {
+	h, mock, cleanup := setupAdminHandler(t)
+	defer cleanup()
+	h.Guard = NewLoginGuard(models.NewLoginAttemptRepository(h.UserRepo.DB), testLockoutConfig)
+
+	mock.ExpectQuery("SELECT id, username").
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password_hash", "role", "must_change_password", "status", "created_at", "locale"}).
+			AddRow(3, "Bob", "hash", 0, false, "active", time.Now(), ""))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM login_attempts WHERE key = ?")).
+		WithArgs("user:bob").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/admin/users/3/unlock", nil)
+	w := httptest.NewRecorder()
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "3")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	h.UnlockUser(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// ====== TEST: ApproveUser ======
+
+func TestApproveUser_Success(t *testing.T) {
+	h, mock, cleanup := setupAdminHandler(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT id, username").
+		WithArgs(int64(4)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password_hash", "role", "must_change_password", "status", "created_at", "locale"}).
+			AddRow(4, "newbie", "hash", 0, false, "pending", time.Now(), ""))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET status = ? WHERE id = ? AND status = ?")).
+		WithArgs("active", int64(4), "pending").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/admin/users/4/approve", nil)
+	w := httptest.NewRecorder()
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "4")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	h.ApproveUser(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApproveUser_NotPending(t *testing.T) {
+	h, mock, cleanup := setupAdminHandler(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT id, username").
+		WithArgs(int64(4)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password_hash", "role", "must_change_password", "status", "created_at", "locale"}).
+			AddRow(4, "olduser", "hash", 0, false, "active", time.Now(), ""))
+	mock.ExpectExec("UPDATE users SET status").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/admin/users/4/approve", nil)
+	w := httptest.NewRecorder()
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "4")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	h.ApproveUser(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+// ====== TEST: Invites ======
+
+func TestCreateInvite_ReturnsCodeOnce(t *testing.T) {
+	h, mock, cleanup := setupAdminHandler(t)
+	defer cleanup()
+	h.Invites = models.NewInviteRepository(h.UserRepo.DB)
+
+	mock.ExpectExec("INSERT INTO invite_codes").
+		WithArgs(sqlmock.AnyArg(), nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/admin/invites", bytes.NewBufferString(`{"ttl":"1h"}`))
+	w := httptest.NewRecorder()
+
+	h.CreateInvite(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var resp struct {
+		Data InviteCreated `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, int64(7), resp.Data.ID)
+	assert.Len(t, resp.Data.Code, 16)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), resp.Data.ExpiresAt, time.Minute)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteInvite_NotFound(t *testing.T) {
+	h, mock, cleanup := setupAdminHandler(t)
+	defer cleanup()
+	h.Invites = models.NewInviteRepository(h.UserRepo.DB)
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM invite_codes WHERE id = ?")).
+		WithArgs(int64(9)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v2/admin/invites/9", nil)
+	w := httptest.NewRecorder()
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "9")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	h.DeleteInvite(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/apierr"
+	"rim-router-service-ver-cgo/internal/audit"
+	"rim-router-service-ver-cgo/internal/i18n"
+	"rim-router-service-ver-cgo/internal/middleware"
+	"rim-router-service-ver-cgo/internal/models"
+	"rim-router-service-ver-cgo/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// apiKeyPrefixLen — сколько первых символов ключа хранится открыто для опознания в списке
+const apiKeyPrefixLen = 12
+
+// APIKeyCreated — ответ на создание ключа. Сам ключ показывается один раз.
+type APIKeyCreated struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// POST /api/v2/admin/apikeys — выпустить API-ключ от имени текущего администратора.
+// Тело: {"name": "monitoring", "scopes": ["logs:read"], "ttl": "8760h"}; ttl необязателен.
+func (h *AdminHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	logger := middleware.Log(r, "admin")
+	if h.APIKeys == nil {
+		apierr.Write(w, r, apierr.New(http.StatusNotImplemented, apierr.CodeFeatureDisabled, "API keys are disabled"))
+		return
+	}
+
+	var body struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+		TTL    string   `json:"ttl"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		apierr.Write(w, r, apierr.ErrInvalidJSON)
+		return
+	}
+
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" {
+		apierr.Write(w, r, apierr.Validation("name is required", apierr.Field("name", "required", "")))
+		return
+	}
+	if len(body.Scopes) == 0 {
+		apierr.Write(w, r, apierr.Validation("At least one scope is required", apierr.Field("scopes", "required", "")).
+			WithData(models.APIKeyScopes))
+		return
+	}
+	for _, s := range body.Scopes {
+		if !models.IsValidScope(s) {
+			apierr.Write(w, r, apierr.Validation("Unknown scope: "+s, apierr.Field("scopes", "unknown", "Unknown scope: "+s)).
+				WithData(models.APIKeyScopes))
+			return
+		}
+	}
+
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		apierr.Write(w, r, apierr.ErrAuthRequired)
+		return
+	}
+
+	now := time.Now().UTC()
+	k := models.APIKey{Name: body.Name, UserID: claims.UserID, Scopes: body.Scopes, CreatedAt: now}
+	if body.TTL != "" {
+		d, err := time.ParseDuration(body.TTL)
+		if err != nil || d <= 0 {
+			apierr.Write(w, r, apierr.Validation("Invalid ttl", apierr.Field("ttl", "invalid", "")))
+			return
+		}
+		expiresAt := now.Add(d)
+		k.ExpiresAt = &expiresAt
+	}
+
+	token, err := utils.GenerateSecureToken()
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to generate API key")
+		apierr.Write(w, r, apierr.New(http.StatusInternalServerError, apierr.CodeInternal, "Failed to generate API key"))
+		return
+	}
+	key := "rim_" + token
+	k.Prefix = key[:apiKeyPrefixLen]
+
+	k.ID, err = h.APIKeys.CreateAPIKey(k, key)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to store API key")
+		apierr.Write(w, r, apierr.ErrDatabase)
+		return
+	}
+
+	logger.Info().Int64("api_key_id", k.ID).Str("name", k.Name).Strs("scopes", k.Scopes).Msg("API key created")
+	audit.Record(r, audit.Entry{
+		Action: audit.ActionAPIKeyCreate,
+		Target: "apikey:" + strconv.FormatInt(k.ID, 10),
+		After:  map[string]interface{}{"name": k.Name, "scopes": k.Scopes, "expires_at": k.ExpiresAt},
+	})
+	sendJSON(w, r, http.StatusCreated, i18n.MsgAPIKeyCreated, APIKeyCreated{APIKey: k, Key: key})
+}
+
+// GET /api/v2/admin/apikeys — список ключей (без самих ключей)
+func (h *AdminHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	logger := middleware.Log(r, "admin")
+	if h.APIKeys == nil {
+		apierr.Write(w, r, apierr.New(http.StatusNotImplemented, apierr.CodeFeatureDisabled, "API keys are disabled"))
+		return
+	}
+
+	keys, err := h.APIKeys.ListAPIKeys()
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to fetch API keys")
+		apierr.Write(w, r, apierr.ErrDatabase)
+		return
+	}
+	sendJSON(w, r, http.StatusOK, i18n.MsgOK, keys)
+}
+
+// DELETE /api/v2/admin/apikeys/{id} — отозвать ключ. Запись остаётся в списке с revoked_at.
+func (h *AdminHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	logger := middleware.Log(r, "admin")
+	if h.APIKeys == nil {
+		apierr.Write(w, r, apierr.New(http.StatusNotImplemented, apierr.CodeFeatureDisabled, "API keys are disabled"))
+		return
+	}
+
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || id <= 0 {
+		apierr.Write(w, r, apierr.New(http.StatusBadRequest, apierr.CodeInvalidID, "Invalid API key ID"))
+		return
+	}
+
+	ok, err := h.APIKeys.RevokeAPIKey(id, time.Now())
+	if err != nil {
+		logger.Error().Err(err).Int64("api_key_id", id).Msg("Failed to revoke API key")
+		apierr.Write(w, r, apierr.ErrDatabase)
+		return
+	}
+	if !ok {
+		apierr.Write(w, r, apierr.New(http.StatusNotFound, apierr.CodeAPIKeyNotFound, "API key not found"))
+		return
+	}
+
+	logger.Info().Int64("api_key_id", id).Msg("API key revoked")
+	audit.Record(r, audit.Entry{Action: audit.ActionAPIKeyRevoke, Target: "apikey:" + strconv.FormatInt(id, 10)})
+	sendJSON(w, r, http.StatusOK, i18n.MsgAPIKeyRevoked, nil)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"rim-router-service-ver-cgo/internal/models"
+	"rim-router-service-ver-cgo/internal/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateAPIKey_ReturnsKeyOnce(t *testing.T) {
+	h, mock, cleanup := setupAdminHandler(t)
+	defer cleanup()
+	h.APIKeys = models.NewAPIKeyRepository(h.UserRepo.DB)
+
+	mock.ExpectExec("INSERT INTO api_keys").
+		WithArgs("monitoring", sqlmock.AnyArg(), sqlmock.AnyArg(), int64(1), "logs:read", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+
+	body := `{"name":"monitoring","scopes":["logs:read"],"ttl":"24h"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/admin/apikeys", bytes.NewBufferString(body))
+	req = withClaims(req, &utils.Claims{UserID: 1, Username: "admin", Role: 1})
+	w := httptest.NewRecorder()
+
+	h.CreateAPIKey(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var resp struct {
+		Data APIKeyCreated `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, int64(3), resp.Data.ID)
+	assert.True(t, strings.HasPrefix(resp.Data.Key, resp.Data.Prefix))
+	assert.Equal(t, []string{"logs:read"}, resp.Data.Scopes)
+	assert.NotNil(t, resp.Data.ExpiresAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateAPIKey_UnknownScope(t *testing.T) {
+	h, mock, cleanup := setupAdminHandler(t)
+	defer cleanup()
+	h.APIKeys = models.NewAPIKeyRepository(h.UserRepo.DB)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/admin/apikeys", bytes.NewBufferString(`{"name":"x","scopes":["root"]}`))
+	req = withClaims(req, &utils.Claims{UserID: 1, Role: 1})
+	w := httptest.NewRecorder()
+
+	h.CreateAPIKey(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Unknown scope")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateAPIKey_Disabled(t *testing.T) {
+	h, _, cleanup := setupAdminHandler(t)
+	defer cleanup()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/admin/apikeys", bytes.NewBufferString(`{}`))
+	w := httptest.NewRecorder()
+
+	h.CreateAPIKey(w, req)
+
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+}
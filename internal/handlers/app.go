@@ -6,11 +6,16 @@ import (
 	"sync"
 	"time"
 
-	"github.com/rs/zerolog/log"
+	"rim-router-service-ver-cgo/internal/apierr"
+	"rim-router-service-ver-cgo/internal/audit"
+	"rim-router-service-ver-cgo/internal/i18n"
+	"rim-router-service-ver-cgo/internal/middleware"
+	"rim-router-service-ver-cgo/internal/version"
 )
 
 var (
-	tirStatus   bool
+	tirDesired  bool // состояние, заданное последним start/stop
+	tirStatus   bool // фактическое состояние ТИР
 	tirStatusMu sync.RWMutex
 )
 
@@ -20,13 +25,17 @@ type Response struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
-func sendJSON(w http.ResponseWriter, code int, message string, data interface{}) {
+// sendJSON отправляет успешный ответ; текст сообщения берётся из каталога
+// на языке запроса. Ошибки отправляются через apierr.Write.
+func sendJSON(w http.ResponseWriter, r *http.Request, code int, message i18n.Key, data interface{}) {
+	lang := i18n.FromContext(r.Context())
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Content-Language", string(lang))
 	w.WriteHeader(code)
 
 	response := Response{
 		Code:    code,
-		Message: message,
+		Message: i18n.T(lang, message),
 		Data:    data,
 	}
 
@@ -40,77 +49,115 @@ func sendJSON(w http.ResponseWriter, code int, message string, data interface{})
 	_, _ = w.Write(jsonBytes)
 }
 
+// NotFound — ответ на неизвестный маршрут
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	apierr.Write(w, r, apierr.New(http.StatusNotFound, apierr.CodeNotFound, "Not found"))
+}
+
+// MethodNotAllowed — маршрут есть, но не для этого метода
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	apierr.Write(w, r, apierr.New(http.StatusMethodNotAllowed, apierr.CodeMethodNotAllowed, "Method not allowed"))
+}
+
 func HealthHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	response := map[string]interface{}{
 		"ok":      true,
 		"message": "server is running",
+		"version": version.Get().Version,
 		"ts":      time.Now().UnixMilli(),
 	}
 
-	logger := log.With().Str("module", "system").Logger()
+	logger := middleware.Log(r, "system")
 	logger.Debug().Msg("Health check")
 
 	_ = json.NewEncoder(w).Encode(response)
 }
 
 func GetSoftwareVer(w http.ResponseWriter, r *http.Request) {
-	logger := log.With().
-		Str("module", "system").
-		Str("endpoint", "/api/v1/softwareVer").
-		Logger()
+	logger := middleware.Log(r, "system")
 	(&logger).Info().Msg("Software version requested")
 
-	sendJSON(w, http.StatusOK, "Success", "1.99.999")
+	// формат ответа прежний — строка версии; подробности в /api/v1/version
+	sendJSON(w, r, http.StatusOK, i18n.MsgSuccess, version.Get().Version)
+}
+
+// GET /api/v1/version — версия, коммит, дата сборки, Go и CGO
+func GetVersion(w http.ResponseWriter, r *http.Request) {
+	sendJSON(w, r, http.StatusOK, i18n.MsgSuccess, version.Get())
+}
+
+// GET /api/v2/admin/ratelimits — счётчики ограничителей запросов
+func GetRateLimitStats(w http.ResponseWriter, r *http.Request) {
+	sendJSON(w, r, http.StatusOK, i18n.MsgOK, middleware.RateLimitStats())
+}
+
+// TirRunning сообщает, запущен ли ТИР
+func TirRunning() bool {
+	tirStatusMu.RLock()
+	defer tirStatusMu.RUnlock()
+	return tirStatus
+}
+
+// TirDesired сообщает, в каком состоянии ТИР должен быть после последнего start/stop
+func TirDesired() bool {
+	tirStatusMu.RLock()
+	defer tirStatusMu.RUnlock()
+	return tirDesired
+}
+
+// SetTirRunning — супервизор сообщает фактическое состояние процесса ТИР
+// (например, процесс завершился сам). Желаемое состояние не меняется, так что
+// readiness увидит расхождение.
+func SetTirRunning(running bool) {
+	tirStatusMu.Lock()
+	defer tirStatusMu.Unlock()
+	tirStatus = running
 }
 
 func StartTir(w http.ResponseWriter, r *http.Request) {
-	logger := log.With().
-		Str("module", "system").
-		Str("endpoint", "/api/v2/startTir").
-		Logger()
+	logger := middleware.Log(r, "system")
 
 	tirStatusMu.Lock()
 	defer tirStatusMu.Unlock()
 
 	if tirStatus {
 		(&logger).Warn().Msg("TIR already started")
-		sendJSON(w, http.StatusBadRequest, "ТИР уже запущен", nil)
+		apierr.Write(w, r, apierr.New(http.StatusBadRequest, apierr.CodeTirAlreadyRunning, "TIR is already running"))
 		return
 	}
 
-	tirStatus = true
+	tirDesired, tirStatus = true, true
 	(&logger).Info().Msg("TIR started successfully")
-	sendJSON(w, http.StatusOK, "ТИР успешно запущен", nil)
+	audit.Record(r, audit.Entry{Action: audit.ActionTirStart, Target: "tir",
+		Before: map[string]bool{"running": false}, After: map[string]bool{"running": true}})
+	sendJSON(w, r, http.StatusOK, i18n.MsgTirStarted, nil)
 }
 
 func StopTir(w http.ResponseWriter, r *http.Request) {
-	logger := log.With().
-		Str("module", "system").
-		Str("endpoint", "/api/v2/stopTir").
-		Logger()
+	logger := middleware.Log(r, "system")
 
 	tirStatusMu.Lock()
 	defer tirStatusMu.Unlock()
 
 	if !tirStatus {
 		(&logger).Warn().Msg("TIR not running")
-		sendJSON(w, http.StatusBadRequest, "ТИР не запущен", nil)
+		apierr.Write(w, r, apierr.New(http.StatusBadRequest, apierr.CodeTirNotRunning, "TIR is not running"))
 		return
 	}
 
-	tirStatus = false
+	tirDesired, tirStatus = false, false
 	(&logger).Info().Msg("TIR stopped successfully")
-	sendJSON(w, http.StatusOK, "ТИР успешно остановлен", nil)
+	audit.Record(r, audit.Entry{Action: audit.ActionTirStop, Target: "tir",
+		Before: map[string]bool{"running": true}, After: map[string]bool{"running": false}})
+	sendJSON(w, r, http.StatusOK, i18n.MsgTirStopped, nil)
 }
 
 func RestartTir(w http.ResponseWriter, r *http.Request) {
-	logger := log.With().
-		Str("module", "system").
-		Str("endpoint", "/api/v2/restartTir").
-		Logger()
+	logger := middleware.Log(r, "system")
 	(&logger).Info().Msg("TIR restarted")
+	audit.Record(r, audit.Entry{Action: audit.ActionTirRestart, Target: "tir"})
 
-	sendJSON(w, http.StatusOK, "ТИР успешно перезапущен", nil)
+	sendJSON(w, r, http.StatusOK, i18n.MsgTirRestarted, nil)
 }
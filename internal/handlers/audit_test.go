@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var auditColumns = []string{"id", "ts", "actor", "actor_id", "action", "target", "request_id", "ip", "before", "after"}
+
+func setupAuditHandler(t *testing.T) (*AuditHandler, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Ошибка создания mock DB: %v", err)
+	}
+	return NewAuditHandler(models.NewAuditRepository(db)), mock, func() { db.Close() }
+}
+
+func TestAuditList_FiltersAndPagination(t *testing.T) {
+	h, mock, cleanup := setupAuditHandler(t)
+	defer cleanup()
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM audit_events WHERE action = \\? AND ts >= \\?").
+		WithArgs("user.role_change", from).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery("FROM audit_events WHERE action = \\? AND ts >= \\? ORDER BY id DESC LIMIT \\? OFFSET \\?").
+		WithArgs("user.role_change", from, 2, 1).
+		WillReturnRows(sqlmock.NewRows(auditColumns).
+			AddRow(3, from, "admin", 1, "user.role_change", "bob", "req-1", "10.0.0.1", `{"role":0}`, `{"role":1}`))
+
+	req := httptest.NewRequest(http.MethodGet,
+		"/api/v2/audit?action=user.role_change&from=2024-01-01T00:00:00Z&limit=2&offset=1", nil)
+	w := httptest.NewRecorder()
+
+	h.List(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data AuditPage `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, 3, resp.Data.Total)
+	assert.Equal(t, 2, resp.Data.Limit)
+	if assert.Len(t, resp.Data.Events, 1) {
+		assert.Equal(t, "bob", resp.Data.Events[0].Target)
+		assert.JSONEq(t, `{"role":1}`, string(resp.Data.Events[0].After))
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuditList_InvalidFrom(t *testing.T) {
+	h, _, cleanup := setupAuditHandler(t)
+	defer cleanup()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/audit?from=yesterday", nil)
+	w := httptest.NewRecorder()
+
+	h.List(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAuditExport_NDJSON(t *testing.T) {
+	h, mock, cleanup := setupAuditHandler(t)
+	defer cleanup()
+
+	now := time.Now().UTC()
+	mock.ExpectQuery("FROM audit_events WHERE actor = \\? ORDER BY id ASC").
+		WithArgs("admin").
+		WillReturnRows(sqlmock.NewRows(auditColumns).
+			AddRow(1, now, "admin", 1, "auth.login", "admin", "", "", nil, nil).
+			AddRow(2, now, "admin", 1, "tir.start", "tir", "", "", `{"running":false}`, `{"running":true}`))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/audit/export?actor=admin", nil)
+	w := httptest.NewRecorder()
+
+	h.Export(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		var e models.AuditEvent
+		assert.NoError(t, json.Unmarshal([]byte(lines[1]), &e))
+		assert.Equal(t, "tir.start", e.Action)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,30 +2,62 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"regexp"
+	"strings"
 	"time"
 
+	"rim-router-service-ver-cgo/internal/apierr"
+	"rim-router-service-ver-cgo/internal/audit"
+	"rim-router-service-ver-cgo/internal/authn"
 	"rim-router-service-ver-cgo/internal/config"
+	"rim-router-service-ver-cgo/internal/i18n"
+	"rim-router-service-ver-cgo/internal/metrics"
 	"rim-router-service-ver-cgo/internal/models"
 	"rim-router-service-ver-cgo/internal/utils"
 
-	"github.com/rs/zerolog/log"
-	"golang.org/x/crypto/bcrypt"
+	"rim-router-service-ver-cgo/internal/middleware"
+
+	"github.com/rs/zerolog"
 )
 
 type AuthHandler struct {
-	UserRepo  *models.UserRepository
-	TokenRepo *models.TokenRepository
+	UserRepo      *models.UserRepository
+	TokenRepo     *models.TokenRepository
+	Authenticator authn.Authenticator // проверка пароля при входе; по умолчанию — локальная база
+	Guard         *LoginGuard         // защита от перебора; nil — выключена
+
+	Invites      *models.InviteRepository // коды приглашений для режима invite
+	Registration config.RegistrationConfig
+
+	MFA       *models.MFARepository // TOTP; nil — вход по одному паролю
+	MFAConfig config.MFAConfig
+
+	Session config.SessionConfig // атрибуты cookie и режим cookie-сессий
 }
 
 func NewAuthHandler(userRepo *models.UserRepository, tokenRepo *models.TokenRepository) *AuthHandler {
-	return &AuthHandler{UserRepo: userRepo, TokenRepo: tokenRepo}
+	return &AuthHandler{
+		UserRepo:      userRepo,
+		TokenRepo:     tokenRepo,
+		Authenticator: authn.NewLocal(userRepo),
+		Registration:  config.GetRegistrationConfig(),
+		MFAConfig:     config.GetMFAConfig(),
+		Session:       config.GetSessionConfig(),
+	}
 }
 
 type RegisterRequest struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	InviteCode string `json:"invite_code,omitempty"`
+}
+
+// RegisterResponse — состояние созданной учётной записи и режим регистрации
+type RegisterResponse struct {
+	Status string `json:"status"`
+	Mode   string `json:"mode"`
 }
 
 type LoginRequest struct {
@@ -33,125 +65,400 @@ type LoginRequest struct {
 	Password string `json:"password"`
 }
 
+// PasswordPolicyError — данные ответа 400 при нарушении политики паролей
+type PasswordPolicyError struct {
+	Violations []utils.PolicyViolation `json:"violations"`
+}
+
 type AuthResponse struct {
-	AccessToken string `json:"access_token"`
-	Role        int    `json:"role"`
+	AccessToken        string `json:"access_token,omitempty"` // пусто в режиме cookie-сессий
+	Role               int    `json:"role"`
+	MustChangePassword bool   `json:"must_change_password,omitempty"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
 }
 
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
+	mode := h.Registration.Mode
+	if mode == config.RegistrationDisabled || mode == "" {
+		apierr.Write(w, r, apierr.New(http.StatusForbidden, apierr.CodeRegistrationDisabled, "Registration is disabled"))
+		return
+	}
+
 	var req RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		sendJSON(w, http.StatusBadRequest, "Invalid request", nil)
+		apierr.Write(w, r, apierr.ErrInvalidRequest)
+		return
+	}
+	if mode == config.RegistrationInvite && req.InviteCode == "" {
+		apierr.Write(w, r, apierr.New(http.StatusForbidden, apierr.CodeInviteRequired, "Invite code required"))
 		return
 	}
 
 	if len(req.Username) < 3 || len(req.Username) > 20 {
-		sendJSON(w, http.StatusBadRequest, "Username must be 3-20 characters", nil)
+		apierr.Write(w, r, apierr.Validation("Username must be 3-20 characters", apierr.Field("username", "length", "")))
 		return
 	}
-	if len(req.Password) < 6 {
-		sendJSON(w, http.StatusBadRequest, "Password must be at least 6 characters", nil)
+	if matched, _ := regexp.MatchString("^[a-zA-Z0-9_]+$", req.Username); !matched {
+		apierr.Write(w, r, apierr.Validation("Username can only contain letters, numbers and underscores", apierr.Field("username", "charset", "")))
 		return
 	}
-	if matched, _ := regexp.MatchString("^[a-zA-Z0-9_]+$", req.Username); !matched {
-		sendJSON(w, http.StatusBadRequest, "Username can only contain letters, numbers and underscores", nil)
+	if violations := utils.ValidatePassword(config.GetPasswordConfig(), req.Username, req.Password); len(violations) > 0 {
+		apierr.Write(w, r, passwordPolicyError(violations))
 		return
 	}
 
-	logger := log.With().Str("module", "auth").Str("user", req.Username).Logger()
+	logger := middleware.Log(r, "auth").With().Str("user", req.Username).Logger()
 
 	exists, err := h.UserRepo.UserExists(req.Username)
 	if err != nil {
 		(&logger).Error().Err(err).Msg("Database error during registration")
-		sendJSON(w, http.StatusInternalServerError, "Database error", nil)
+		apierr.Write(w, r, apierr.ErrDatabase)
 		return
 	}
 	if exists {
 		(&logger).Warn().Msg("Attempt to register existing user")
-		sendJSON(w, http.StatusConflict, "User already exists", nil)
+		apierr.Write(w, r, apierr.New(http.StatusConflict, apierr.CodeUserExists, "User already exists"))
 		return
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
 		(&logger).Error().Msg("Password hashing failed")
-		sendJSON(w, http.StatusInternalServerError, "Password hashing failed", nil)
+		apierr.Write(w, r, apierr.New(http.StatusInternalServerError, apierr.CodeInternal, "Password hashing failed"))
 		return
 	}
 
-	if err := h.UserRepo.CreateUser(req.Username, string(hashedPassword), 0); err != nil {
+	if mode == config.RegistrationInvite {
+		if h.Invites == nil {
+			apierr.Write(w, r, apierr.New(http.StatusForbidden, apierr.CodeRegistrationDisabled, "Registration is disabled"))
+			return
+		}
+		ok, err := h.Invites.ConsumeInvite(req.InviteCode, req.Username, time.Now())
+		if err != nil {
+			(&logger).Error().Err(err).Msg("Failed to consume invite code")
+			apierr.Write(w, r, apierr.ErrDatabase)
+			return
+		}
+		if !ok {
+			(&logger).Warn().Msg("Registration with invalid invite code")
+			apierr.Write(w, r, apierr.New(http.StatusForbidden, apierr.CodeInvalidInvite, "Invalid or expired invite code"))
+			return
+		}
+	}
+
+	status := models.UserStatusActive
+	if mode == config.RegistrationApproval {
+		status = models.UserStatusPending
+		err = h.UserRepo.CreateUserWithStatus(req.Username, hashedPassword, 0, status)
+	} else {
+		err = h.UserRepo.CreateUser(req.Username, hashedPassword, 0)
+	}
+	if err != nil {
+		if mode == config.RegistrationInvite {
+			// код не должен сгореть из-за неудачной регистрации
+			_ = h.Invites.ReleaseInvite(req.InviteCode)
+		}
 		(&logger).Error().Msg("Failed to create user in database")
-		sendJSON(w, http.StatusInternalServerError, "Failed to create user", nil)
+		apierr.Write(w, r, apierr.New(http.StatusInternalServerError, apierr.CodeDatabase, "Failed to create user"))
 		return
 	}
 
-	(&logger).Info().Msg("User registered successfully")
-	sendJSON(w, http.StatusCreated, "User registered successfully", nil)
+	resp := RegisterResponse{Status: status, Mode: mode}
+	if status == models.UserStatusPending {
+		(&logger).Info().Msg("User registered, pending approval")
+		sendJSON(w, r, http.StatusCreated, i18n.MsgRegistrationPending, resp)
+		return
+	}
+
+	(&logger).Info().Str("mode", mode).Msg("User registered successfully")
+	sendJSON(w, r, http.StatusCreated, i18n.MsgUserRegistered, resp)
 }
 
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		sendJSON(w, http.StatusBadRequest, "Invalid request", nil)
+		apierr.Write(w, r, apierr.ErrInvalidRequest)
 		return
 	}
 
-	logger := log.With().Str("module", "auth").Str("user", req.Username).Logger()
+	ip := middleware.ClientIP(r)
+	logger := middleware.Log(r, "auth").With().Str("user", req.Username).Logger()
 
-	user, err := h.UserRepo.GetUserByUsername(req.Username)
-	if err != nil {
+	if h.Guard != nil {
+		wait, err := h.Guard.Check(req.Username, ip)
+		if err != nil {
+			(&logger).Error().Err(err).Msg("Failed to check login lockout")
+			apierr.Write(w, r, apierr.ErrDatabase)
+			return
+		}
+		if wait > 0 {
+			(&logger).Warn().Str("ip", ip).Dur("retry_after", wait).Msg("Login attempt while locked out")
+			audit.Record(r, audit.Entry{Action: audit.ActionLoginFailed, Actor: req.Username, Target: req.Username,
+				After: map[string]string{"reason": "locked_out"}})
+			metrics.LoginAttempts.Inc(metrics.LoginLockedOut)
+			setRetryAfter(w, wait)
+			apierr.Write(w, r, apierr.New(http.StatusTooManyRequests, apierr.CodeTooManyAttempts, "Too many failed login attempts"))
+			return
+		}
+	}
+
+	user, err := h.Authenticator.Authenticate(req.Username, req.Password)
+	switch {
+	case errors.Is(err, authn.ErrUnknownUser):
 		(&logger).Warn().Msg("User not found during login")
-		sendJSON(w, http.StatusUnauthorized, "Invalid credentials", nil)
+		audit.Record(r, audit.Entry{Action: audit.ActionLoginFailed, Actor: req.Username, Target: req.Username,
+			After: map[string]string{"reason": "unknown_user"}})
+		h.loginFailed(w, r, req.Username, ip)
+		return
+	case errors.Is(err, authn.ErrInvalidCredentials):
+		(&logger).Warn().Msg("Invalid password attempt")
+		audit.Record(r, audit.Entry{Action: audit.ActionLoginFailed, Actor: req.Username, Target: req.Username,
+			After: map[string]string{"reason": "invalid_password"}})
+		h.loginFailed(w, r, req.Username, ip)
+		return
+	case errors.Is(err, authn.ErrNoMappedRole):
+		(&logger).Warn().Msg("Directory user has no mapped role")
+		audit.Record(r, audit.Entry{Action: audit.ActionLoginFailed, Actor: req.Username, Target: req.Username,
+			After: map[string]string{"reason": "no_mapped_role"}})
+		metrics.LoginAttempts.Inc(metrics.LoginDenied)
+		apierr.Write(w, r, apierr.New(http.StatusForbidden, apierr.CodeNoDirectoryRole, "No role is assigned to your directory groups"))
+		return
+	case errors.Is(err, authn.ErrLocalAccount):
+		(&logger).Warn().Msg("Directory login collides with a local account")
+		audit.Record(r, audit.Entry{Action: audit.ActionLoginFailed, Actor: req.Username, Target: req.Username,
+			After: map[string]string{"reason": "local_account_conflict"}})
+		metrics.LoginAttempts.Inc(metrics.LoginDenied)
+		apierr.Write(w, r, apierr.New(http.StatusForbidden, apierr.CodeLocalAccountConflict, "A local account with this name already exists"))
+		return
+	case err != nil:
+		(&logger).Error().Err(err).Str("backend", h.Authenticator.Name()).Msg("Authentication backend error")
+		metrics.LoginAttempts.Inc(metrics.LoginError)
+		apierr.Write(w, r, apierr.New(http.StatusServiceUnavailable, apierr.CodeAuthBackendUnavailable, "Authentication backend unavailable"))
 		return
 	}
 
-	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)) != nil {
-		(&logger).Warn().Msg("Invalid password attempt")
-		sendJSON(w, http.StatusUnauthorized, "Invalid credentials", nil)
+	// Пароль верный, но учётная запись ещё не одобрена или отключена
+	if user.Status != models.UserStatusActive {
+		(&logger).Warn().Str("status", user.Status).Msg("Login to inactive account")
+		audit.Record(r, audit.Entry{Action: audit.ActionLoginFailed, Actor: user.Username, ActorID: user.ID, Target: user.Username,
+			After: map[string]string{"reason": "account_" + user.Status}})
+		metrics.LoginAttempts.Inc(metrics.LoginDenied)
+		apierr.Write(w, r, inactiveAccountError(user.Status))
 		return
 	}
 
-	access, err := utils.GenerateAccessToken(user)
+	// Второй фактор: вместо токенов выдаётся короткоживущий mfa_token
+	purpose, err := h.mfaPurpose(user)
 	if err != nil {
-		(&logger).Error().Msg("Access token generation failed")
-		sendJSON(w, http.StatusInternalServerError, "Token generation failed", nil)
+		(&logger).Error().Err(err).Msg("Failed to load MFA settings")
+		apierr.Write(w, r, apierr.ErrDatabase)
+		return
+	}
+	if purpose == utils.TokenPurposeMFA {
+		// счётчик неудач сбрасывается только после верного кода, иначе
+		// знающий пароль мог бы перебирать коды без блокировки
+		h.sendMFAChallenge(w, r, user, purpose, &logger)
+		return
+	}
+
+	if h.Guard != nil {
+		if err := h.Guard.Succeed(req.Username); err != nil {
+			(&logger).Error().Err(err).Msg("Failed to reset login failures")
+		}
+	}
+
+	if purpose == utils.TokenPurposeMFAEnroll {
+		h.sendMFAChallenge(w, r, user, purpose, &logger)
+		return
+	}
+
+	access, ok := h.issueSession(w, r, user, &logger)
+	if !ok {
 		return
 	}
 
+	(&logger).Info().Msg("User logged in successfully")
+	metrics.LoginAttempts.Inc(metrics.LoginSuccess)
+	audit.Record(r, audit.Entry{Action: audit.ActionLogin, Actor: user.Username, ActorID: user.ID, Target: user.Username})
+
+	resp := AuthResponse{AccessToken: access, Role: user.Role, MustChangePassword: user.MustChangePassword}
+	sendJSON(w, r, http.StatusOK, i18n.MsgLoginSuccessful, resp)
+}
+
+// issueSession выдаёт access-токен и новый refresh-токен (старые сессии
+// пользователя удаляются). При ошибке сам отвечает клиенту и возвращает false.
+func (h *AuthHandler) issueSession(w http.ResponseWriter, r *http.Request, user *models.User, logger *zerolog.Logger) (string, bool) {
+	access, err := utils.GenerateAccessToken(user)
+	if err != nil {
+		logger.Error().Msg("Access token generation failed")
+		apierr.Write(w, r, apierr.New(http.StatusInternalServerError, apierr.CodeInternal, "Token generation failed"))
+		return "", false
+	}
+
 	cfg := config.GetJWTConfig()
 	refresh, err := utils.GenerateSecureToken()
 	if err != nil {
-		(&logger).Error().Msg("Refresh token generation failed")
-		sendJSON(w, http.StatusInternalServerError, "Refresh token generation failed", nil)
-		return
+		logger.Error().Msg("Refresh token generation failed")
+		apierr.Write(w, r, apierr.New(http.StatusInternalServerError, apierr.CodeInternal, "Refresh token generation failed"))
+		return "", false
 	}
 
 	_ = h.TokenRepo.DeleteAllForUser(user.ID)
 	if err := h.TokenRepo.SaveRefreshToken(user.ID, refresh, time.Now().Add(cfg.RefreshExpiration)); err != nil {
-		(&logger).Error().Msg("Failed to persist refresh token")
-		sendJSON(w, http.StatusInternalServerError, "Failed to persist refresh token", nil)
+		logger.Error().Msg("Failed to persist refresh token")
+		apierr.Write(w, r, apierr.New(http.StatusInternalServerError, apierr.CodeInternal, "Failed to persist refresh token"))
+		return "", false
+	}
+
+	if err := h.setSessionCookies(w, refresh, access); err != nil {
+		logger.Error().Msg("CSRF token generation failed")
+		apierr.Write(w, r, apierr.New(http.StatusInternalServerError, apierr.CodeInternal, "Token generation failed"))
+		return "", false
+	}
+	if h.Session.CookieMode {
+		// токен только в HttpOnly cookie — скрипты страницы его не видят
+		return "", true
+	}
+	return access, true
+}
+
+// sendMFAChallenge отвечает на первый шаг входа токеном для второго шага.
+func (h *AuthHandler) sendMFAChallenge(w http.ResponseWriter, r *http.Request, user *models.User, purpose string, logger *zerolog.Logger) {
+	token, err := utils.GenerateMFAToken(user, purpose, h.MFAConfig.ChallengeTTL)
+	if err != nil {
+		logger.Error().Err(err).Msg("MFA token generation failed")
+		apierr.Write(w, r, apierr.New(http.StatusInternalServerError, apierr.CodeInternal, "Token generation failed"))
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "refresh_token",
-		Value:    refresh,
-		Path:     "/",
-		HttpOnly: true,
-		SameSite: http.SameSiteStrictMode,
-		MaxAge:   int(cfg.RefreshExpiration / time.Second),
-	})
+	metrics.LoginAttempts.Inc(metrics.LoginMFA)
+	resp := MFAChallenge{MFARequired: true, MFAToken: token}
+	message := i18n.MsgMFACodeRequired
+	if purpose == utils.TokenPurposeMFAEnroll {
+		resp.EnrollmentRequired = true
+		message = i18n.MsgMFAEnrollRequired
+	}
+	logger.Info().Str("step", purpose).Msg("Password accepted, second factor pending")
+	sendJSON(w, r, http.StatusOK, message, resp)
+}
 
-	(&logger).Info().Msg("User logged in successfully")
+// recheckDirectoryUser подтверждает учётную запись каталога без пароля и
+// синхронизирует роль. Если записи или подходящих групп больше нет, сессии
+// пользователя закрываются; при ошибке сам отвечает клиенту и возвращает false.
+func (h *AuthHandler) recheckDirectoryUser(w http.ResponseWriter, r *http.Request, user *models.User) (*models.User, bool) {
+	logger := middleware.Log(r, "auth").With().Str("user", user.Username).Logger()
+
+	// источник без повторной проверки (например, AUTH_BACKEND снова local)
+	// не может подтвердить пользователя без локального пароля
+	var fresh *models.User
+	err := authn.ErrUnknownUser
+	if rc, ok := h.Authenticator.(authn.Rechecker); ok {
+		fresh, err = rc.Recheck(user.Username)
+	}
+
+	switch {
+	case err == nil:
+		return fresh, true
+	case errors.Is(err, authn.ErrUnknownUser), errors.Is(err, authn.ErrNoMappedRole):
+		_ = h.TokenRepo.DeleteAllForUser(user.ID)
+		if err := utils.RevokeUserTokens(user.ID); err != nil {
+			(&logger).Error().Err(err).Msg("Failed to revoke access tokens")
+		}
+		(&logger).Warn().Err(err).Msg("Refresh refused: directory account no longer valid")
+		metrics.RefreshRotations.Inc("rejected")
+		if errors.Is(err, authn.ErrNoMappedRole) {
+			apierr.Write(w, r, apierr.New(http.StatusForbidden, apierr.CodeNoDirectoryRole, "No role is assigned to your directory groups"))
+		} else {
+			apierr.Write(w, r, apierr.New(http.StatusUnauthorized, apierr.CodeUserNotFound, "User not found"))
+		}
+	default:
+		(&logger).Error().Err(err).Str("backend", h.Authenticator.Name()).Msg("Authentication backend error on refresh")
+		apierr.Write(w, r, apierr.New(http.StatusServiceUnavailable, apierr.CodeAuthBackendUnavailable, "Authentication backend unavailable"))
+	}
+	return nil, false
+}
+
+// lockedOut отвечает 429, если логин или IP заблокированы после неудачных
+// попыток; true — ответ уже отправлен.
+func (h *AuthHandler) lockedOut(w http.ResponseWriter, r *http.Request, username, ip string, logger *zerolog.Logger) bool {
+	if h.Guard == nil {
+		return false
+	}
+	wait, err := h.Guard.Check(username, ip)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to check login lockout")
+		apierr.Write(w, r, apierr.ErrDatabase)
+		return true
+	}
+	if wait > 0 {
+		logger.Warn().Str("ip", ip).Dur("retry_after", wait).Msg("Credential check while locked out")
+		setRetryAfter(w, wait)
+		apierr.Write(w, r, apierr.New(http.StatusTooManyRequests, apierr.CodeTooManyAttempts, "Too many failed login attempts"))
+		return true
+	}
+	return false
+}
+
+// loginFailed учитывает неудачный вход и отвечает 401 или 429 (если наступила блокировка).
+func (h *AuthHandler) loginFailed(w http.ResponseWriter, r *http.Request, username, ip string) {
+	metrics.LoginAttempts.Inc(metrics.LoginFailure)
+	h.credentialsFailed(w, r, username, ip, apierr.New(http.StatusUnauthorized, apierr.CodeInvalidCredentials, "Invalid credentials"))
+}
+
+// credentialsFailed учитывает неверный пароль или код в блокировке входа и
+// отвечает 429, если блокировка наступила, иначе — переданной ошибкой.
+func (h *AuthHandler) credentialsFailed(w http.ResponseWriter, r *http.Request, username, ip string, failure *apierr.Error) {
+	if h.Guard != nil {
+		lock, err := h.Guard.Fail(username, ip)
+		if err != nil {
+			logger := middleware.Log(r, "auth").With().Str("user", username).Logger()
+			(&logger).Error().Err(err).Msg("Failed to record login failure")
+		}
+		if lock > 0 {
+			setRetryAfter(w, lock)
+			apierr.Write(w, r, apierr.New(http.StatusTooManyRequests, apierr.CodeTooManyAttempts, "Too many failed login attempts"))
+			return
+		}
+	}
+	apierr.Write(w, r, failure)
+}
 
-	resp := AuthResponse{AccessToken: access, Role: user.Role}
-	sendJSON(w, http.StatusOK, "Login successful", resp)
+// bearerToken достаёт токен из заголовка Authorization: Bearer <token>
+func bearerToken(r *http.Request) string {
+	parts := strings.Split(r.Header.Get("Authorization"), " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return ""
+	}
+	return parts[1]
+}
+
+// inactiveAccountError — ответ для неактивной учётной записи
+func inactiveAccountError(status string) *apierr.Error {
+	if status == models.UserStatusPending {
+		return apierr.New(http.StatusForbidden, apierr.CodeAccountPending, "Account pending approval")
+	}
+	return apierr.New(http.StatusForbidden, apierr.CodeAccountDisabled, "Account disabled")
+}
+
+// passwordPolicyError — ответ 400 с нарушенными правилами политики паролей
+func passwordPolicyError(violations []utils.PolicyViolation) *apierr.Error {
+	fields := make([]apierr.FieldError, 0, len(violations))
+	for _, v := range violations {
+		fields = append(fields, apierr.Field("password", v.Rule, v.Message))
+	}
+	return apierr.New(http.StatusBadRequest, apierr.CodePasswordPolicy, "Password does not meet policy").
+		WithFields(fields...).
+		WithData(PasswordPolicyError{Violations: violations})
 }
 
 func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
-	cookie, err := r.Cookie("refresh_token")
+	cookie, err := r.Cookie(middleware.RefreshCookieName)
 	if err != nil || cookie.Value == "" {
-		sendJSON(w, http.StatusUnauthorized, "Missing refresh token", nil)
+		apierr.Write(w, r, apierr.New(http.StatusUnauthorized, apierr.CodeInvalidRefreshToken, "Missing refresh token"))
 		return
 	}
 
@@ -159,79 +466,201 @@ func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 	userID, expiresAt, err := h.TokenRepo.GetRefreshToken(token)
 	if err != nil || time.Now().After(expiresAt) {
 		_ = h.TokenRepo.DeleteRefreshToken(token)
-		logger := log.With().Str("module", "auth").Logger()
+		logger := middleware.Log(r, "auth")
 		(&logger).Warn().Msg("Invalid or expired refresh token")
-		sendJSON(w, http.StatusUnauthorized, "Invalid or expired refresh token", nil)
+		metrics.RefreshRotations.Inc("rejected")
+		apierr.Write(w, r, apierr.New(http.StatusUnauthorized, apierr.CodeInvalidRefreshToken, "Invalid or expired refresh token"))
 		return
 	}
 
 	user, err := h.UserRepo.GetUserByID(userID)
 	if err != nil {
-		logger := log.With().Str("module", "auth").Logger()
+		logger := middleware.Log(r, "auth")
 		(&logger).Warn().Msg("User not found for refresh")
-		sendJSON(w, http.StatusUnauthorized, "User not found", nil)
+		apierr.Write(w, r, apierr.New(http.StatusUnauthorized, apierr.CodeUserNotFound, "User not found"))
+		return
+	}
+	if user.Status != models.UserStatusActive {
+		_ = h.TokenRepo.DeleteAllForUser(user.ID)
+		apierr.Write(w, r, inactiveAccountError(user.Status))
 		return
 	}
+	// пароль пользователя каталога проверялся только при входе: каждое
+	// продление сверяется с LDAP, чтобы удалённая учётная запись теряла доступ
+	if user.PasswordHash == models.ExternalPasswordHash {
+		fresh, ok := h.recheckDirectoryUser(w, r, user)
+		if !ok {
+			return
+		}
+		user = fresh
+	}
+	// MFA стала обязательной для роли уже после входа: сессия без второго
+	// фактора не продлевается, пользователь входит заново и подключает MFA
+	if h.MFAConfig.Required(user.Role) {
+		purpose, err := h.mfaPurpose(user)
+		if err != nil {
+			logger := middleware.Log(r, "auth")
+			(&logger).Error().Err(err).Msg("Failed to load MFA settings")
+			apierr.Write(w, r, apierr.ErrDatabase)
+			return
+		}
+		if purpose == utils.TokenPurposeMFAEnroll {
+			_ = h.TokenRepo.DeleteAllForUser(user.ID)
+			logger := middleware.Log(r, "auth").With().Str("user", user.Username).Logger()
+			(&logger).Warn().Msg("Refresh refused: MFA required but not enrolled")
+			metrics.RefreshRotations.Inc("rejected")
+			apierr.Write(w, r, apierr.New(http.StatusForbidden, apierr.CodeMFARequired, "MFA is required for your role"))
+			return
+		}
+	}
 
 	_ = h.TokenRepo.DeleteRefreshToken(token)
 	cfg := config.GetJWTConfig()
 
 	newRefresh, err := utils.GenerateSecureToken()
 	if err != nil {
-		sendJSON(w, http.StatusInternalServerError, "Refresh token generation failed", nil)
+		apierr.Write(w, r, apierr.New(http.StatusInternalServerError, apierr.CodeInternal, "Refresh token generation failed"))
 		return
 	}
 
 	if err := h.TokenRepo.SaveRefreshToken(user.ID, newRefresh, time.Now().Add(cfg.RefreshExpiration)); err != nil {
-		sendJSON(w, http.StatusInternalServerError, "Failed to persist refresh token", nil)
+		apierr.Write(w, r, apierr.New(http.StatusInternalServerError, apierr.CodeInternal, "Failed to persist refresh token"))
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "refresh_token",
-		Value:    newRefresh,
-		Path:     "/",
-		HttpOnly: true,
-		SameSite: http.SameSiteStrictMode,
-		MaxAge:   int(cfg.RefreshExpiration / time.Second),
-	})
-
 	access, err := utils.GenerateAccessToken(user)
 	if err != nil {
-		sendJSON(w, http.StatusInternalServerError, "Token generation failed", nil)
+		apierr.Write(w, r, apierr.New(http.StatusInternalServerError, apierr.CodeInternal, "Token generation failed"))
+		return
+	}
+
+	if err := h.setSessionCookies(w, newRefresh, access); err != nil {
+		apierr.Write(w, r, apierr.New(http.StatusInternalServerError, apierr.CodeInternal, "Token generation failed"))
 		return
 	}
 
-	logger := log.With().Str("module", "auth").Str("user", user.Username).Logger()
+	logger := middleware.Log(r, "auth").With().Str("user", user.Username).Logger()
 	(&logger).Debug().Msg("Refresh token rotated")
+	metrics.RefreshRotations.Inc("rotated")
 
-	sendJSON(w, http.StatusOK, "Token refreshed", map[string]string{
+	if h.Session.CookieMode {
+		sendJSON(w, r, http.StatusOK, i18n.MsgTokenRefreshed, nil)
+		return
+	}
+	sendJSON(w, r, http.StatusOK, i18n.MsgTokenRefreshed, map[string]string{
 		"access_token": access,
 	})
 }
 
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
-	cookie, err := r.Cookie("refresh_token")
+	logger := middleware.Log(r, "auth")
+
+	// Access-токен из заголовка или cookie (если передан) отзывается сразу
+	if token := requestAccessToken(r); token != "" {
+		if claims, err := utils.ValidateTokenFunc(token); err == nil {
+			if err := utils.RevokeAccessToken(claims); err != nil {
+				(&logger).Error().Err(err).Msg("Failed to revoke access token")
+			}
+		}
+	}
+
+	cookie, err := r.Cookie(middleware.RefreshCookieName)
 	if err == nil && cookie.Value != "" {
 		userID, _, _ := h.TokenRepo.GetRefreshToken(cookie.Value)
 		if userID != 0 {
 			_ = h.TokenRepo.DeleteAllForUser(userID)
+			// все сессии пользователя закрыты — вместе с их access-токенами
+			if err := utils.RevokeUserTokens(userID); err != nil {
+				(&logger).Error().Err(err).Msg("Failed to revoke access tokens")
+			}
 		} else {
 			_ = h.TokenRepo.DeleteRefreshToken(cookie.Value)
 		}
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "refresh_token",
-		Value:    "",
-		Path:     "/",
-		HttpOnly: true,
-		SameSite: http.SameSiteStrictMode,
-		MaxAge:   -1,
-	})
+	h.clearSessionCookies(w)
 
-	logger := log.With().Str("module", "auth").Logger()
 	(&logger).Info().Msg("User logged out")
 
-	sendJSON(w, http.StatusOK, "Logged out", nil)
+	sendJSON(w, r, http.StatusOK, i18n.MsgLoggedOut, nil)
+}
+
+// POST /api/v1/password — смена пароля текущим пользователем. Доступна и
+// тогда, когда остальные эндпоинты требуют сначала сменить пароль.
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		apierr.Write(w, r, apierr.ErrAuthRequired)
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, r, apierr.ErrInvalidRequest)
+		return
+	}
+
+	logger := middleware.Log(r, "auth")
+
+	user, err := h.UserRepo.GetUserByID(claims.UserID)
+	if err != nil {
+		(&logger).Warn().Msg("User not found for password change")
+		apierr.Write(w, r, apierr.New(http.StatusUnauthorized, apierr.CodeUserNotFound, "User not found"))
+		return
+	}
+
+	if user.PasswordHash == models.ExternalPasswordHash {
+		apierr.Write(w, r, apierr.New(http.StatusConflict, apierr.CodePasswordManagedExternally, "Password is managed by the external directory"))
+		return
+	}
+
+	// подбор текущего пароля по украденному токену упирается в ту же блокировку, что и вход
+	ip := middleware.ClientIP(r)
+	if h.lockedOut(w, r, user.Username, ip, &logger) {
+		return
+	}
+	if !utils.CheckPassword(user.PasswordHash, req.CurrentPassword) {
+		(&logger).Warn().Msg("Invalid current password on password change")
+		h.credentialsFailed(w, r, user.Username, ip, apierr.New(http.StatusUnauthorized, apierr.CodeInvalidCredentials, "Invalid credentials"))
+		return
+	}
+	if req.NewPassword == req.CurrentPassword {
+		apierr.Write(w, r, apierr.New(http.StatusBadRequest, apierr.CodePasswordReused, "New password must differ from the current one"))
+		return
+	}
+	if violations := utils.ValidatePassword(config.GetPasswordConfig(), user.Username, req.NewPassword); len(violations) > 0 {
+		apierr.Write(w, r, passwordPolicyError(violations))
+		return
+	}
+
+	hash, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		(&logger).Error().Msg("Password hashing failed")
+		apierr.Write(w, r, apierr.New(http.StatusInternalServerError, apierr.CodeInternal, "Password hashing failed"))
+		return
+	}
+	if err := h.UserRepo.UpdatePassword(user.ID, hash); err != nil {
+		(&logger).Error().Err(err).Msg("Failed to update password")
+		apierr.Write(w, r, apierr.New(http.StatusInternalServerError, apierr.CodeDatabase, "Failed to update password"))
+		return
+	}
+	user.PasswordHash = hash
+	user.MustChangePassword = false
+	// иначе кэш повторной проверки ещё TTL требовал бы сменить пароль
+	middleware.InvalidateUser(user.ID)
+
+	// токены, выданные со старым паролем, больше не действуют
+	if err := utils.RevokeUserTokens(user.ID); err != nil {
+		(&logger).Error().Err(err).Msg("Failed to revoke access tokens")
+	}
+
+	// Новая пара токенов: старые refresh-токены удаляются, флаг снят
+	access, ok := h.issueSession(w, r, user, &logger)
+	if !ok {
+		return
+	}
+
+	(&logger).Info().Msg("Password changed")
+	audit.Record(r, audit.Entry{Action: audit.ActionPasswordChange, Target: user.Username})
+	sendJSON(w, r, http.StatusOK, i18n.MsgPasswordChanged, AuthResponse{AccessToken: access, Role: user.Role})
 }
@@ -2,16 +2,25 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"testing"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 
+	"rim-router-service-ver-cgo/internal/apierr"
+	"rim-router-service-ver-cgo/internal/authn"
+	"rim-router-service-ver-cgo/internal/config"
+	"rim-router-service-ver-cgo/internal/metrics"
+	"rim-router-service-ver-cgo/internal/middleware"
 	"rim-router-service-ver-cgo/internal/models"
+	"rim-router-service-ver-cgo/internal/utils"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
@@ -26,6 +35,8 @@ func setupAuthHandler(t *testing.T) (*AuthHandler, sqlmock.Sqlmock, func()) {
 	userRepo := models.NewUserRepository(db)
 	tokenRepo := models.NewTokenRepository(db)
 	handler := NewAuthHandler(userRepo, tokenRepo)
+	handler.Invites = models.NewInviteRepository(db)
+	handler.Registration = config.RegistrationConfig{Mode: config.RegistrationOpen}
 	return handler, mock, func() { db.Close() }
 }
 
@@ -43,7 +54,7 @@ func TestRegister_Success(t *testing.T) {
 		WithArgs("newuser", sqlmock.AnyArg(), 0).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	body := `{"username":"newuser","password":"strongpass"}`
+	body := `{"username":"newuser","password":"Str0ng-pass"}`
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/register", bytes.NewBufferString(body))
 	w := httptest.NewRecorder()
 
@@ -64,7 +75,7 @@ func TestRegister_UserExists(t *testing.T) {
 		WithArgs("john").
 		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
 
-	body := `{"username":"john","password":"123456"}`
+	body := `{"username":"john","password":"Str0ng-pass"}`
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/register", bytes.NewBufferString(body))
 	w := httptest.NewRecorder()
 
@@ -99,6 +110,35 @@ func TestRegister_ShortPassword(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestRegister_WeakPassword(t *testing.T) {
+	h, _, cleanup := setupAuthHandler(t)
+	defer cleanup()
+
+	body := `{"username":"okuser","password":"Admin123"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/register", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	h.Register(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp struct {
+		Message string              `json:"message"`
+		Error   apierr.Code         `json:"error"`
+		Errors  []apierr.FieldError `json:"errors"`
+		Data    PasswordPolicyError `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, "Password does not meet policy", resp.Message)
+	assert.Equal(t, apierr.CodePasswordPolicy, resp.Error)
+	if assert.Len(t, resp.Data.Violations, 1) {
+		assert.Equal(t, "denylist", resp.Data.Violations[0].Rule)
+	}
+	if assert.Len(t, resp.Errors, 1) {
+		assert.Equal(t, apierr.FieldError{Field: "password", Code: "denylist", Message: resp.Data.Violations[0].Message}, resp.Errors[0])
+	}
+}
+
 func TestRegister_DBError(t *testing.T) {
 	h, mock, cleanup := setupAuthHandler(t)
 	defer cleanup()
@@ -107,7 +147,7 @@ func TestRegister_DBError(t *testing.T) {
 		WithArgs("alex").
 		WillReturnError(sql.ErrConnDone)
 
-	body := `{"username":"alex","password":"1234567"}`
+	body := `{"username":"alex","password":"Str0ng-pass"}`
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/register", bytes.NewBufferString(body))
 	w := httptest.NewRecorder()
 
@@ -116,16 +156,130 @@ func TestRegister_DBError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
 
+func TestRegister_Disabled(t *testing.T) {
+	h, mock, cleanup := setupAuthHandler(t)
+	defer cleanup()
+	h.Registration.Mode = config.RegistrationDisabled
+
+	body := `{"username":"newuser","password":"Str0ng-pass"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/register", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	h.Register(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRegister_ApprovalCreatesPendingUser(t *testing.T) {
+	h, mock, cleanup := setupAuthHandler(t)
+	defer cleanup()
+	h.Registration.Mode = config.RegistrationApproval
+
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs("newuser").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec("INSERT INTO users").
+		WithArgs("newuser", sqlmock.AnyArg(), 0, models.UserStatusPending).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	body := `{"username":"newuser","password":"Str0ng-pass"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/register", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	h.Register(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var resp struct {
+		Message string           `json:"message"`
+		Data    RegisterResponse `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, "Registration pending approval", resp.Message)
+	assert.Equal(t, models.UserStatusPending, resp.Data.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRegister_InviteSuccess(t *testing.T) {
+	h, mock, cleanup := setupAuthHandler(t)
+	defer cleanup()
+	h.Registration.Mode = config.RegistrationInvite
+
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs("newuser").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec("UPDATE invite_codes SET used_by").
+		WithArgs("newuser", sqlmock.AnyArg(), models.HashToken("CODE123"), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO users").
+		WithArgs("newuser", sqlmock.AnyArg(), 0).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	body := `{"username":"newuser","password":"Str0ng-pass","invite_code":"CODE123"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/register", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	h.Register(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRegister_InviteInvalid(t *testing.T) {
+	h, mock, cleanup := setupAuthHandler(t)
+	defer cleanup()
+	h.Registration.Mode = config.RegistrationInvite
+
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs("newuser").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec("UPDATE invite_codes SET used_by").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	body := `{"username":"newuser","password":"Str0ng-pass","invite_code":"USED"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/register", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	h.Register(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 // ========== TEST: Login ==========
 
+func TestLogin_PendingAccount(t *testing.T) {
+	h, mock, cleanup := setupAuthHandler(t)
+	defer cleanup()
+
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	rows := sqlmock.NewRows([]string{"id", "username", "password_hash", "role", "must_change_password", "status", "created_at", "locale"}).
+		AddRow(1, "tester", string(hashed), 0, false, models.UserStatusPending, time.Now(), "")
+	mock.ExpectQuery("SELECT id, username").
+		WithArgs("tester").
+		WillReturnRows(rows)
+
+	body := `{"username":"tester","password":"password123"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/login", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	h.Login(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	var resp Response
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, "Account pending approval", resp.Message)
+}
+
 func TestLogin_Success(t *testing.T) {
 	h, mock, cleanup := setupAuthHandler(t)
 	defer cleanup()
 
 	hashed, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
 
-	rows := sqlmock.NewRows([]string{"id", "username", "password_hash", "role", "created_at"}).
-		AddRow(1, "tester", string(hashed), 0, time.Now())
+	rows := sqlmock.NewRows([]string{"id", "username", "password_hash", "role", "must_change_password", "status", "created_at", "locale"}).
+		AddRow(1, "tester", string(hashed), 0, false, "active", time.Now(), "")
 
 	mock.ExpectQuery("SELECT id, username").
 		WithArgs("tester").
@@ -171,13 +325,15 @@ func TestLogin_InvalidPassword(t *testing.T) {
 
 	hashed, _ := bcrypt.GenerateFromPassword([]byte("realpass"), bcrypt.DefaultCost)
 
-	rows := sqlmock.NewRows([]string{"id", "username", "password_hash", "role", "created_at"}).
-		AddRow(1, "john", string(hashed), 0, time.Now())
+	rows := sqlmock.NewRows([]string{"id", "username", "password_hash", "role", "must_change_password", "status", "created_at", "locale"}).
+		AddRow(1, "john", string(hashed), 0, false, "active", time.Now(), "")
 
 	mock.ExpectQuery("SELECT id, username").
 		WithArgs("john").
 		WillReturnRows(rows)
 
+	failures := metrics.LoginAttempts.Value(metrics.LoginFailure)
+
 	body := `{"username":"john","password":"wrong"}`
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/login", bytes.NewBufferString(body))
 	w := httptest.NewRecorder()
@@ -185,6 +341,42 @@ func TestLogin_InvalidPassword(t *testing.T) {
 	h.Login(w, req)
 
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), `"error":"invalid_credentials"`)
+	assert.Equal(t, failures+1, metrics.LoginAttempts.Value(metrics.LoginFailure))
+}
+
+func TestLogin_RehashOnCostChange(t *testing.T) {
+	h, mock, cleanup := setupAuthHandler(t)
+	defer cleanup()
+
+	t.Setenv("PASSWORD_BCRYPT_COST", "5")
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+
+	rows := sqlmock.NewRows([]string{"id", "username", "password_hash", "role", "must_change_password", "status", "created_at", "locale"}).
+		AddRow(1, "tester", string(hashed), 0, false, "active", time.Now(), "")
+	mock.ExpectQuery("SELECT id, username").
+		WithArgs("tester").
+		WillReturnRows(rows)
+
+	mock.ExpectExec("UPDATE users SET password_hash").
+		WithArgs(sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectExec("DELETE FROM refresh_tokens").
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO refresh_tokens").
+		WithArgs(int64(1), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	body := `{"username":"tester","password":"password123"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/login", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	h.Login(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
 // ========== TEST: Refresh ==========
@@ -196,18 +388,18 @@ func TestRefresh_Success(t *testing.T) {
 	now := time.Now().Add(10 * time.Minute)
 
 	mock.ExpectQuery("SELECT user_id, expires_at").
-		WithArgs("refresh123").
+		WithArgs(models.HashToken("refresh123")).
 		WillReturnRows(sqlmock.NewRows([]string{"user_id", "expires_at"}).AddRow(1, now))
 
-	rows := sqlmock.NewRows([]string{"id", "username", "password_hash", "role", "created_at"}).
-		AddRow(1, "user", "hash", 0, time.Now())
+	rows := sqlmock.NewRows([]string{"id", "username", "password_hash", "role", "must_change_password", "status", "created_at", "locale"}).
+		AddRow(1, "user", "hash", 0, false, "active", time.Now(), "")
 
 	mock.ExpectQuery("SELECT id, username").
 		WithArgs(int64(1)).
 		WillReturnRows(rows)
 
 	mock.ExpectExec("DELETE FROM refresh_tokens").
-		WithArgs("refresh123").
+		WithArgs(models.HashToken("refresh123")).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
 	mock.ExpectExec("INSERT INTO refresh_tokens").
@@ -228,7 +420,7 @@ func TestRefresh_InvalidToken(t *testing.T) {
 	defer cleanup()
 
 	mock.ExpectQuery("SELECT user_id, expires_at").
-		WithArgs("expired").
+		WithArgs(models.HashToken("expired")).
 		WillReturnError(sql.ErrNoRows)
 
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/refresh", nil)
@@ -247,7 +439,7 @@ func TestLogout_Success(t *testing.T) {
 	defer cleanup()
 
 	mock.ExpectQuery("SELECT user_id, expires_at").
-		WithArgs("ref123").
+		WithArgs(models.HashToken("ref123")).
 		WillReturnRows(sqlmock.NewRows([]string{"user_id", "expires_at"}).AddRow(5, time.Now().Add(1*time.Hour)))
 
 	mock.ExpectExec("DELETE FROM refresh_tokens WHERE user_id = ?").
@@ -262,3 +454,318 @@ func TestLogout_Success(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 }
+
+func TestLogout_RevokesAccessToken(t *testing.T) {
+	h, _, cleanup := setupAuthHandler(t)
+	defer cleanup()
+
+	access, err := utils.GenerateAccessToken(&models.User{ID: 8, Username: "leaving"})
+	assert.NoError(t, err)
+	_, err = utils.ValidateToken(access)
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/logout", nil)
+	req.Header.Set("Authorization", "Bearer "+access)
+	w := httptest.NewRecorder()
+
+	h.Logout(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	_, err = utils.ValidateToken(access)
+	assert.ErrorIs(t, err, utils.ErrTokenRevoked)
+}
+
+// ========== TEST: ChangePassword ==========
+
+func withClaims(req *http.Request, claims *utils.Claims) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), middleware.UserContextKey, claims))
+}
+
+func TestChangePassword_Success(t *testing.T) {
+	h, mock, cleanup := setupAuthHandler(t)
+	defer cleanup()
+
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("admin123"), bcrypt.MinCost)
+	mock.ExpectQuery("SELECT id, username").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password_hash", "role", "must_change_password", "status", "created_at", "locale"}).
+			AddRow(1, "admin", string(hashed), 1, true, "active", time.Now(), ""))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET password_hash = ?, must_change_password = 0 WHERE id = ?")).
+		WithArgs(sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM refresh_tokens").
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO refresh_tokens").
+		WithArgs(int64(1), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	body := `{"current_password":"admin123","new_password":"N3w-Secure-pass"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/password", bytes.NewBufferString(body))
+	req = withClaims(req, &utils.Claims{UserID: 1, Username: "admin", Role: 1, MustChangePassword: true})
+	w := httptest.NewRecorder()
+
+	h.ChangePassword(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChangePassword_InvalidatesRevalidationCache(t *testing.T) {
+	h, mock, cleanup := setupAuthHandler(t)
+	defer cleanup()
+
+	lookups := 0
+	middleware.EnableUserRevalidation(func(id int64) (*models.User, error) {
+		lookups++
+		return &models.User{ID: id, Username: "admin", Role: 1, Status: models.UserStatusActive, MustChangePassword: true}, nil
+	}, time.Minute)
+	defer middleware.EnableUserRevalidation(nil, 0)
+
+	oldValidate := utils.ValidateTokenFunc
+	defer func() { utils.ValidateTokenFunc = oldValidate }()
+	utils.ValidateTokenFunc = func(token string) (*utils.Claims, error) {
+		return &utils.Claims{UserID: 1, Username: "admin", Role: 1}, nil
+	}
+	touch := func() {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/me", nil)
+		req.Header.Set("Authorization", "Bearer token")
+		middleware.AuthMiddleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).ServeHTTP(httptest.NewRecorder(), req)
+	}
+	touch()
+	assert.Equal(t, 1, lookups)
+
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("admin123"), bcrypt.MinCost)
+	mock.ExpectQuery("SELECT id, username").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password_hash", "role", "must_change_password", "status", "created_at", "locale"}).
+			AddRow(1, "admin", string(hashed), 1, true, "active", time.Now(), ""))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET password_hash = ?, must_change_password = 0 WHERE id = ?")).
+		WithArgs(sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM refresh_tokens").
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO refresh_tokens").
+		WithArgs(int64(1), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	body := `{"current_password":"admin123","new_password":"N3w-Secure-pass"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/password", bytes.NewBufferString(body))
+	req = withClaims(req, &utils.Claims{UserID: 1, Username: "admin", Role: 1, MustChangePassword: true})
+	w := httptest.NewRecorder()
+	h.ChangePassword(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// следующий запрос перечитывает пользователя, а не берёт флаг из кэша
+	touch()
+	assert.Equal(t, 2, lookups)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChangePassword_WrongCurrent(t *testing.T) {
+	h, mock, cleanup := setupAuthHandler(t)
+	defer cleanup()
+
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("admin123"), bcrypt.MinCost)
+	mock.ExpectQuery("SELECT id, username").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password_hash", "role", "must_change_password", "status", "created_at", "locale"}).
+			AddRow(1, "admin", string(hashed), 1, true, "active", time.Now(), ""))
+
+	body := `{"current_password":"nope","new_password":"N3w-Secure-pass"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/password", bytes.NewBufferString(body))
+	req = withClaims(req, &utils.Claims{UserID: 1, Username: "admin", Role: 1})
+	w := httptest.NewRecorder()
+
+	h.ChangePassword(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// ========== TEST: внешние источники учётных записей ==========
+
+// failingAuthenticator всегда возвращает заданную ошибку
+type failingAuthenticator struct{ err error }
+
+func (a failingAuthenticator) Name() string { return "ldap" }
+
+func (a failingAuthenticator) Authenticate(username, password string) (*models.User, error) {
+	return nil, a.err
+}
+
+func TestLogin_AuthenticatorErrors(t *testing.T) {
+	cases := []struct {
+		err  error
+		code int
+	}{
+		{authn.ErrNoMappedRole, http.StatusForbidden},
+		{authn.ErrLocalAccount, http.StatusForbidden},
+		{errors.New("ldap dial: connection refused"), http.StatusServiceUnavailable},
+		{authn.ErrInvalidCredentials, http.StatusUnauthorized},
+	}
+	for _, c := range cases {
+		h, _, cleanup := setupAuthHandler(t)
+		h.Authenticator = failingAuthenticator{err: c.err}
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/login", bytes.NewBufferString(`{"username":"alice","password":"x"}`))
+		w := httptest.NewRecorder()
+		h.Login(w, req)
+
+		assert.Equal(t, c.code, w.Code, c.err.Error())
+		cleanup()
+	}
+}
+
+// directoryAuthenticator — каталог, который при продлении сессии отвечает заданной ошибкой
+type directoryAuthenticator struct {
+	failingAuthenticator
+	recheckErr error
+}
+
+func (a directoryAuthenticator) Recheck(username string) (*models.User, error) {
+	if a.recheckErr != nil {
+		return nil, a.recheckErr
+	}
+	return &models.User{ID: 1, Username: username, PasswordHash: models.ExternalPasswordHash, Role: 0, Status: models.UserStatusActive}, nil
+}
+
+func TestRefresh_DirectoryUserRechecked(t *testing.T) {
+	cases := []struct {
+		err       error
+		code      int
+		loggedOut bool
+	}{
+		{nil, http.StatusOK, false},
+		{authn.ErrUnknownUser, http.StatusUnauthorized, true},
+		{authn.ErrNoMappedRole, http.StatusForbidden, true},
+		{errors.New("ldap dial: connection refused"), http.StatusServiceUnavailable, false},
+	}
+	for _, c := range cases {
+		h, mock, cleanup := setupAuthHandler(t)
+		h.Authenticator = directoryAuthenticator{recheckErr: c.err}
+
+		mock.ExpectQuery("SELECT user_id, expires_at").
+			WithArgs(models.HashToken("refresh123")).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "expires_at"}).AddRow(1, time.Now().Add(time.Hour)))
+		mock.ExpectQuery("SELECT id, username").
+			WithArgs(int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password_hash", "role", "must_change_password", "status", "created_at", "locale"}).
+				AddRow(1, "alice", models.ExternalPasswordHash, 0, false, "active", time.Now(), ""))
+		if c.loggedOut {
+			mock.ExpectExec("DELETE FROM refresh_tokens WHERE user_id").
+				WithArgs(int64(1)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		if c.code == http.StatusOK {
+			mock.ExpectExec("DELETE FROM refresh_tokens").
+				WithArgs(models.HashToken("refresh123")).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec("INSERT INTO refresh_tokens").
+				WithArgs(int64(1), sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/refresh", nil)
+		req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "refresh123"})
+		w := httptest.NewRecorder()
+		h.Refresh(w, req)
+
+		assert.Equal(t, c.code, w.Code, "%v", c.err)
+		assert.NoError(t, mock.ExpectationsWereMet(), "%v", c.err)
+		cleanup()
+	}
+}
+
+func TestChangePassword_ExternalUser(t *testing.T) {
+	h, mock, cleanup := setupAuthHandler(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT id, username").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password_hash", "role", "must_change_password", "status", "created_at", "locale"}).
+			AddRow(1, "alice", models.ExternalPasswordHash, 0, false, "active", time.Now(), ""))
+
+	body := `{"current_password":"x","new_password":"N3w!Passw0rd#xyz"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/password", bytes.NewBufferString(body))
+	req = withClaims(req, &utils.Claims{UserID: 1, Username: "alice"})
+	w := httptest.NewRecorder()
+
+	h.ChangePassword(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+// ========== TEST: cookie-сессии ==========
+
+func responseCookies(w *httptest.ResponseRecorder) map[string]*http.Cookie {
+	cookies := map[string]*http.Cookie{}
+	for _, c := range w.Result().Cookies() {
+		cookies[c.Name] = c
+	}
+	return cookies
+}
+
+func TestLogin_CookieMode(t *testing.T) {
+	h, mock, cleanup := setupAuthHandler(t)
+	defer cleanup()
+	h.Session = config.SessionConfig{CookieMode: true, Secure: true, Domain: "router.local", SameSite: http.SameSiteStrictMode}
+
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	mock.ExpectQuery("SELECT id, username").
+		WithArgs("tester").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password_hash", "role", "must_change_password", "status", "created_at", "locale"}).
+			AddRow(1, "tester", string(hashed), 0, false, "active", time.Now(), ""))
+	mock.ExpectExec("DELETE FROM refresh_tokens").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO refresh_tokens").WillReturnResult(sqlmock.NewResult(1, 1))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/login", bytes.NewBufferString(`{"username":"tester","password":"password123"}`))
+	w := httptest.NewRecorder()
+	h.Login(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "access_token", "в режиме cookie токен не отдаётся скриптам")
+
+	cookies := responseCookies(w)
+	for _, name := range []string{middleware.AccessCookieName, middleware.RefreshCookieName, middleware.CSRFCookieName} {
+		c := cookies[name]
+		if assert.NotNil(t, c, name) {
+			assert.NotEmpty(t, c.Value)
+			assert.True(t, c.Secure)
+			assert.Equal(t, "router.local", c.Domain)
+		}
+	}
+	assert.True(t, cookies[middleware.AccessCookieName].HttpOnly)
+	assert.False(t, cookies[middleware.CSRFCookieName].HttpOnly, "CSRF-токен читается интерфейсом")
+
+	claims, err := utils.ValidateToken(cookies[middleware.AccessCookieName].Value)
+	assert.NoError(t, err)
+	assert.Equal(t, "tester", claims.Username)
+}
+
+func TestLogout_CookieModeClearsCookies(t *testing.T) {
+	h, _, cleanup := setupAuthHandler(t)
+	defer cleanup()
+	h.Session = config.SessionConfig{CookieMode: true}
+	middleware.EnableCookieSessions(h.Session)
+	defer middleware.EnableCookieSessions(config.SessionConfig{})
+
+	access, err := utils.GenerateAccessToken(&models.User{ID: 9, Username: "leaving"})
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/logout", nil)
+	req.AddCookie(&http.Cookie{Name: middleware.AccessCookieName, Value: access})
+	w := httptest.NewRecorder()
+	h.Logout(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	_, err = utils.ValidateToken(access)
+	assert.ErrorIs(t, err, utils.ErrTokenRevoked, "access-токен из cookie отозван")
+
+	cookies := responseCookies(w)
+	for _, name := range []string{middleware.AccessCookieName, middleware.RefreshCookieName, middleware.CSRFCookieName} {
+		if assert.NotNil(t, cookies[name], name) {
+			assert.Less(t, cookies[name].MaxAge, 0)
+		}
+	}
+}
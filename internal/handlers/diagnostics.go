@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"archive/zip"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/audit"
+	"rim-router-service-ver-cgo/internal/config"
+	database "rim-router-service-ver-cgo/internal/db"
+	"rim-router-service-ver-cgo/internal/middleware"
+	"rim-router-service-ver-cgo/internal/models"
+	"rim-router-service-ver-cgo/internal/sysinfo"
+	"rim-router-service-ver-cgo/internal/utils"
+	"rim-router-service-ver-cgo/internal/version"
+)
+
+// diagnosticsAuditEvents — сколько последних событий аудита попадает в архив
+const diagnosticsAuditEvents = 500
+
+// DiagnosticsHandler собирает архив для обращения в поддержку
+type DiagnosticsHandler struct {
+	DB     *sql.DB
+	Users  *models.UserRepository
+	Audit  *models.AuditRepository // nil — без журнала аудита
+	System *sysinfo.Collector
+}
+
+func NewDiagnosticsHandler(db *sql.DB, users *models.UserRepository, auditRepo *models.AuditRepository, system *sysinfo.Collector) *DiagnosticsHandler {
+	return &DiagnosticsHandler{DB: db, Users: users, Audit: auditRepo, System: system}
+}
+
+// DiagnosticsManifest — manifest.json в архиве
+type DiagnosticsManifest struct {
+	GeneratedAt   time.Time              `json:"generated_at"`
+	Version       version.Info           `json:"version"`
+	System        sysinfo.Info           `json:"system"`
+	SchemaVersion int                    `json:"schema_version"`
+	LatestSchema  int                    `json:"latest_schema_version"`
+	Users         []models.UserCount     `json:"users"`
+	Tir           map[string]bool        `json:"tir"`
+	Config        map[string]interface{} `json:"config"`
+	Logs          []string               `json:"logs"`
+	Errors        []string               `json:"errors,omitempty"`
+}
+
+// GET /api/v2/diagnostics/bundle — один zip: manifest.json (версия, система,
+// схема БД, число пользователей, ТИР, конфигурация без секретов), audit.json
+// с последними событиями и все логи по корням (logs/local/api.log).
+func (h *DiagnosticsHandler) Bundle(w http.ResponseWriter, r *http.Request) {
+	logger := middleware.Log(r, "system")
+
+	files, err := utils.DiscoverLogFiles(true)
+	if err != nil {
+		(&logger).Warn().Err(err).Msg("Log discovery failed")
+	}
+
+	manifest := h.manifest(files)
+	if err != nil {
+		manifest.Errors = append(manifest.Errors, "logs: "+err.Error())
+	}
+
+	var events []models.AuditEvent
+	if h.Audit != nil {
+		events, _, err = h.Audit.List(models.AuditFilter{Limit: diagnosticsAuditEvents})
+		if err != nil {
+			manifest.Errors = append(manifest.Errors, "audit: "+err.Error())
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition",
+		`attachment; filename="diagnostics_`+time.Now().UTC().Format("20060102T150405")+`.zip"`)
+	audit.Record(r, audit.Entry{Action: audit.ActionDiagnosticsBundle, Target: "diagnostics",
+		After: map[string]int{"logs": len(files)}})
+
+	pr, pw := io.Pipe()
+	go func() {
+		zw := zip.NewWriter(pw)
+		err := writeZipJSON(zw, "manifest.json", manifest)
+		if err == nil && events != nil {
+			err = writeZipJSON(zw, "audit.json", events)
+		}
+		for _, f := range files {
+			if err != nil {
+				break
+			}
+			if addErr := addFileToZipWithRoot(zw, f.Path, "logs/"+f.RootID); addErr != nil {
+				(&logger).Warn().Err(addErr).Str("file", f.Path).Msg("zip add failed")
+			}
+		}
+		if cerr := zw.Close(); err == nil {
+			err = cerr
+		}
+		pw.CloseWithError(err)
+	}()
+
+	if _, err := io.Copy(w, pr); err != nil {
+		(&logger).Warn().Err(err).Msg("Diagnostics bundle transfer interrupted")
+		pr.CloseWithError(err)
+	}
+}
+
+func (h *DiagnosticsHandler) manifest(files []utils.LogInfo) DiagnosticsManifest {
+	m := DiagnosticsManifest{
+		GeneratedAt:  time.Now().UTC(),
+		Version:      version.Get(),
+		LatestSchema: database.LatestSchemaVersion(),
+		Users:        []models.UserCount{},
+		Tir:          map[string]bool{"running": TirRunning()},
+		Config:       config.Effective(),
+		Logs:         []string{},
+	}
+
+	if h.System != nil {
+		m.System = h.System.Collect()
+	}
+	if v, err := database.SchemaVersion(h.DB); err != nil {
+		m.Errors = append(m.Errors, "schema version: "+err.Error())
+	} else {
+		m.SchemaVersion = v
+	}
+	if counts, err := h.Users.CountUsers(); err != nil {
+		m.Errors = append(m.Errors, "users: "+err.Error())
+	} else {
+		m.Users = counts
+	}
+	for _, f := range files {
+		m.Logs = append(m.Logs, f.RootID+"/"+f.Name)
+	}
+	return m
+}
+
+func writeZipJSON(zw *zip.Writer, name string, v interface{}) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/models"
+	"rim-router-service-ver-cgo/internal/sysinfo"
+	"rim-router-service-ver-cgo/internal/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagnosticsBundle(t *testing.T) {
+	t.Setenv("JWT_SECRET", "super-secret-jwt-value")
+	t.Setenv("LDAP_BIND_PASSWORD", "directory-password")
+
+	dir := t.TempDir()
+	logPath := makeTempLogFile(t, dir, "api.log", "line one\nline two\n")
+
+	oldDiscover := utils.DiscoverLogFilesFunc
+	defer func() { utils.DiscoverLogFilesFunc = oldDiscover }()
+	utils.DiscoverLogFilesFunc = func(bool) ([]utils.LogInfo, error) {
+		return []utils.LogInfo{{Name: "api.log", Path: logPath, Dir: dir, RootID: "sd"}}, nil
+	}
+
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	mock.ExpectQuery("PRAGMA user_version").WillReturnRows(sqlmock.NewRows([]string{"user_version"}).AddRow(10))
+	mock.ExpectQuery("SELECT role, status, COUNT").
+		WillReturnRows(sqlmock.NewRows([]string{"role", "status", "count"}).AddRow(0, "active", 3).AddRow(1, "active", 1))
+	mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT id, ts").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "ts", "actor", "actor_id", "action", "target", "request_id", "ip", "before", "after"}).
+			AddRow(1, time.Now(), "admin", 1, "auth.login", "admin", "req-1", "10.0.0.1", nil, nil))
+
+	h := NewDiagnosticsHandler(db, models.NewUserRepository(db), models.NewAuditRepository(db), &sysinfo.Collector{
+		ProcRoot:   t.TempDir(),
+		Roots:      func() []utils.Root { return nil },
+		LogDir:     func() string { return dir },
+		Interfaces: func() ([]net.Interface, error) { return nil, nil },
+	})
+
+	w := httptest.NewRecorder()
+	h.Bundle(w, httptest.NewRequest(http.MethodGet, "/api/v2/diagnostics/bundle", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	assert.ElementsMatch(t, []string{"manifest.json", "audit.json", "logs/sd/api.log"}, readZipEntries(t, w.Body.Bytes()))
+
+	manifest := readZipFile(t, w.Body.Bytes(), "manifest.json")
+	assert.NotContains(t, manifest, "super-secret-jwt-value")
+	assert.NotContains(t, manifest, "directory-password")
+
+	var m DiagnosticsManifest
+	assert.NoError(t, json.Unmarshal([]byte(manifest), &m))
+	assert.Equal(t, 10, m.SchemaVersion)
+	assert.Equal(t, []models.UserCount{{Role: 0, Status: "active", Count: 3}, {Role: 1, Status: "active", Count: 1}}, m.Users)
+	assert.Equal(t, []string{"sd/api.log"}, m.Logs)
+	assert.Contains(t, m.Tir, "running")
+	assert.Equal(t, "[redacted]", m.Config["jwt"].(map[string]interface{})["Secret"])
+	assert.Equal(t, "15m0s", m.Config["jwt"].(map[string]interface{})["AccessExpiration"])
+
+	assert.Contains(t, readZipFile(t, w.Body.Bytes(), "audit.json"), "auth.login")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func readZipFile(t *testing.T, data []byte, name string) string {
+	t.Helper()
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	for _, f := range r.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		assert.NoError(t, err)
+		defer rc.Close()
+		b, err := io.ReadAll(rc)
+		assert.NoError(t, err)
+		return string(b)
+	}
+	t.Fatalf("%s not found in zip", name)
+	return ""
+}
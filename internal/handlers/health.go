@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	database "rim-router-service-ver-cgo/internal/db"
+	"rim-router-service-ver-cgo/internal/middleware"
+	"rim-router-service-ver-cgo/internal/utils"
+	"rim-router-service-ver-cgo/internal/version"
+)
+
+// ReadinessCheck — одна проверка готовности. Check возвращает краткое
+// описание состояния и ошибку, если зависимость неисправна.
+type ReadinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) (string, error)
+}
+
+// CheckResult — результат проверки в ответе /health/ready
+type CheckResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"` // ok | fail
+	LatencyMs float64 `json:"latency_ms"`
+	Detail    string  `json:"detail,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// HealthReport — ответ /health/ready
+type HealthReport struct {
+	Status  string        `json:"status"` // ok | degraded
+	Version string        `json:"version"`
+	Checks  []CheckResult `json:"checks"`
+	TS      int64         `json:"ts"`
+}
+
+// ReadinessHandler выполняет проверки готовности для watchdog
+type ReadinessHandler struct {
+	Checks  []ReadinessCheck
+	Timeout time.Duration // на каждую проверку
+}
+
+func NewReadinessHandler(checks ...ReadinessCheck) *ReadinessHandler {
+	return &ReadinessHandler{Checks: checks, Timeout: 2 * time.Second}
+}
+
+// GET /health/live — процесс жив и обслуживает запросы; зависимости не проверяются
+func LivenessHandler(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, map[string]interface{}{
+		"status":  "ok",
+		"version": version.Get().Version,
+		"ts":      time.Now().UnixMilli(),
+	})
+}
+
+// GET /health/ready — все проверки; 503, если хотя бы одна не прошла
+func (h *ReadinessHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	report := h.Run(r.Context())
+
+	code := http.StatusOK
+	if report.Status != "ok" {
+		code = http.StatusServiceUnavailable
+		logger := middleware.Log(r, "system")
+		for _, c := range report.Checks {
+			if c.Status != "ok" {
+				(&logger).Warn().Str("check", c.Name).Str("error", c.Error).Msg("Readiness check failed")
+			}
+		}
+	}
+	writeHealth(w, code, report)
+}
+
+// Run выполняет проверки по очереди, каждую со своим таймаутом
+func (h *ReadinessHandler) Run(ctx context.Context) HealthReport {
+	report := HealthReport{Status: "ok", Version: version.Get().Version, Checks: make([]CheckResult, 0, len(h.Checks))}
+	for _, c := range h.Checks {
+		cctx, cancel := context.WithTimeout(ctx, h.Timeout)
+		start := time.Now()
+		detail, err := c.Check(cctx)
+		cancel()
+
+		res := CheckResult{
+			Name:      c.Name,
+			Status:    "ok",
+			LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			Detail:    detail,
+		}
+		if err != nil {
+			res.Status = "fail"
+			res.Error = err.Error()
+			report.Status = "degraded"
+		}
+		report.Checks = append(report.Checks, res)
+	}
+	report.TS = time.Now().UnixMilli()
+	return report
+}
+
+func writeHealth(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// =============================
+//   Проверки
+// =============================
+
+// DatabaseCheck — SQLite отвечает на запрос
+func DatabaseCheck(db *sql.DB) ReadinessCheck {
+	return ReadinessCheck{Name: "database", Check: func(ctx context.Context) (string, error) {
+		var one int
+		if err := db.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+			return "", err
+		}
+		return "", nil
+	}}
+}
+
+// MigrationsCheck — схема БД соответствует последней миграции
+func MigrationsCheck(db *sql.DB) ReadinessCheck {
+	return ReadinessCheck{Name: "migrations", Check: func(ctx context.Context) (string, error) {
+		var version int
+		if err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+			return "", fmt.Errorf("read schema version: %w", err)
+		}
+		latest := database.LatestSchemaVersion()
+		detail := fmt.Sprintf("schema version %d", version)
+		if version != latest {
+			return detail, fmt.Errorf("schema version %d, expected %d", version, latest)
+		}
+		return detail, nil
+	}}
+}
+
+// LogDirCheck — каталог логов доступен на запись и места хватает. statfs и
+// запись на зависшую SD-карту могут не вернуться, поэтому проверка ждёт их
+// не дольше таймаута из ctx.
+func LogDirCheck() ReadinessCheck {
+	return ReadinessCheck{Name: "log_dir", Check: func(ctx context.Context) (string, error) {
+		type result struct {
+			freeMB float64
+			err    error
+		}
+		done := make(chan result, 1)
+		go func() {
+			freeMB, err := utils.CheckLogDir()
+			done <- result{freeMB, err}
+		}()
+
+		select {
+		case res := <-done:
+			return fmt.Sprintf("%.1f MB free", res.freeMB), res.err
+		case <-ctx.Done():
+			return "", fmt.Errorf("log directory check timed out: %w", ctx.Err())
+		}
+	}}
+}
+
+// TirCheck — ТИР в том состоянии, которое задано через start/stop
+func TirCheck() ReadinessCheck {
+	return ReadinessCheck{Name: "tir", Check: func(ctx context.Context) (string, error) {
+		state := map[bool]string{true: "running", false: "stopped"}
+		desired, actual := TirDesired(), TirRunning()
+		if actual != desired {
+			return state[actual], fmt.Errorf("TIR is %s, expected %s", state[actual], state[desired])
+		}
+		return state[actual], nil
+	}}
+}
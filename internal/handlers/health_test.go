@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func okCheck(name string) ReadinessCheck {
+	return ReadinessCheck{Name: name, Check: func(ctx context.Context) (string, error) { return "fine", nil }}
+}
+
+func TestReady_AllChecksPass(t *testing.T) {
+	h := NewReadinessHandler(okCheck("a"), okCheck("b"))
+
+	w := httptest.NewRecorder()
+	h.Ready(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var report HealthReport
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, "ok", report.Status)
+	assert.Len(t, report.Checks, 2)
+	assert.Equal(t, "fine", report.Checks[0].Detail)
+}
+
+func TestReady_DegradedReturns503(t *testing.T) {
+	failing := ReadinessCheck{Name: "database", Check: func(ctx context.Context) (string, error) {
+		return "", errors.New("database is locked")
+	}}
+	h := NewReadinessHandler(okCheck("a"), failing)
+
+	w := httptest.NewRecorder()
+	h.Ready(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	var report HealthReport
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, "degraded", report.Status)
+	assert.Equal(t, "fail", report.Checks[1].Status)
+	assert.Equal(t, "database is locked", report.Checks[1].Error)
+}
+
+func TestMigrationsCheck_Outdated(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+	mock.ExpectQuery("PRAGMA user_version").WillReturnRows(sqlmock.NewRows([]string{"user_version"}).AddRow(1))
+
+	_, err := MigrationsCheck(db).Check(context.Background())
+	assert.Error(t, err)
+}
+
+func TestLogDirCheck(t *testing.T) {
+	old := utils.LogDirFunc
+	defer func() { utils.LogDirFunc = old }()
+	utils.LogDirFunc = func() string { return t.TempDir() }
+
+	detail, err := LogDirCheck().Check(context.Background())
+	assert.NoError(t, err)
+	assert.Contains(t, detail, "MB free")
+}
+
+func TestLogDirCheck_Timeout(t *testing.T) {
+	old := utils.LogDirFunc
+	release := make(chan struct{})
+	defer func() {
+		close(release)
+		utils.LogDirFunc = old
+	}()
+	// каталог на зависшей карте: обращение не возвращается
+	utils.LogDirFunc = func() string {
+		<-release
+		return t.TempDir()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := LogDirCheck().Check(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestTirCheck(t *testing.T) {
+	defer func() {
+		tirStatusMu.Lock()
+		tirDesired, tirStatus = false, false
+		tirStatusMu.Unlock()
+	}()
+
+	_, err := TirCheck().Check(context.Background())
+	assert.NoError(t, err)
+
+	tirStatusMu.Lock()
+	tirDesired, tirStatus = true, true
+	tirStatusMu.Unlock()
+	// процесс завершился сам, хотя должен работать
+	SetTirRunning(false)
+
+	detail, err := TirCheck().Check(context.Background())
+	assert.Equal(t, "stopped", detail)
+	assert.EqualError(t, err, "TIR is stopped, expected running")
+}
+
+func TestLiveness(t *testing.T) {
+	w := httptest.NewRecorder()
+	LivenessHandler(w, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"rim-router-service-ver-cgo/internal/apierr"
+	"rim-router-service-ver-cgo/internal/config"
+	"rim-router-service-ver-cgo/internal/middleware"
+	"rim-router-service-ver-cgo/internal/utils"
+)
+
+// GET /.well-known/jwks.json — публичные ключи для проверки access-токенов
+// другими сервисами устройства без общего секрета.
+func JWKS(w http.ResponseWriter, r *http.Request) {
+	keys, err := utils.PublicJWKs()
+	if err != nil {
+		apierr.Write(w, r, apierr.New(http.StatusInternalServerError, apierr.CodeSigningUnavailable, "Signing keys unavailable"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+}
+
+// IntrospectionResponse — ответ в духе RFC 7662. Для недействительного
+// токена заполняется только Active=false.
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Sub       string `json:"sub,omitempty"`
+	Username  string `json:"username,omitempty"`
+	Role      *int   `json:"role,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+}
+
+// POST /api/v1/introspect — проверка токена по запросу локального сервиса.
+// Клиент аутентифицируется HTTP Basic (client_id:secret из INTROSPECTION_CLIENTS),
+// токен передаётся формой token=<jwt>.
+func Introspect(w http.ResponseWriter, r *http.Request) {
+	cfg := config.GetIntrospectionConfig()
+	if len(cfg.Clients) == 0 {
+		apierr.Write(w, r, apierr.New(http.StatusNotImplemented, apierr.CodeFeatureDisabled, "Introspection is disabled"))
+		return
+	}
+
+	clientID, secret, ok := r.BasicAuth()
+	expected, known := cfg.Clients[clientID]
+	if !ok || !known || subtle.ConstantTimeCompare([]byte(secret), []byte(expected)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Basic realm="introspect"`)
+		apierr.Write(w, r, apierr.New(http.StatusUnauthorized, apierr.CodeInvalidClient, "Invalid client credentials"))
+		return
+	}
+
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("token") == "" {
+		apierr.Write(w, r, apierr.Validation("token is required", apierr.Field("token", "required", "")))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	resp := IntrospectionResponse{}
+	if claims, err := utils.ValidateTokenFunc(r.PostForm.Get("token")); err == nil {
+		role := claims.Role
+		resp = IntrospectionResponse{
+			Active:    true,
+			Sub:       strconv.FormatInt(claims.UserID, 10),
+			Username:  claims.Username,
+			Role:      &role,
+			TokenType: "Bearer",
+			Exp:       claims.ExpiresAt,
+			Iat:       claims.IssuedAt,
+		}
+	}
+
+	logger := middleware.Log(r, "auth").With().Str("client", clientID).Logger()
+	(&logger).Debug().Bool("active", resp.Active).Msg("Token introspected")
+
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"rim-router-service-ver-cgo/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func introspectRequest(token, clientID, secret string) *http.Request {
+	form := url.Values{"token": {token}}
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/introspect", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if clientID != "" {
+		req.SetBasicAuth(clientID, secret)
+	}
+	return req
+}
+
+func TestJWKS_PublishesActiveKey(t *testing.T) {
+	w := httptest.NewRecorder()
+	JWKS(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var body struct {
+		Keys []utils.JWK `json:"keys"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	if assert.NotEmpty(t, body.Keys) {
+		assert.Equal(t, "sig", body.Keys[0].Use)
+		assert.NotEmpty(t, body.Keys[0].Kid)
+	}
+}
+
+func TestIntrospect_ActiveToken(t *testing.T) {
+	t.Setenv("INTROSPECTION_CLIENTS", "tir-ui:s3cret")
+
+	old := utils.ValidateTokenFunc
+	defer func() { utils.ValidateTokenFunc = old }()
+	utils.ValidateTokenFunc = func(token string) (*utils.Claims, error) {
+		c := &utils.Claims{UserID: 7, Username: "operator", Role: 0}
+		c.ExpiresAt = 1700000000
+		return c, nil
+	}
+
+	w := httptest.NewRecorder()
+	Introspect(w, introspectRequest("some.jwt.token", "tir-ui", "s3cret"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+	var resp IntrospectionResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.True(t, resp.Active)
+	assert.Equal(t, "7", resp.Sub)
+	assert.Equal(t, "operator", resp.Username)
+	if assert.NotNil(t, resp.Role) {
+		assert.Equal(t, 0, *resp.Role)
+	}
+	assert.Equal(t, int64(1700000000), resp.Exp)
+}
+
+func TestIntrospect_InactiveToken(t *testing.T) {
+	t.Setenv("INTROSPECTION_CLIENTS", "tir-ui:s3cret")
+
+	old := utils.ValidateTokenFunc
+	defer func() { utils.ValidateTokenFunc = old }()
+	utils.ValidateTokenFunc = func(token string) (*utils.Claims, error) {
+		return nil, errors.New("expired")
+	}
+
+	w := httptest.NewRecorder()
+	Introspect(w, introspectRequest("expired.jwt", "tir-ui", "s3cret"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"active":false}`, w.Body.String())
+}
+
+func TestIntrospect_BadClient(t *testing.T) {
+	t.Setenv("INTROSPECTION_CLIENTS", "tir-ui:s3cret")
+
+	w := httptest.NewRecorder()
+	Introspect(w, introspectRequest("some.jwt", "tir-ui", "wrong"))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	Introspect(w, introspectRequest("some.jwt", "", ""))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestIntrospect_Disabled(t *testing.T) {
+	t.Setenv("INTROSPECTION_CLIENTS", "")
+
+	w := httptest.NewRecorder()
+	Introspect(w, introspectRequest("some.jwt", "tir-ui", "s3cret"))
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"rim-router-service-ver-cgo/internal/apierr"
+	"rim-router-service-ver-cgo/internal/i18n"
+	"rim-router-service-ver-cgo/internal/middleware"
+)
+
+// LocaleSettings — язык сообщений пользователя
+type LocaleSettings struct {
+	Locale    string      `json:"locale"`    // сохранённая настройка; пусто — по Accept-Language
+	Effective i18n.Lang   `json:"effective"` // язык текущего ответа
+	Supported []i18n.Lang `json:"supported"`
+}
+
+// GET /api/v1/locale — настройка языка текущего пользователя
+func (h *AuthHandler) GetLocale(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		apierr.Write(w, r, apierr.ErrAuthRequired)
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByID(claims.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		apierr.Write(w, r, apierr.New(http.StatusNotFound, apierr.CodeUserNotFound, "User not found"))
+		return
+	}
+	if err != nil {
+		logger := middleware.Log(r, "auth")
+		(&logger).Error().Err(err).Msg("Failed to load user")
+		apierr.Write(w, r, apierr.ErrDatabase)
+		return
+	}
+
+	// В токене может быть прежняя настройка — берём сохранённую
+	if lang, ok := i18n.Parse(user.Locale); ok {
+		r = r.WithContext(i18n.WithLang(r.Context(), lang))
+	}
+	sendJSON(w, r, http.StatusOK, i18n.MsgOK, LocaleSettings{
+		Locale:    user.Locale,
+		Effective: i18n.FromContext(r.Context()),
+		Supported: i18n.Supported,
+	})
+}
+
+// PUT /api/v1/locale — сохранить язык сообщений: {"locale": "ru"}; пустая
+// строка — снова выбирать по Accept-Language. Текущий ответ уже на новом языке.
+// Остальные запросы берут язык из claim loc токена: при AUTH_REVALIDATE_USER=true
+// новый язык действует сразу, иначе — после следующего refresh.
+func (h *AuthHandler) SetLocale(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		apierr.Write(w, r, apierr.ErrAuthRequired)
+		return
+	}
+
+	var body struct {
+		Locale string `json:"locale"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		apierr.Write(w, r, apierr.ErrInvalidJSON)
+		return
+	}
+
+	lang, ok := i18n.Parse(body.Locale)
+	if body.Locale != "" && !ok {
+		apierr.Write(w, r, apierr.Validation("Unsupported locale", apierr.Field("locale", "unsupported", "")).
+			WithData(i18n.Supported))
+		return
+	}
+
+	logger := middleware.Log(r, "auth")
+	if err := h.UserRepo.UpdateLocale(claims.UserID, string(lang)); err != nil {
+		(&logger).Error().Err(err).Msg("Failed to update locale")
+		apierr.Write(w, r, apierr.ErrDatabase)
+		return
+	}
+	// кэш перепроверки подставляет язык в claims — иначе старый язык
+	// держался бы до истечения записи
+	middleware.InvalidateUser(claims.UserID)
+	(&logger).Info().Str("locale", string(lang)).Msg("Locale updated")
+
+	ctx := r.Context()
+	if ok {
+		ctx = i18n.WithLang(ctx, lang)
+	}
+	r = r.WithContext(ctx)
+	sendJSON(w, r, http.StatusOK, i18n.MsgLocaleUpdated, LocaleSettings{
+		Locale:    string(lang),
+		Effective: i18n.FromContext(ctx),
+		Supported: i18n.Supported,
+	})
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/i18n"
+	"rim-router-service-ver-cgo/internal/middleware"
+	"rim-router-service-ver-cgo/internal/models"
+	"rim-router-service-ver-cgo/internal/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetLocale_Success(t *testing.T) {
+	h, mock, cleanup := setupAuthHandler(t)
+	defer cleanup()
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET locale = ? WHERE id = ?")).
+		WithArgs("ru", int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/locale", bytes.NewBufferString(`{"locale":"ru-RU"}`))
+	req = req.WithContext(i18n.WithLang(req.Context(), i18n.EN))
+	req = withClaims(req, &utils.Claims{UserID: 1, Username: "admin", Role: 1})
+	w := httptest.NewRecorder()
+
+	h.SetLocale(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ru", w.Header().Get("Content-Language"))
+	var resp Response
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "Язык изменён", resp.Message)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetLocale_InvalidatesRevalidationCache(t *testing.T) {
+	h, mock, cleanup := setupAuthHandler(t)
+	defer cleanup()
+
+	locale := "en"
+	middleware.EnableUserRevalidation(func(id int64) (*models.User, error) {
+		return &models.User{ID: id, Username: "admin", Role: 1, Status: models.UserStatusActive, Locale: locale}, nil
+	}, time.Minute)
+	defer middleware.EnableUserRevalidation(nil, 0)
+
+	oldValidate := utils.ValidateTokenFunc
+	defer func() { utils.ValidateTokenFunc = oldValidate }()
+	utils.ValidateTokenFunc = func(token string) (*utils.Claims, error) {
+		return &utils.Claims{UserID: 1, Username: "admin", Role: 1, Locale: "en"}, nil
+	}
+	localeOf := func() string {
+		var got string
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/me", nil)
+		req.Header.Set("Authorization", "Bearer token")
+		middleware.AuthMiddleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			got = middleware.GetUserFromContext(r.Context()).Locale
+		})).ServeHTTP(httptest.NewRecorder(), req)
+		return got
+	}
+	assert.Equal(t, "en", localeOf())
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET locale = ? WHERE id = ?")).
+		WithArgs("ru", int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/locale", bytes.NewBufferString(`{"locale":"ru"}`))
+	req = withClaims(req, &utils.Claims{UserID: 1, Username: "admin", Role: 1})
+	w := httptest.NewRecorder()
+	h.SetLocale(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	locale = "ru"
+
+	// следующий запрос берёт новый язык, а не запись из кэша
+	assert.Equal(t, "ru", localeOf())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetLocale_Unsupported(t *testing.T) {
+	h, mock, cleanup := setupAuthHandler(t)
+	defer cleanup()
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/locale", bytes.NewBufferString(`{"locale":"de"}`))
+	req = withClaims(req, &utils.Claims{UserID: 1, Username: "admin", Role: 1})
+	w := httptest.NewRecorder()
+
+	h.SetLocale(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"field":"locale"`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/config"
+	"rim-router-service-ver-cgo/internal/loglevel"
+	"rim-router-service-ver-cgo/internal/models"
+)
+
+// LoginGuard ограничивает подбор паролей: считает неудачные входы по логину
+// и по IP и блокирует их с экспоненциально растущим временем.
+type LoginGuard struct {
+	Repo   *models.LoginAttemptRepository
+	Config config.LockoutConfig
+	now    func() time.Time
+
+	pruneMu   sync.Mutex
+	lastPrune time.Time
+}
+
+// attemptPruneInterval — как часто Fail удаляет устаревшие счётчики
+const attemptPruneInterval = 10 * time.Minute
+
+func NewLoginGuard(repo *models.LoginAttemptRepository, cfg config.LockoutConfig) *LoginGuard {
+	return &LoginGuard{Repo: repo, Config: cfg, now: time.Now}
+}
+
+func userAttemptKey(username string) string { return "user:" + strings.ToLower(username) }
+func ipAttemptKey(ip string) string         { return "ip:" + ip }
+
+// Check возвращает оставшееся время блокировки (0 — вход разрешён).
+func (g *LoginGuard) Check(username, ip string) (time.Duration, error) {
+	var wait time.Duration
+	for _, key := range []string{userAttemptKey(username), ipAttemptKey(ip)} {
+		a, err := g.Repo.Get(key)
+		if err != nil {
+			return 0, err
+		}
+		if a == nil || a.LockedUntil == nil {
+			continue
+		}
+		if left := a.LockedUntil.Sub(g.now()); left > wait {
+			wait = left
+		}
+	}
+	return wait, nil
+}
+
+// Fail учитывает неудачную попытку и возвращает время блокировки, если она наступила.
+func (g *LoginGuard) Fail(username, ip string) (time.Duration, error) {
+	g.pruneIfDue()
+
+	userLock, err := g.registerFailure(userAttemptKey(username), g.Config.MaxUserFailures)
+	if err != nil {
+		return 0, err
+	}
+	ipLock, err := g.registerFailure(ipAttemptKey(ip), g.Config.MaxIPFailures)
+	if err != nil {
+		return 0, err
+	}
+
+	logger := loglevel.Logger("auth").With().Str("user", username).Str("ip", ip).Logger()
+	if userLock > 0 {
+		(&logger).Warn().Dur("locked_for", userLock).Msg("Account locked after repeated login failures")
+	}
+	if ipLock > 0 {
+		(&logger).Warn().Dur("locked_for", ipLock).Msg("Client IP locked after repeated login failures")
+	}
+
+	if ipLock > userLock {
+		return ipLock, nil
+	}
+	return userLock, nil
+}
+
+// Succeed сбрасывает счётчик логина после успешного входа. Счётчик IP не
+// сбрасывается, чтобы свой аккаунт не открывал перебор чужих.
+func (g *LoginGuard) Succeed(username string) error {
+	return g.Repo.Reset(userAttemptKey(username))
+}
+
+// Unlock снимает блокировку с логина.
+func (g *LoginGuard) Unlock(username string) error {
+	return g.Repo.Reset(userAttemptKey(username))
+}
+
+// pruneIfDue удаляет счётчики, которые уже ни на что не влияют: окно ошибок
+// прошло и блокировка истекла. Иначе перебор случайных логинов и адресов
+// растил бы login_attempts без ограничений.
+func (g *LoginGuard) pruneIfDue() {
+	now := g.now()
+	g.pruneMu.Lock()
+	if now.Sub(g.lastPrune) < attemptPruneInterval {
+		g.pruneMu.Unlock()
+		return
+	}
+	g.lastPrune = now
+	g.pruneMu.Unlock()
+
+	n, err := g.Repo.DeleteStale(now.Add(-g.Config.FailureWindow), now)
+	logger := loglevel.Logger("auth")
+	if err != nil {
+		(&logger).Warn().Err(err).Msg("Failed to prune login attempts")
+		return
+	}
+	if n > 0 {
+		(&logger).Debug().Int64("deleted", n).Msg("Pruned stale login attempts")
+	}
+}
+
+func (g *LoginGuard) registerFailure(key string, threshold int) (time.Duration, error) {
+	now := g.now()
+	failures, err := g.Repo.RegisterFailure(key, now, now.Add(-g.Config.FailureWindow))
+	if err != nil {
+		return 0, err
+	}
+	if threshold <= 0 || failures < threshold {
+		return 0, nil
+	}
+	lock := lockoutDuration(failures-threshold, g.Config.BaseLockout, g.Config.MaxLockout)
+	return lock, g.Repo.Lock(key, now.Add(lock))
+}
+
+// lockoutDuration: base, 2*base, 4*base ... но не больше max.
+func lockoutDuration(step int, base, max time.Duration) time.Duration {
+	d := base
+	for i := 0; i < step && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
+
+// setRetryAfter выставляет заголовок Retry-After в секундах (с округлением вверх).
+func setRetryAfter(w http.ResponseWriter, d time.Duration) {
+	secs := int((d + time.Second - 1) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(secs))
+}
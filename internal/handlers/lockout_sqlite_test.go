@@ -0,0 +1,57 @@
+//go:build cgo
+
+package handlers
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+
+	"rim-router-service-ver-cgo/internal/db"
+	"rim-router-service-ver-cgo/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Параллельные ошибки входа не должны терять инкременты: иначе перебор
+// в несколько потоков обходит порог блокировки.
+func TestLoginGuard_ConcurrentFailures(t *testing.T) {
+	conn, err := db.OpenSQLite(filepath.Join(t.TempDir(), "data.db"))
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, db.Migrate(conn))
+
+	// на одноядерной машине горутины почти не пересекаются — даём планировщику
+	// несколько P, чтобы гонка чтения-записи проявлялась
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(8))
+
+	cfg := testLockoutConfig
+	cfg.MaxUserFailures = 0
+	cfg.MaxIPFailures = 0
+	g := NewLoginGuard(models.NewLoginAttemptRepository(conn), cfg)
+
+	const n = 50
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := g.Fail("bob", fmt.Sprintf("192.0.2.%d", i%2))
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	a, err := g.Repo.Get(userAttemptKey("bob"))
+	require.NoError(t, err)
+	require.NotNil(t, a)
+	assert.Equal(t, n, a.Failures)
+	for _, ip := range []string{"192.0.2.0", "192.0.2.1"} {
+		a, err := g.Repo.Get(ipAttemptKey(ip))
+		require.NoError(t, err)
+		assert.Equal(t, n/2, a.Failures)
+	}
+}
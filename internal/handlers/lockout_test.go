@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/config"
+	"rim-router-service-ver-cgo/internal/models"
+	"rim-router-service-ver-cgo/internal/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testLockoutConfig = config.LockoutConfig{
+	MaxUserFailures: 3,
+	MaxIPFailures:   10,
+	BaseLockout:     time.Minute,
+	MaxLockout:      time.Hour,
+	FailureWindow:   24 * time.Hour,
+}
+
+func TestLockoutDuration(t *testing.T) {
+	assert.Equal(t, time.Minute, lockoutDuration(0, time.Minute, time.Hour))
+	assert.Equal(t, 4*time.Minute, lockoutDuration(2, time.Minute, time.Hour))
+	assert.Equal(t, time.Hour, lockoutDuration(20, time.Minute, time.Hour))
+}
+
+// expectFailure ожидает учёт ошибки по ключу; locked — счётчик дошёл до порога
+// и за ним следует блокировка
+func expectFailure(mock sqlmock.Sqlmock, key string, failures int, locked bool) {
+	mock.ExpectQuery("INSERT INTO login_attempts").
+		WithArgs(key, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"failures"}).AddRow(failures))
+	if locked {
+		mock.ExpectExec("UPDATE login_attempts SET locked_until").
+			WithArgs(sqlmock.AnyArg(), key, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+}
+
+func TestLogin_LockedOut(t *testing.T) {
+	h, mock, cleanup := setupAuthHandler(t)
+	defer cleanup()
+	h.Guard = NewLoginGuard(models.NewLoginAttemptRepository(h.UserRepo.DB), testLockoutConfig)
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT key, failures").
+		WithArgs("user:john").
+		WillReturnRows(sqlmock.NewRows([]string{"key", "failures", "last_failure", "locked_until"}).
+			AddRow("user:john", 3, now, now.Add(2*time.Minute)))
+	mock.ExpectQuery("SELECT key, failures").
+		WithArgs("ip:192.0.2.1").
+		WillReturnRows(sqlmock.NewRows([]string{"key", "failures", "last_failure", "locked_until"}))
+
+	body := `{"username":"john","password":"whatever"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/login", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	h.Login(w, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLogin_FailureTriggersLockout(t *testing.T) {
+	h, mock, cleanup := setupAuthHandler(t)
+	defer cleanup()
+	h.Guard = NewLoginGuard(models.NewLoginAttemptRepository(h.UserRepo.DB), testLockoutConfig)
+
+	now := time.Now()
+	attemptCols := []string{"key", "failures", "last_failure", "locked_until"}
+
+	// Check: блокировок пока нет
+	mock.ExpectQuery("SELECT key, failures").WithArgs("user:ghost").
+		WillReturnRows(sqlmock.NewRows(attemptCols).AddRow("user:ghost", 2, now, nil))
+	mock.ExpectQuery("SELECT key, failures").WithArgs("ip:192.0.2.1").
+		WillReturnRows(sqlmock.NewRows(attemptCols))
+
+	mock.ExpectQuery("SELECT id, username").WithArgs("ghost").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password_hash", "role", "must_change_password", "status", "created_at", "locale"}))
+
+	// Fail: сначала удаляются устаревшие счётчики, затем третья ошибка по логину включает блокировку
+	mock.ExpectExec("DELETE FROM login_attempts WHERE last_failure").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	expectFailure(mock, "user:ghost", 3, true)
+	expectFailure(mock, "ip:192.0.2.1", 1, false)
+
+	body := `{"username":"ghost","password":"whatever"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/login", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	h.Login(w, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoginGuard_PrunesStaleAttempts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	g := NewLoginGuard(models.NewLoginAttemptRepository(db), testLockoutConfig)
+	g.now = func() time.Time { return now }
+
+	mock.ExpectExec("DELETE FROM login_attempts WHERE last_failure").
+		WithArgs(now.Add(-testLockoutConfig.FailureWindow), now).
+		WillReturnResult(sqlmock.NewResult(0, 42))
+	for i := 0; i < 2; i++ {
+		// второй Fail в пределах интервала чистку не повторяет
+		expectFailure(mock, "user:bob", i+1, false)
+		expectFailure(mock, "ip:192.0.2.1", i+1, false)
+
+		_, err = g.Fail("bob", "192.0.2.1")
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChangePassword_LockedOut(t *testing.T) {
+	h, mock, cleanup := setupAuthHandler(t)
+	defer cleanup()
+	h.Guard = NewLoginGuard(models.NewLoginAttemptRepository(h.UserRepo.DB), testLockoutConfig)
+
+	now := time.Now()
+	attemptCols := []string{"key", "failures", "last_failure", "locked_until"}
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("admin123"), bcrypt.MinCost)
+	mock.ExpectQuery("SELECT id, username").WithArgs(int64(1)).WillReturnRows(userRow(1, string(hashed)))
+	mock.ExpectQuery("SELECT key, failures").WithArgs("user:tester").
+		WillReturnRows(sqlmock.NewRows(attemptCols).AddRow("user:tester", 3, now, now.Add(2*time.Minute)))
+	mock.ExpectQuery("SELECT key, failures").WithArgs("ip:192.0.2.1").
+		WillReturnRows(sqlmock.NewRows(attemptCols))
+
+	// даже верный текущий пароль не проверяется, пока действует блокировка
+	body := `{"current_password":"admin123","new_password":"N3w-Secure-pass"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/password", bytes.NewBufferString(body))
+	req = withClaims(req, &utils.Claims{UserID: 1, Username: "tester", Role: 1})
+	w := httptest.NewRecorder()
+
+	h.ChangePassword(w, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChangePassword_WrongCurrentTriggersLockout(t *testing.T) {
+	h, mock, cleanup := setupAuthHandler(t)
+	defer cleanup()
+	h.Guard = NewLoginGuard(models.NewLoginAttemptRepository(h.UserRepo.DB), testLockoutConfig)
+
+	now := time.Now()
+	attemptCols := []string{"key", "failures", "last_failure", "locked_until"}
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("admin123"), bcrypt.MinCost)
+	mock.ExpectQuery("SELECT id, username").WithArgs(int64(1)).WillReturnRows(userRow(1, string(hashed)))
+	mock.ExpectQuery("SELECT key, failures").WithArgs("user:tester").
+		WillReturnRows(sqlmock.NewRows(attemptCols).AddRow("user:tester", 2, now, nil))
+	mock.ExpectQuery("SELECT key, failures").WithArgs("ip:192.0.2.1").
+		WillReturnRows(sqlmock.NewRows(attemptCols))
+
+	mock.ExpectExec("DELETE FROM login_attempts WHERE last_failure").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	expectFailure(mock, "user:tester", 3, true)
+	expectFailure(mock, "ip:192.0.2.1", 1, false)
+
+	body := `{"current_password":"nope","new_password":"N3w-Secure-pass"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/password", bytes.NewBufferString(body))
+	req = withClaims(req, &utils.Claims{UserID: 1, Username: "tester", Role: 1})
+	w := httptest.NewRecorder()
+
+	h.ChangePassword(w, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDisableMFA_WrongPasswordCountsFailure(t *testing.T) {
+	h, mock, cleanup := setupMFAHandler(t)
+	defer cleanup()
+	h.Guard = NewLoginGuard(models.NewLoginAttemptRepository(h.UserRepo.DB), testLockoutConfig)
+
+	attemptCols := []string{"key", "failures", "last_failure", "locked_until"}
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	mock.ExpectQuery("SELECT id, username").WithArgs(int64(1)).WillReturnRows(userRow(2, string(hashed)))
+	mock.ExpectQuery("SELECT key, failures").WithArgs("user:tester").WillReturnRows(sqlmock.NewRows(attemptCols))
+	mock.ExpectQuery("SELECT key, failures").WithArgs("ip:192.0.2.1").WillReturnRows(sqlmock.NewRows(attemptCols))
+	mock.ExpectQuery("SELECT id, username").WithArgs("tester").WillReturnRows(userRow(2, string(hashed)))
+
+	mock.ExpectExec("DELETE FROM login_attempts WHERE last_failure").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	expectFailure(mock, "user:tester", 1, false)
+	expectFailure(mock, "ip:192.0.2.1", 1, false)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/mfa/disable", bytes.NewBufferString(`{"password":"nope","code":"123456"}`))
+	req = withClaims(req, &utils.Claims{UserID: 1, Username: "tester", Role: 2})
+	w := httptest.NewRecorder()
+	h.DisableMFA(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
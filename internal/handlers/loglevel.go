@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/apierr"
+	"rim-router-service-ver-cgo/internal/audit"
+	"rim-router-service-ver-cgo/internal/i18n"
+	"rim-router-service-ver-cgo/internal/loglevel"
+	"rim-router-service-ver-cgo/internal/middleware"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxLogLevelTTL — предельный срок временного изменения уровня
+const maxLogLevelTTL = 24 * time.Hour
+
+// GET /api/v2/admin/loglevel — текущие уровни логирования
+func GetLogLevels(w http.ResponseWriter, r *http.Request) {
+	sendJSON(w, r, http.StatusOK, i18n.MsgOK, loglevel.Snapshot())
+}
+
+// PUT /api/v2/admin/loglevel — сменить уровень глобально или для модуля.
+// Тело: {"level": "debug", "module": "auth", "ttl": "30m"}; module и ttl
+// необязательны.
+func SetLogLevel(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Level  string `json:"level"`
+		Module string `json:"module"`
+		TTL    string `json:"ttl"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		apierr.Write(w, r, apierr.ErrInvalidJSON)
+		return
+	}
+
+	level, err := loglevel.ParseLevel(body.Level)
+	if err != nil {
+		apierr.Write(w, r, apierr.Validation("Invalid level", apierr.Field("level", "invalid", "")).
+			WithData([]string{"trace", "debug", "info", "warn", "error"}))
+		return
+	}
+	var ttl time.Duration
+	if body.TTL != "" {
+		ttl, err = time.ParseDuration(body.TTL)
+		if err != nil || ttl <= 0 || ttl > maxLogLevelTTL {
+			apierr.Write(w, r, apierr.Validation("Invalid ttl", apierr.Field("ttl", "invalid", "")))
+			return
+		}
+	}
+
+	before := loglevel.Current(body.Module)
+	if err := loglevel.Set(body.Module, level, ttl); err != nil {
+		if errors.Is(err, loglevel.ErrUnknownModule) {
+			apierr.Write(w, r, unknownModuleError(body.Module))
+			return
+		}
+		apierr.Write(w, r, apierr.ErrInternal)
+		return
+	}
+	after := loglevel.Current(body.Module)
+
+	logger := middleware.Log(r, "system")
+	logger.Warn().Str("target", logLevelTarget(body.Module)).Str("level", level.String()).
+		Dur("ttl", ttl).Msg("Log level changed")
+	audit.Record(r, audit.Entry{
+		Action: audit.ActionLogLevelChange,
+		Target: "loglevel:" + logLevelTarget(body.Module),
+		Before: before,
+		After:  after,
+	})
+	sendJSON(w, r, http.StatusOK, i18n.MsgLogLevelUpdated, loglevel.Snapshot())
+}
+
+// DELETE /api/v2/admin/loglevel/{module} — снять переопределение модуля;
+// {module} = global возвращает глобальный уровень к стартовому
+func ResetLogLevel(w http.ResponseWriter, r *http.Request) {
+	module := chi.URLParam(r, "module")
+	if module == "global" {
+		module = ""
+	}
+
+	before := loglevel.Current(module)
+	if err := loglevel.Reset(module); err != nil {
+		apierr.Write(w, r, unknownModuleError(module))
+		return
+	}
+	after := loglevel.Current(module)
+
+	logger := middleware.Log(r, "system")
+	logger.Warn().Str("target", logLevelTarget(module)).Msg("Log level reset")
+	audit.Record(r, audit.Entry{
+		Action: audit.ActionLogLevelChange,
+		Target: "loglevel:" + logLevelTarget(module),
+		Before: before,
+		After:  after,
+	})
+	sendJSON(w, r, http.StatusOK, i18n.MsgLogLevelReset, loglevel.Snapshot())
+}
+
+func unknownModuleError(module string) *apierr.Error {
+	return apierr.Validation("Unknown module: "+module, apierr.Field("module", "unknown", "")).
+		WithData(loglevel.Modules)
+}
+
+func logLevelTarget(module string) string {
+	if module == "" {
+		return "global"
+	}
+	return module
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"rim-router-service-ver-cgo/internal/audit"
+	"rim-router-service-ver-cgo/internal/loglevel"
+	"rim-router-service-ver-cgo/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+type auditSpy struct {
+	events []models.AuditEvent
+}
+
+func (s *auditSpy) Insert(e models.AuditEvent) (int64, error) {
+	s.events = append(s.events, e)
+	return int64(len(s.events)), nil
+}
+
+func TestSetLogLevel_ModuleWithTTL(t *testing.T) {
+	loglevel.Init(zerolog.InfoLevel)
+	defer loglevel.Init(zerolog.InfoLevel)
+	spy := &auditSpy{}
+	audit.SetRecorder(spy)
+	defer audit.SetRecorder(nil)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v2/admin/loglevel",
+		bytes.NewBufferString(`{"level":"debug","module":"auth","ttl":"30m"}`))
+	w := httptest.NewRecorder()
+	SetLogLevel(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, zerolog.DebugLevel, loglevel.For("auth"))
+	assert.Equal(t, zerolog.InfoLevel, loglevel.For("admin"))
+
+	var resp struct {
+		Data loglevel.State `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "debug", resp.Data.Modules["auth"].Level)
+	assert.NotNil(t, resp.Data.Modules["auth"].ExpiresAt)
+
+	if assert.Len(t, spy.events, 1) {
+		assert.Equal(t, audit.ActionLogLevelChange, spy.events[0].Action)
+		assert.Equal(t, "loglevel:auth", spy.events[0].Target)
+		assert.Contains(t, string(spy.events[0].After), `"level":"debug"`)
+	}
+}
+
+func TestSetLogLevel_Invalid(t *testing.T) {
+	loglevel.Init(zerolog.InfoLevel)
+	defer loglevel.Init(zerolog.InfoLevel)
+
+	for _, body := range []string{
+		`{"level":"loud"}`,
+		`{"level":"debug","module":"tir"}`,
+		`{"level":"debug","ttl":"-1m"}`,
+		`{"level":"debug","ttl":"48h"}`,
+	} {
+		req := httptest.NewRequest(http.MethodPut, "/api/v2/admin/loglevel", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		SetLogLevel(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+	assert.Equal(t, zerolog.InfoLevel, loglevel.Global())
+}
+
+func TestResetLogLevel_Global(t *testing.T) {
+	loglevel.Init(zerolog.InfoLevel)
+	defer loglevel.Init(zerolog.InfoLevel)
+	assert.NoError(t, loglevel.Set("", zerolog.DebugLevel, 0))
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v2/admin/loglevel/global", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("module", "global")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	w := httptest.NewRecorder()
+	ResetLogLevel(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, zerolog.InfoLevel, loglevel.Global())
+}
@@ -12,9 +12,11 @@ import (
 	"strings"
 	"time"
 
+	"rim-router-service-ver-cgo/internal/apierr"
+	"rim-router-service-ver-cgo/internal/audit"
+	"rim-router-service-ver-cgo/internal/i18n"
+	"rim-router-service-ver-cgo/internal/middleware"
 	"rim-router-service-ver-cgo/internal/utils"
-
-	"github.com/rs/zerolog/log"
 )
 
 // =============================
@@ -25,7 +27,7 @@ import (
 func ListAllLogs(w http.ResponseWriter, r *http.Request) {
 	files, err := utils.DiscoverLogFiles(false)
 	if err != nil {
-		sendJSON(w, http.StatusInternalServerError, "scan failed", nil)
+		apierr.Write(w, r, apierr.New(http.StatusInternalServerError, apierr.CodeInternal, "scan failed"))
 		return
 	}
 
@@ -56,7 +58,7 @@ func ListAllLogs(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	sendJSON(w, http.StatusOK, "OK", out)
+	sendJSON(w, r, http.StatusOK, i18n.MsgOK, out)
 }
 
 // =============================
@@ -66,14 +68,17 @@ func ListAllLogs(w http.ResponseWriter, r *http.Request) {
 func DownloadAllLogs(w http.ResponseWriter, r *http.Request) {
 	files, err := utils.DiscoverLogFiles(true)
 	if err != nil || len(files) == 0 {
-		sendJSON(w, http.StatusNotFound, "no logs found", nil)
+		apierr.Write(w, r, apierr.New(http.StatusNotFound, apierr.CodeLogNotFound, "no logs found"))
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/zip")
 	w.Header().Set("Content-Disposition",
 		`attachment; filename="logs_all_`+time.Now().UTC().Format("20060102T150405")+`.zip"`)
+	audit.Record(r, audit.Entry{Action: audit.ActionLogsDownload, Target: "all",
+		After: map[string]int{"files": len(files)}})
 
+	logger := middleware.Log(r, "system")
 	pr, pw := io.Pipe()
 	go func() {
 		defer pw.Close()
@@ -83,7 +88,7 @@ func DownloadAllLogs(w http.ResponseWriter, r *http.Request) {
 		for _, f := range files {
 			// добавляем файл в архив с указанием корня
 			if err := addFileToZipWithRoot(zw, f.Path, f.RootID); err != nil {
-				log.Warn().Err(err).Str("file", f.Path).Msg("zip add failed")
+				logger.Warn().Err(err).Str("file", f.Path).Msg("zip add failed")
 			}
 		}
 	}()
@@ -104,19 +109,19 @@ func TailUnified(w http.ResponseWriter, r *http.Request) {
 	rootHint := strings.TrimSpace(q.Get("root"))
 
 	if name == "" || rootHint == "" {
-		sendJSON(w, http.StatusBadRequest, "name and root required", nil)
+		apierr.Write(w, r, apierr.Validation("name and root required", apierr.Field("name", "required", ""), apierr.Field("root", "required", "")))
 		return
 	}
 
 	li, err := utils.ResolveOneByName(name, rootHint)
 	if err != nil {
-		sendJSON(w, http.StatusNotFound, err.Error(), nil)
+		apierr.Write(w, r, apierr.New(http.StatusNotFound, apierr.CodeLogNotFound, err.Error()))
 		return
 	}
 
 	f, err := utils.OpenSafe(li.Path)
 	if err != nil {
-		sendJSON(w, http.StatusForbidden, "open blocked", nil)
+		apierr.Write(w, r, apierr.New(http.StatusForbidden, apierr.CodeLogAccessDenied, "open blocked"))
 		return
 	}
 	defer f.Close()
@@ -138,7 +143,7 @@ func TailUnified(w http.ResponseWriter, r *http.Request) {
 		return
 	case res := <-ch:
 		if res.err != nil {
-			sendJSON(w, http.StatusInternalServerError, "tail failed", nil)
+			apierr.Write(w, r, apierr.New(http.StatusInternalServerError, apierr.CodeInternal, "tail failed"))
 			return
 		}
 
@@ -157,7 +162,7 @@ func TailUnified(w http.ResponseWriter, r *http.Request) {
 				normalized = append(normalized, utils.ParseBracketLine(ss))
 			}
 		}
-		sendJSON(w, http.StatusOK, "OK", normalized)
+		sendJSON(w, r, http.StatusOK, i18n.MsgOK, normalized)
 	}
 }
 
@@ -178,7 +183,7 @@ type DownloadRequest struct {
 func DownloadSelectedLogs(w http.ResponseWriter, r *http.Request) {
 	var req DownloadRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Files) == 0 {
-		sendJSON(w, http.StatusBadRequest, "invalid body; expect {files:[...]}", nil)
+		apierr.Write(w, r, apierr.ErrInvalidJSON.WithMessage("invalid body; expect {files:[...]}"))
 		return
 	}
 
@@ -191,26 +196,33 @@ func DownloadSelectedLogs(w http.ResponseWriter, r *http.Request) {
 
 	for _, item := range req.Files {
 		if strings.TrimSpace(item.Name) == "" || strings.TrimSpace(item.Root) == "" {
-			sendJSON(w, http.StatusBadRequest, "each file requires name and root", nil)
+			apierr.Write(w, r, apierr.Validation("each file requires name and root", apierr.Field("files", "required", "")))
 			return
 		}
 		li, err := utils.ResolveOneByName(item.Name, strings.TrimSpace(item.Root))
 		if err != nil {
-			sendJSON(w, http.StatusNotFound, "not found: "+item.Name+" ("+item.Root+")", nil)
+			apierr.Write(w, r, apierr.New(http.StatusNotFound, apierr.CodeLogNotFound, "not found: "+item.Name+" ("+item.Root+")"))
 			return
 		}
 		toZip = append(toZip, resolved{Path: li.Path, Name: li.Name, Root: li.RootID})
 	}
 
 	if len(toZip) == 0 {
-		sendJSON(w, http.StatusNotFound, "no files to archive", nil)
+		apierr.Write(w, r, apierr.New(http.StatusNotFound, apierr.CodeLogNotFound, "no files to archive"))
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/zip")
 	w.Header().Set("Content-Disposition",
 		`attachment; filename="logs_selected_`+time.Now().UTC().Format("20060102T150405")+`.zip"`)
+	names := make([]string, 0, len(toZip))
+	for _, f := range toZip {
+		names = append(names, f.Root+"/"+f.Name)
+	}
+	audit.Record(r, audit.Entry{Action: audit.ActionLogsDownload, Target: "selected",
+		After: map[string][]string{"files": names}})
 
+	logger := middleware.Log(r, "system")
 	pr, pw := io.Pipe()
 	go func() {
 		defer pw.Close()
@@ -218,7 +230,7 @@ func DownloadSelectedLogs(w http.ResponseWriter, r *http.Request) {
 		defer zw.Close()
 		for _, f := range toZip {
 			if err := addFileToZipWithRoot(zw, f.Path, f.Root); err != nil {
-				log.Warn().Err(err).Str("file", f.Path).Msg("zip add failed")
+				logger.Warn().Err(err).Str("file", f.Path).Msg("zip add failed")
 			}
 		}
 	}()
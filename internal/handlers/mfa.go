@@ -0,0 +1,423 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/apierr"
+	"rim-router-service-ver-cgo/internal/audit"
+	"rim-router-service-ver-cgo/internal/i18n"
+	"rim-router-service-ver-cgo/internal/metrics"
+	"rim-router-service-ver-cgo/internal/middleware"
+	"rim-router-service-ver-cgo/internal/models"
+	"rim-router-service-ver-cgo/internal/utils"
+)
+
+// recoveryCodeCount — сколько кодов восстановления выдаётся при подключении MFA
+const recoveryCodeCount = 10
+
+// MFAChallenge — ответ Login, когда токены выдаются только после второго шага.
+type MFAChallenge struct {
+	MFARequired        bool   `json:"mfa_required"`
+	EnrollmentRequired bool   `json:"mfa_enrollment_required,omitempty"`
+	MFAToken           string `json:"mfa_token"`
+}
+
+type MFAVerifyRequest struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+}
+
+// MFAEnrollment — секрет для приложения-аутентификатора. URI кодируется в QR.
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type MFAConfirmRequest struct {
+	Code string `json:"code"`
+}
+
+// MFAConfirmed — новая сессия и коды восстановления. Коды показываются один раз.
+type MFAConfirmed struct {
+	AuthResponse
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type MFADisableRequest struct {
+	Password     string `json:"password"`
+	Code         string `json:"code,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+}
+
+type MFAStatus struct {
+	Enabled           bool `json:"enabled"`
+	Required          bool `json:"required"`
+	RecoveryCodesLeft int  `json:"recovery_codes_left"`
+}
+
+// mfaPurpose определяет, какой второй шаг нужен пользователю при входе:
+// TokenPurposeMFA, TokenPurposeMFAEnroll или "" (MFA не требуется).
+func (h *AuthHandler) mfaPurpose(user *models.User) (string, error) {
+	if h.MFA == nil {
+		return "", nil
+	}
+	m, err := h.MFA.GetMFA(user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+	if m != nil && m.Enabled {
+		return utils.TokenPurposeMFA, nil
+	}
+	if h.MFAConfig.Required(user.Role) {
+		return utils.TokenPurposeMFAEnroll, nil
+	}
+	return "", nil
+}
+
+// checkSecondFactor принимает TOTP-код (каждый шаг — один раз) или одноразовый
+// код восстановления. Возвращает способ проверки или "", если код не подошёл.
+func (h *AuthHandler) checkSecondFactor(m *models.UserMFA, code, recoveryCode string) (string, error) {
+	now := time.Now()
+	if recoveryCode != "" {
+		ok, err := h.MFA.ConsumeRecoveryCode(m.UserID, utils.NormalizeRecoveryCode(recoveryCode), now)
+		if err != nil || !ok {
+			return "", err
+		}
+		return "recovery_code", nil
+	}
+
+	step, ok := utils.VerifyTOTP(m.Secret, code, now)
+	if !ok {
+		return "", nil
+	}
+	ok, err := h.MFA.UseTOTPStep(m.UserID, step)
+	if err != nil || !ok {
+		return "", err
+	}
+	return "totp", nil
+}
+
+// POST /api/v1/login/mfa — второй шаг входа: mfa_token из Login и TOTP-код
+// (или код восстановления). Неверный код учитывается блокировкой входа.
+func (h *AuthHandler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	if h.MFA == nil {
+		apierr.Write(w, r, apierr.New(http.StatusNotImplemented, apierr.CodeFeatureDisabled, "MFA is disabled"))
+		return
+	}
+
+	var req MFAVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, r, apierr.ErrInvalidRequest)
+		return
+	}
+
+	claims, err := utils.ValidateMFAToken(req.MFAToken, utils.TokenPurposeMFA)
+	if err != nil {
+		apierr.Write(w, r, apierr.New(http.StatusUnauthorized, apierr.CodeInvalidMFAToken, "Invalid or expired MFA token"))
+		return
+	}
+
+	ip := middleware.ClientIP(r)
+	logger := middleware.Log(r, "auth").With().Str("user", claims.Username).Logger()
+
+	if h.Guard != nil {
+		wait, err := h.Guard.Check(claims.Username, ip)
+		if err != nil {
+			(&logger).Error().Err(err).Msg("Failed to check login lockout")
+			apierr.Write(w, r, apierr.ErrDatabase)
+			return
+		}
+		if wait > 0 {
+			setRetryAfter(w, wait)
+			apierr.Write(w, r, apierr.New(http.StatusTooManyRequests, apierr.CodeTooManyAttempts, "Too many failed login attempts"))
+			return
+		}
+	}
+
+	user, err := h.UserRepo.GetUserByID(claims.UserID)
+	if err != nil {
+		apierr.Write(w, r, apierr.New(http.StatusUnauthorized, apierr.CodeUserNotFound, "User not found"))
+		return
+	}
+	if user.Status != models.UserStatusActive {
+		apierr.Write(w, r, inactiveAccountError(user.Status))
+		return
+	}
+
+	m, err := h.MFA.GetMFA(user.ID)
+	if err != nil || !m.Enabled {
+		apierr.Write(w, r, apierr.New(http.StatusUnauthorized, apierr.CodeMFANotEnabled, "MFA is not enabled"))
+		return
+	}
+
+	method, err := h.checkSecondFactor(m, req.Code, req.RecoveryCode)
+	if err != nil {
+		(&logger).Error().Err(err).Msg("Failed to check MFA code")
+		apierr.Write(w, r, apierr.ErrDatabase)
+		return
+	}
+	if method == "" {
+		(&logger).Warn().Msg("Invalid MFA code")
+		audit.Record(r, audit.Entry{Action: audit.ActionLoginFailed, Actor: user.Username, ActorID: user.ID, Target: user.Username,
+			After: map[string]string{"reason": "invalid_mfa_code"}})
+		h.loginFailed(w, r, user.Username, ip)
+		return
+	}
+
+	// mfa_token одноразовый
+	if err := utils.RevokeAccessToken(claims); err != nil {
+		(&logger).Error().Err(err).Msg("Failed to revoke MFA token")
+	}
+	if h.Guard != nil {
+		if err := h.Guard.Succeed(user.Username); err != nil {
+			(&logger).Error().Err(err).Msg("Failed to reset login failures")
+		}
+	}
+
+	access, ok := h.issueSession(w, r, user, &logger)
+	if !ok {
+		return
+	}
+
+	(&logger).Info().Str("method", method).Msg("User logged in with MFA")
+	metrics.LoginAttempts.Inc(metrics.LoginSuccess)
+	audit.Record(r, audit.Entry{Action: audit.ActionLogin, Actor: user.Username, ActorID: user.ID, Target: user.Username,
+		After: map[string]string{"mfa": method}})
+
+	resp := AuthResponse{AccessToken: access, Role: user.Role, MustChangePassword: user.MustChangePassword}
+	sendJSON(w, r, http.StatusOK, i18n.MsgLoginSuccessful, resp)
+}
+
+// GET /api/v1/mfa — состояние MFA текущего пользователя
+func (h *AuthHandler) MFAStatus(w http.ResponseWriter, r *http.Request) {
+	if h.MFA == nil {
+		apierr.Write(w, r, apierr.New(http.StatusNotImplemented, apierr.CodeFeatureDisabled, "MFA is disabled"))
+		return
+	}
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		apierr.Write(w, r, apierr.ErrAuthRequired)
+		return
+	}
+
+	status := MFAStatus{Required: h.MFAConfig.Required(claims.Role)}
+	m, err := h.MFA.GetMFA(claims.UserID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		apierr.Write(w, r, apierr.ErrDatabase)
+		return
+	}
+	if m != nil && m.Enabled {
+		status.Enabled = true
+		if status.RecoveryCodesLeft, err = h.MFA.RecoveryCodesLeft(claims.UserID); err != nil {
+			apierr.Write(w, r, apierr.ErrDatabase)
+			return
+		}
+	}
+	sendJSON(w, r, http.StatusOK, i18n.MsgOK, status)
+}
+
+// POST /api/v1/mfa/enroll — начать подключение TOTP: выдаёт новый секрет.
+// Повторный вызов до подтверждения заменяет секрет.
+func (h *AuthHandler) EnrollMFA(w http.ResponseWriter, r *http.Request) {
+	if h.MFA == nil {
+		apierr.Write(w, r, apierr.New(http.StatusNotImplemented, apierr.CodeFeatureDisabled, "MFA is disabled"))
+		return
+	}
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		apierr.Write(w, r, apierr.ErrAuthRequired)
+		return
+	}
+
+	logger := middleware.Log(r, "auth")
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		(&logger).Error().Err(err).Msg("Failed to generate TOTP secret")
+		apierr.Write(w, r, apierr.New(http.StatusInternalServerError, apierr.CodeInternal, "Failed to generate secret"))
+		return
+	}
+	ok, err := h.MFA.SavePendingMFA(claims.UserID, secret, time.Now())
+	if err != nil {
+		(&logger).Error().Err(err).Msg("Failed to store TOTP secret")
+		apierr.Write(w, r, apierr.ErrDatabase)
+		return
+	}
+	if !ok {
+		apierr.Write(w, r, apierr.New(http.StatusConflict, apierr.CodeMFAAlreadyEnabled, "MFA is already enabled"))
+		return
+	}
+
+	(&logger).Info().Msg("MFA enrollment started")
+	sendJSON(w, r, http.StatusOK, i18n.MsgMFAEnrollStarted, MFAEnrollment{
+		Secret: secret,
+		URI:    utils.TOTPProvisioningURI(h.MFAConfig.Issuer, claims.Username, secret),
+	})
+}
+
+// POST /api/v1/mfa/confirm — подтвердить подключение первым кодом из приложения.
+// Выдаёт коды восстановления и новую сессию; прежние токены отзываются.
+func (h *AuthHandler) ConfirmMFA(w http.ResponseWriter, r *http.Request) {
+	if h.MFA == nil {
+		apierr.Write(w, r, apierr.New(http.StatusNotImplemented, apierr.CodeFeatureDisabled, "MFA is disabled"))
+		return
+	}
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		apierr.Write(w, r, apierr.ErrAuthRequired)
+		return
+	}
+
+	var req MFAConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, r, apierr.ErrInvalidRequest)
+		return
+	}
+
+	logger := middleware.Log(r, "auth")
+
+	m, err := h.MFA.GetMFA(claims.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		apierr.Write(w, r, apierr.New(http.StatusConflict, apierr.CodeMFANotEnrolling, "MFA enrollment not started"))
+		return
+	}
+	if err != nil {
+		(&logger).Error().Err(err).Msg("Failed to load MFA settings")
+		apierr.Write(w, r, apierr.ErrDatabase)
+		return
+	}
+	if m.Enabled {
+		apierr.Write(w, r, apierr.New(http.StatusConflict, apierr.CodeMFAAlreadyEnabled, "MFA is already enabled"))
+		return
+	}
+
+	step, ok := utils.VerifyTOTP(m.Secret, req.Code, time.Now())
+	if !ok {
+		(&logger).Warn().Msg("Invalid code on MFA confirmation")
+		apierr.Write(w, r, apierr.New(http.StatusUnauthorized, apierr.CodeInvalidMFACode, "Invalid code"))
+		return
+	}
+	// тот же код не должен подойти для входа
+	if _, err := h.MFA.UseTOTPStep(m.UserID, step); err != nil {
+		(&logger).Error().Err(err).Msg("Failed to store TOTP step")
+		apierr.Write(w, r, apierr.ErrDatabase)
+		return
+	}
+
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		(&logger).Error().Err(err).Msg("Failed to generate recovery codes")
+		apierr.Write(w, r, apierr.New(http.StatusInternalServerError, apierr.CodeInternal, "Failed to generate recovery codes"))
+		return
+	}
+	if err := h.MFA.EnableMFA(m.UserID, codes, time.Now()); err != nil {
+		(&logger).Error().Err(err).Msg("Failed to enable MFA")
+		apierr.Write(w, r, apierr.ErrDatabase)
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByID(claims.UserID)
+	if err != nil {
+		apierr.Write(w, r, apierr.New(http.StatusUnauthorized, apierr.CodeUserNotFound, "User not found"))
+		return
+	}
+
+	// сессии, открытые без второго фактора, закрываются
+	if err := utils.RevokeAccessToken(claims); err != nil {
+		(&logger).Error().Err(err).Msg("Failed to revoke access token")
+	}
+	if err := utils.RevokeUserTokens(user.ID); err != nil {
+		(&logger).Error().Err(err).Msg("Failed to revoke access tokens")
+	}
+	access, ok := h.issueSession(w, r, user, &logger)
+	if !ok {
+		return
+	}
+
+	(&logger).Info().Msg("MFA enabled")
+	audit.Record(r, audit.Entry{Action: audit.ActionMFAEnable, Actor: user.Username, ActorID: user.ID, Target: user.Username})
+	sendJSON(w, r, http.StatusOK, i18n.MsgMFAEnabled, MFAConfirmed{
+		AuthResponse:  AuthResponse{AccessToken: access, Role: user.Role, MustChangePassword: user.MustChangePassword},
+		RecoveryCodes: codes,
+	})
+}
+
+// POST /api/v1/mfa/disable — отключить MFA (пароль и код обязательны).
+// Недоступно, если MFA для роли пользователя обязательна.
+func (h *AuthHandler) DisableMFA(w http.ResponseWriter, r *http.Request) {
+	if h.MFA == nil {
+		apierr.Write(w, r, apierr.New(http.StatusNotImplemented, apierr.CodeFeatureDisabled, "MFA is disabled"))
+		return
+	}
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		apierr.Write(w, r, apierr.ErrAuthRequired)
+		return
+	}
+
+	if claims.APIKeyID != 0 {
+		apierr.Write(w, r, apierr.New(http.StatusForbidden, apierr.CodeAPIKeyNotAllowed, "Not available for API keys"))
+		return
+	}
+
+	var req MFADisableRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, r, apierr.ErrInvalidRequest)
+		return
+	}
+
+	logger := middleware.Log(r, "auth")
+
+	user, err := h.UserRepo.GetUserByID(claims.UserID)
+	if err != nil {
+		apierr.Write(w, r, apierr.New(http.StatusUnauthorized, apierr.CodeUserNotFound, "User not found"))
+		return
+	}
+	if h.MFAConfig.Required(user.Role) {
+		apierr.Write(w, r, apierr.New(http.StatusForbidden, apierr.CodeMFARequired, "MFA is required for your role"))
+		return
+	}
+	// пароль проверяется там же, где при входе (у пользователей каталога — в
+	// LDAP), и неудачи считаются в ту же блокировку
+	ip := middleware.ClientIP(r)
+	if h.lockedOut(w, r, user.Username, ip, &logger) {
+		return
+	}
+	if _, err := h.Authenticator.Authenticate(user.Username, req.Password); err != nil {
+		(&logger).Warn().Err(err).Msg("Invalid password on MFA disable")
+		h.credentialsFailed(w, r, user.Username, ip, apierr.New(http.StatusUnauthorized, apierr.CodeInvalidCredentials, "Invalid credentials"))
+		return
+	}
+
+	m, err := h.MFA.GetMFA(user.ID)
+	if err != nil || !m.Enabled {
+		apierr.Write(w, r, apierr.New(http.StatusConflict, apierr.CodeMFANotEnabled, "MFA is not enabled"))
+		return
+	}
+	method, err := h.checkSecondFactor(m, req.Code, req.RecoveryCode)
+	if err != nil {
+		(&logger).Error().Err(err).Msg("Failed to check MFA code")
+		apierr.Write(w, r, apierr.ErrDatabase)
+		return
+	}
+	if method == "" {
+		(&logger).Warn().Msg("Invalid MFA code on disable")
+		h.credentialsFailed(w, r, user.Username, ip, apierr.New(http.StatusUnauthorized, apierr.CodeInvalidMFACode, "Invalid code"))
+		return
+	}
+
+	if _, err := h.MFA.DisableMFA(user.ID); err != nil {
+		(&logger).Error().Err(err).Msg("Failed to disable MFA")
+		apierr.Write(w, r, apierr.ErrDatabase)
+		return
+	}
+
+	(&logger).Info().Msg("MFA disabled")
+	audit.Record(r, audit.Entry{Action: audit.ActionMFADisable, Target: user.Username})
+	sendJSON(w, r, http.StatusOK, i18n.MsgMFADisabled, nil)
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/config"
+	"rim-router-service-ver-cgo/internal/models"
+	"rim-router-service-ver-cgo/internal/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var mfaColumns = []string{"user_id", "secret", "enabled", "last_step", "created_at", "confirmed_at"}
+
+func setupMFAHandler(t *testing.T) (*AuthHandler, sqlmock.Sqlmock, func()) {
+	h, mock, cleanup := setupAuthHandler(t)
+	h.MFA = models.NewMFARepository(h.UserRepo.DB)
+	h.MFAConfig = config.MFAConfig{Issuer: "RIM Router", ChallengeTTL: 5 * time.Minute}
+	return h, mock, cleanup
+}
+
+func userRow(role int, hash string) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "username", "password_hash", "role", "must_change_password", "status", "created_at", "locale"}).
+		AddRow(1, "tester", hash, role, false, "active", time.Now(), "")
+}
+
+func TestLogin_MFAEnabledReturnsChallenge(t *testing.T) {
+	h, mock, cleanup := setupMFAHandler(t)
+	defer cleanup()
+
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	mock.ExpectQuery("SELECT id, username").WithArgs("tester").WillReturnRows(userRow(1, string(hashed)))
+	mock.ExpectQuery("SELECT user_id, secret").WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows(mfaColumns).AddRow(1, "SECRET", true, 0, time.Now(), time.Now()))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/login", bytes.NewBufferString(`{"username":"tester","password":"password123"}`))
+	w := httptest.NewRecorder()
+	h.Login(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Result().Cookies(), "refresh-токен выдаётся только после второго шага")
+
+	var resp struct {
+		Data MFAChallenge `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.True(t, resp.Data.MFARequired)
+	assert.False(t, resp.Data.EnrollmentRequired)
+	assert.NotEmpty(t, resp.Data.MFAToken)
+	assert.NotContains(t, w.Body.String(), "access_token")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLogin_MFAEnrollmentRequiredForAdmins(t *testing.T) {
+	h, mock, cleanup := setupMFAHandler(t)
+	defer cleanup()
+	h.MFAConfig.RequireForAdmins = true
+
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	mock.ExpectQuery("SELECT id, username").WithArgs("tester").WillReturnRows(userRow(1, string(hashed)))
+	mock.ExpectQuery("SELECT user_id, secret").WithArgs(int64(1)).WillReturnError(sql.ErrNoRows)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/login", bytes.NewBufferString(`{"username":"tester","password":"password123"}`))
+	w := httptest.NewRecorder()
+	h.Login(w, req)
+
+	var resp struct {
+		Data MFAChallenge `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, resp.Data.EnrollmentRequired)
+
+	// токен подключения годится только для подключения
+	_, err := utils.ValidateToken(resp.Data.MFAToken)
+	assert.Error(t, err)
+	_, err = utils.ValidateMFAToken(resp.Data.MFAToken, utils.TokenPurposeMFAEnroll)
+	assert.NoError(t, err)
+}
+
+func TestVerifyMFA_SuccessAndSingleUse(t *testing.T) {
+	h, mock, cleanup := setupMFAHandler(t)
+	defer cleanup()
+
+	secret, _ := utils.GenerateTOTPSecret()
+	code, _ := utils.TOTPCode(secret, time.Now())
+	token, _ := utils.GenerateMFAToken(&models.User{ID: 1, Username: "tester", Role: 1}, utils.TokenPurposeMFA, h.MFAConfig.ChallengeTTL)
+
+	mock.ExpectQuery("SELECT id, username").WithArgs(int64(1)).WillReturnRows(userRow(1, "hash"))
+	mock.ExpectQuery("SELECT user_id, secret").WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows(mfaColumns).AddRow(1, secret, true, 0, time.Now(), time.Now()))
+	mock.ExpectExec("UPDATE user_mfa SET last_step").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM refresh_tokens").WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO refresh_tokens").WillReturnResult(sqlmock.NewResult(1, 1))
+
+	body, _ := json.Marshal(MFAVerifyRequest{MFAToken: token, Code: code})
+	w := httptest.NewRecorder()
+	h.VerifyMFA(w, httptest.NewRequest(http.MethodPost, "/api/v1/login/mfa", bytes.NewReader(body)))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "access_token")
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	w = httptest.NewRecorder()
+	h.VerifyMFA(w, httptest.NewRequest(http.MethodPost, "/api/v1/login/mfa", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "mfa_token одноразовый")
+}
+
+func TestVerifyMFA_InvalidCode(t *testing.T) {
+	h, mock, cleanup := setupMFAHandler(t)
+	defer cleanup()
+
+	secret, _ := utils.GenerateTOTPSecret()
+	token, _ := utils.GenerateMFAToken(&models.User{ID: 1, Username: "tester", Role: 1}, utils.TokenPurposeMFA, h.MFAConfig.ChallengeTTL)
+
+	mock.ExpectQuery("SELECT id, username").WithArgs(int64(1)).WillReturnRows(userRow(1, "hash"))
+	mock.ExpectQuery("SELECT user_id, secret").WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows(mfaColumns).AddRow(1, secret, true, 0, time.Now(), time.Now()))
+
+	body, _ := json.Marshal(MFAVerifyRequest{MFAToken: token, Code: "000000x"})
+	w := httptest.NewRecorder()
+	h.VerifyMFA(w, httptest.NewRequest(http.MethodPost, "/api/v1/login/mfa", bytes.NewReader(body)))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, w.Result().Cookies())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDisableMFA_RequiredForRole(t *testing.T) {
+	h, mock, cleanup := setupMFAHandler(t)
+	defer cleanup()
+	h.MFAConfig.RequireForAdmins = true
+
+	mock.ExpectQuery("SELECT id, username").WithArgs(int64(1)).WillReturnRows(userRow(1, "hash"))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/mfa/disable", bytes.NewBufferString(`{"password":"x","code":"123456"}`))
+	req = withClaims(req, &utils.Claims{UserID: 1, Username: "tester", Role: 1})
+	w := httptest.NewRecorder()
+	h.DisableMFA(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefresh_MFARequiredButNotEnrolled(t *testing.T) {
+	h, mock, cleanup := setupMFAHandler(t)
+	defer cleanup()
+	h.MFAConfig.RequireForAdmins = true
+
+	mock.ExpectQuery("SELECT user_id, expires_at").
+		WithArgs(models.HashToken("refresh123")).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "expires_at"}).AddRow(1, time.Now().Add(time.Hour)))
+	mock.ExpectQuery("SELECT id, username").WithArgs(int64(1)).WillReturnRows(userRow(1, "hash"))
+	mock.ExpectQuery("SELECT user_id, secret").WithArgs(int64(1)).WillReturnError(sql.ErrNoRows)
+	// все refresh-токены пользователя удаляются
+	mock.ExpectExec("DELETE FROM refresh_tokens WHERE user_id").
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/refresh", nil)
+	req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "refresh123"})
+	w := httptest.NewRecorder()
+	h.Refresh(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), `"mfa_required"`)
+	assert.NotContains(t, w.Body.String(), "access_token")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"net/http"
+
+	"rim-router-service-ver-cgo/internal/apierr"
+	"rim-router-service-ver-cgo/internal/middleware"
+	"rim-router-service-ver-cgo/internal/openapi"
+)
+
+// GET /api/openapi.json — спецификация API (OpenAPI 3)
+func OpenAPI(w http.ResponseWriter, r *http.Request) {
+	doc, err := openapi.Document()
+	if err != nil {
+		logger := middleware.Log(r, "system")
+		logger.Error().Err(err).Msg("Failed to load OpenAPI document")
+		apierr.Write(w, r, apierr.ErrInternal)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_, _ = w.Write(doc)
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/config"
+	"rim-router-service-ver-cgo/internal/middleware"
+	"rim-router-service-ver-cgo/internal/utils"
+)
+
+// sessionCookie — cookie сессии с атрибутами из конфигурации
+func (h *AuthHandler) sessionCookie(name, value string, maxAge int, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   h.Session.Domain,
+		Secure:   h.Session.Secure,
+		HttpOnly: httpOnly,
+		SameSite: h.Session.SameSite,
+		MaxAge:   maxAge,
+	}
+}
+
+// setSessionCookies выставляет refresh-cookie, а в режиме cookie-сессий ещё и
+// HttpOnly access-cookie с читаемым CSRF-токеном для double-submit.
+func (h *AuthHandler) setSessionCookies(w http.ResponseWriter, refresh, access string) error {
+	cfg := config.GetJWTConfig()
+	http.SetCookie(w, h.sessionCookie(middleware.RefreshCookieName, refresh, int(cfg.RefreshExpiration/time.Second), true))
+	if !h.Session.CookieMode {
+		return nil
+	}
+
+	csrf, err := utils.GenerateSecureToken()
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, h.sessionCookie(middleware.AccessCookieName, access, int(cfg.AccessExpiration/time.Second), true))
+	// CSRF-токен живёт столько же, сколько сессия: access-cookie обновляется через refresh
+	http.SetCookie(w, h.sessionCookie(middleware.CSRFCookieName, csrf, int(cfg.RefreshExpiration/time.Second), false))
+	return nil
+}
+
+// clearSessionCookies удаляет все cookie сессии
+func (h *AuthHandler) clearSessionCookies(w http.ResponseWriter) {
+	http.SetCookie(w, h.sessionCookie(middleware.RefreshCookieName, "", -1, true))
+	if h.Session.CookieMode {
+		http.SetCookie(w, h.sessionCookie(middleware.AccessCookieName, "", -1, true))
+		http.SetCookie(w, h.sessionCookie(middleware.CSRFCookieName, "", -1, false))
+	}
+}
+
+// requestAccessToken — access-токен запроса: из заголовка или из cookie сессии
+func requestAccessToken(r *http.Request) string {
+	if token := bearerToken(r); token != "" {
+		return token
+	}
+	token, _ := middleware.AccessTokenFromCookie(r)
+	return token
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"rim-router-service-ver-cgo/internal/i18n"
+	"rim-router-service-ver-cgo/internal/middleware"
+	"rim-router-service-ver-cgo/internal/sysinfo"
+)
+
+type SystemHandler struct {
+	Info *sysinfo.Collector
+}
+
+func NewSystemHandler(info *sysinfo.Collector) *SystemHandler {
+	return &SystemHandler{Info: info}
+}
+
+// GET /api/v2/system — состояние устройства: память, нагрузка, диски, сеть, БД
+func (h *SystemHandler) Get(w http.ResponseWriter, r *http.Request) {
+	info := h.Info.Collect()
+	if len(info.Errors) > 0 {
+		logger := middleware.Log(r, "system")
+		(&logger).Warn().Strs("errors", info.Errors).Msg("System info collected partially")
+	}
+	sendJSON(w, r, http.StatusOK, i18n.MsgOK, info)
+}
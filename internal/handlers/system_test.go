@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rim-router-service-ver-cgo/internal/sysinfo"
+	"rim-router-service-ver-cgo/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSystemHandler_Get(t *testing.T) {
+	proc := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(proc, "uptime"), []byte("42.00 1.00\n"), 0o644))
+	logs := t.TempDir()
+
+	h := NewSystemHandler(&sysinfo.Collector{
+		ProcRoot:   proc,
+		Roots:      func() []utils.Root { return []utils.Root{{ID: "local", Path: logs}} },
+		LogDir:     func() string { return logs },
+		Interfaces: func() ([]net.Interface, error) { return nil, nil },
+	})
+
+	w := httptest.NewRecorder()
+	h.Get(w, httptest.NewRequest(http.MethodGet, "/api/v2/system", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data sysinfo.Info `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 42.0, resp.Data.Uptime)
+	assert.Equal(t, logs, resp.Data.LogDir)
+	assert.Len(t, resp.Data.Disks, 1)
+	assert.NotEmpty(t, resp.Data.Errors, "недостающие файлы /proc перечислены, а не роняют ответ")
+}
@@ -0,0 +1,125 @@
+package i18n
+
+// Key — ключ сообщения. Для ошибок ключ совпадает с кодом apierr.Code.
+type Key string
+
+// Сообщения успешных ответов
+const (
+	MsgOK                  Key = "ok"
+	MsgSuccess             Key = "success"
+	MsgLoginSuccessful     Key = "login_successful"
+	MsgMFACodeRequired     Key = "mfa_code_required"
+	MsgMFAEnrollRequired   Key = "mfa_enrollment_required"
+	MsgUserRegistered      Key = "user_registered"
+	MsgRegistrationPending Key = "registration_pending"
+	MsgTokenRefreshed      Key = "token_refreshed"
+	MsgLoggedOut           Key = "logged_out"
+	MsgPasswordChanged     Key = "password_changed"
+	MsgLocaleUpdated       Key = "locale_updated"
+	MsgMFAEnrollStarted    Key = "mfa_enrollment_started"
+	MsgMFAEnabled          Key = "mfa_enabled"
+	MsgMFADisabled         Key = "mfa_disabled"
+	MsgMFAReset            Key = "mfa_reset"
+	MsgRoleUpdated         Key = "role_updated"
+	MsgUserUnlocked        Key = "user_unlocked"
+	MsgUserApproved        Key = "user_approved"
+	MsgInviteCreated       Key = "invite_created"
+	MsgInviteRevoked       Key = "invite_revoked"
+	MsgAPIKeyCreated       Key = "api_key_created"
+	MsgAPIKeyRevoked       Key = "api_key_revoked"
+	MsgLogLevelUpdated     Key = "log_level_updated"
+	MsgLogLevelReset       Key = "log_level_reset"
+	MsgTirStarted          Key = "tir_started"
+	MsgTirStopped          Key = "tir_stopped"
+	MsgTirRestarted        Key = "tir_restarted"
+)
+
+var catalog = map[Key]map[Lang]string{
+	// --- успешные ответы ---
+	MsgOK:                  {EN: "OK", RU: "OK"},
+	MsgSuccess:             {EN: "Success", RU: "Успешно"},
+	MsgLoginSuccessful:     {EN: "Login successful", RU: "Вход выполнен"},
+	MsgMFACodeRequired:     {EN: "MFA code required", RU: "Введите код двухфакторной аутентификации"},
+	MsgMFAEnrollRequired:   {EN: "MFA enrollment required", RU: "Необходимо подключить двухфакторную аутентификацию"},
+	MsgUserRegistered:      {EN: "User registered successfully", RU: "Пользователь зарегистрирован"},
+	MsgRegistrationPending: {EN: "Registration pending approval", RU: "Регистрация ожидает подтверждения администратором"},
+	MsgTokenRefreshed:      {EN: "Token refreshed", RU: "Токен обновлён"},
+	MsgLoggedOut:           {EN: "Logged out", RU: "Выход выполнен"},
+	MsgPasswordChanged:     {EN: "Password changed", RU: "Пароль изменён"},
+	MsgLocaleUpdated:       {EN: "Language updated", RU: "Язык изменён"},
+	MsgMFAEnrollStarted:    {EN: "MFA enrollment started", RU: "Подключение двухфакторной аутентификации начато"},
+	MsgMFAEnabled:          {EN: "MFA enabled", RU: "Двухфакторная аутентификация включена"},
+	MsgMFADisabled:         {EN: "MFA disabled", RU: "Двухфакторная аутентификация отключена"},
+	MsgMFAReset:            {EN: "MFA reset", RU: "Двухфакторная аутентификация сброшена"},
+	MsgRoleUpdated:         {EN: "Role updated successfully", RU: "Роль изменена"},
+	MsgUserUnlocked:        {EN: "User unlocked", RU: "Пользователь разблокирован"},
+	MsgUserApproved:        {EN: "User approved", RU: "Регистрация подтверждена"},
+	MsgInviteCreated:       {EN: "Invite created", RU: "Приглашение создано"},
+	MsgInviteRevoked:       {EN: "Invite revoked", RU: "Приглашение отозвано"},
+	MsgAPIKeyCreated:       {EN: "API key created", RU: "API-ключ создан"},
+	MsgAPIKeyRevoked:       {EN: "API key revoked", RU: "API-ключ отозван"},
+	MsgLogLevelUpdated:     {EN: "Log level updated", RU: "Уровень логирования изменён"},
+	MsgLogLevelReset:       {EN: "Log level reset", RU: "Уровень логирования сброшен"},
+	MsgTirStarted:          {EN: "TIR started successfully", RU: "ТИР успешно запущен"},
+	MsgTirStopped:          {EN: "TIR stopped successfully", RU: "ТИР успешно остановлен"},
+	MsgTirRestarted:        {EN: "TIR restarted successfully", RU: "ТИР успешно перезапущен"},
+
+	// --- ошибки (ключ — код apierr) ---
+	"invalid_json":       {EN: "Invalid JSON", RU: "Некорректный JSON"},
+	"invalid_request":    {EN: "Invalid request", RU: "Некорректный запрос"},
+	"validation_failed":  {EN: "Validation failed", RU: "Данные запроса не прошли проверку"},
+	"invalid_id":         {EN: "Invalid ID", RU: "Некорректный идентификатор"},
+	"not_found":          {EN: "Not found", RU: "Не найдено"},
+	"method_not_allowed": {EN: "Method not allowed", RU: "Метод не поддерживается"},
+	"rate_limited":       {EN: "Too many requests", RU: "Слишком много запросов"},
+	"database_error":     {EN: "Database error", RU: "Ошибка базы данных"},
+	"internal_error":     {EN: "Internal server error", RU: "Внутренняя ошибка сервера"},
+	"feature_disabled":   {EN: "This feature is disabled", RU: "Функция отключена"},
+
+	"auth_required":            {EN: "Authentication required", RU: "Требуется вход в систему"},
+	"invalid_auth_format":      {EN: "Invalid authorization format", RU: "Некорректный формат авторизации"},
+	"invalid_token":            {EN: "Invalid token", RU: "Недействительный токен"},
+	"token_revoked":            {EN: "Token revoked", RU: "Токен отозван"},
+	"invalid_credentials":      {EN: "Invalid credentials", RU: "Неверное имя пользователя или пароль"},
+	"too_many_attempts":        {EN: "Too many failed login attempts", RU: "Слишком много неудачных попыток входа"},
+	"account_pending":          {EN: "Account pending approval", RU: "Учётная запись ожидает подтверждения"},
+	"account_disabled":         {EN: "Account disabled", RU: "Учётная запись отключена"},
+	"account_inactive":         {EN: "Account is not active", RU: "Учётная запись неактивна"},
+	"user_not_found":           {EN: "User not found", RU: "Пользователь не найден"},
+	"insufficient_role":        {EN: "Insufficient permissions", RU: "Недостаточно прав"},
+	"insufficient_scope":       {EN: "API key lacks required scope", RU: "У API-ключа нет нужной области доступа"},
+	"password_change_required": {EN: "Password change required", RU: "Необходимо сменить пароль"},
+	"csrf_failed":              {EN: "CSRF validation failed", RU: "Проверка CSRF не пройдена"},
+	"invalid_refresh_token":    {EN: "Invalid or expired refresh token", RU: "Сессия истекла, войдите заново"},
+	"invalid_api_key":          {EN: "Invalid API key", RU: "Недействительный API-ключ"},
+	"api_key_not_allowed":      {EN: "Not available for API keys", RU: "Недоступно для API-ключей"},
+	"invalid_client":           {EN: "Invalid client credentials", RU: "Неверные учётные данные клиента"},
+	"auth_backend_unavailable": {EN: "Authentication backend unavailable", RU: "Служба аутентификации недоступна"},
+	"no_directory_role":        {EN: "No role is assigned to your directory groups", RU: "Вашим группам каталога не назначена роль"},
+	"local_account_conflict":   {EN: "A local account with this name already exists", RU: "Локальная учётная запись с таким именем уже существует"},
+	"signing_keys_unavailable": {EN: "Signing keys unavailable", RU: "Ключи подписи недоступны"},
+
+	"registration_disabled":       {EN: "Registration is disabled", RU: "Регистрация отключена"},
+	"invite_required":             {EN: "Invite code required", RU: "Требуется код приглашения"},
+	"invalid_invite":              {EN: "Invalid or expired invite code", RU: "Код приглашения недействителен или истёк"},
+	"user_exists":                 {EN: "User already exists", RU: "Пользователь уже существует"},
+	"password_policy":             {EN: "Password does not meet policy", RU: "Пароль не соответствует требованиям"},
+	"password_reused":             {EN: "New password must differ from the current one", RU: "Новый пароль должен отличаться от текущего"},
+	"password_managed_externally": {EN: "Password is managed by the external directory", RU: "Пароль управляется внешним каталогом"},
+
+	"mfa_required":               {EN: "MFA is required for your role", RU: "Для вашей роли обязательна двухфакторная аутентификация"},
+	"invalid_mfa_token":          {EN: "Invalid or expired MFA token", RU: "Срок второго шага входа истёк, войдите заново"},
+	"invalid_mfa_code":           {EN: "Invalid code", RU: "Неверный код"},
+	"mfa_not_enabled":            {EN: "MFA is not enabled", RU: "Двухфакторная аутентификация не подключена"},
+	"mfa_already_enabled":        {EN: "MFA is already enabled", RU: "Двухфакторная аутентификация уже подключена"},
+	"mfa_enrollment_not_started": {EN: "MFA enrollment not started", RU: "Подключение двухфакторной аутентификации не начато"},
+
+	"user_not_pending":  {EN: "User is not pending approval", RU: "Пользователь не ожидает подтверждения"},
+	"invite_not_found":  {EN: "Invite not found", RU: "Приглашение не найдено"},
+	"api_key_not_found": {EN: "API key not found", RU: "API-ключ не найден"},
+
+	"log_not_found":       {EN: "Log not found", RU: "Лог не найден"},
+	"log_access_denied":   {EN: "Access to the file is denied", RU: "Доступ к файлу запрещён"},
+	"tir_already_running": {EN: "TIR is already running", RU: "ТИР уже запущен"},
+	"tir_not_running":     {EN: "TIR is not running", RU: "ТИР не запущен"},
+}
@@ -0,0 +1,115 @@
+// Package i18n — каталог сообщений API на русском и английском. Язык
+// запроса кладётся в контекст middleware: сначала настройка пользователя
+// (models.User.Locale), затем Accept-Language, затем язык по умолчанию.
+package i18n
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Lang — поддерживаемый язык сообщений
+type Lang string
+
+const (
+	EN Lang = "en"
+	RU Lang = "ru"
+)
+
+// Supported — поддерживаемые языки
+var Supported = []Lang{EN, RU}
+
+var defaultLang atomic.Value // Lang
+
+func init() { defaultLang.Store(EN) }
+
+// SetDefault задаёт язык для запросов без предпочтений
+func SetDefault(l Lang) { defaultLang.Store(l) }
+
+// Default возвращает язык по умолчанию
+func Default() Lang { return defaultLang.Load().(Lang) }
+
+// Parse разбирает тег языка ("ru", "ru-RU", "EN_us"); ok == false для
+// неподдерживаемых языков
+func Parse(s string) (Lang, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if i := strings.IndexAny(s, "-_"); i >= 0 {
+		s = s[:i]
+	}
+	for _, l := range Supported {
+		if string(l) == s {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// FromAcceptLanguage выбирает из заголовка Accept-Language поддерживаемый
+// язык с наибольшим q; ok == false, если подходящего нет
+func FromAcceptLanguage(header string) (Lang, bool) {
+	type candidate struct {
+		lang Lang
+		q    float64
+	}
+	var cands []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang, ok := Parse(tag)
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if v, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q > 0 {
+			cands = append(cands, candidate{lang, q})
+		}
+	}
+	if len(cands) == 0 {
+		return "", false
+	}
+	sort.SliceStable(cands, func(i, j int) bool { return cands[i].q > cands[j].q })
+	return cands[0].lang, true
+}
+
+type contextKey struct{}
+
+// WithLang кладёт язык запроса в контекст
+func WithLang(ctx context.Context, l Lang) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext возвращает язык запроса или язык по умолчанию
+func FromContext(ctx context.Context) Lang {
+	if l, ok := ctx.Value(contextKey{}).(Lang); ok {
+		return l
+	}
+	return Default()
+}
+
+// Lookup ищет перевод ключа; для языка без перевода — английский текст
+func Lookup(l Lang, key Key) (string, bool) {
+	tr, ok := catalog[key]
+	if !ok {
+		return "", false
+	}
+	if s, ok := tr[l]; ok {
+		return s, true
+	}
+	s, ok := tr[EN]
+	return s, ok
+}
+
+// T возвращает перевод ключа; неизвестный ключ возвращается как есть
+func T(l Lang, key Key) string {
+	if s, ok := Lookup(l, key); ok {
+		return s
+	}
+	return string(key)
+}
@@ -0,0 +1,43 @@
+package i18n
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromAcceptLanguage(t *testing.T) {
+	cases := []struct {
+		header string
+		want   Lang
+		ok     bool
+	}{
+		{"ru-RU,ru;q=0.9,en-US;q=0.8,en;q=0.7", RU, true},
+		{"de-DE, en;q=0.5, ru;q=0.8", RU, true},
+		{"en-GB", EN, true},
+		{"ru;q=0, en;q=0.1", EN, true},
+		{"de, fr", "", false},
+		{"", "", false},
+	}
+	for _, c := range cases {
+		got, ok := FromAcceptLanguage(c.header)
+		assert.Equal(t, c.ok, ok, c.header)
+		assert.Equal(t, c.want, got, c.header)
+	}
+}
+
+func TestFromContext_Default(t *testing.T) {
+	assert.Equal(t, Default(), FromContext(context.Background()))
+	assert.Equal(t, RU, FromContext(WithLang(context.Background(), RU)))
+}
+
+func TestCatalog_Complete(t *testing.T) {
+	for key, tr := range catalog {
+		for _, l := range Supported {
+			assert.NotEmpty(t, tr[l], "нет перевода %s для %s", key, l)
+		}
+	}
+	assert.Equal(t, "ТИР успешно запущен", T(RU, MsgTirStarted))
+	assert.Equal(t, "unknown_key", T(RU, "unknown_key"))
+}
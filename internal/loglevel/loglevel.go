@@ -0,0 +1,237 @@
+// Package loglevel хранит уровни логирования, которые можно менять на лету:
+// глобальный и переопределения для отдельных модулей (auth, admin, system,
+// audit). Изменение может быть временным — по истечении TTL уровень
+// возвращается к последнему постоянному значению.
+package loglevel
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Modules — модули, для которых допускается отдельный уровень
+var Modules = []string{"admin", "audit", "auth", "system"}
+
+// ErrUnknownModule — модуль не входит в Modules
+var ErrUnknownModule = errors.New("unknown module")
+
+// Override — текущий уровень и момент автоматического отката (если задан)
+type Override struct {
+	Level     string     `json:"level"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// State — снимок уровней для API
+type State struct {
+	Default string              `json:"default"` // уровень из -log-level
+	Global  Override            `json:"global"`
+	Modules map[string]Override `json:"modules"`
+}
+
+type entry struct {
+	level   zerolog.Level
+	base    zerolog.Level // куда откатиться по TTL; NoLevel — снять переопределение
+	expires time.Time
+	timer   *time.Timer
+}
+
+var (
+	mu      sync.RWMutex
+	def     = zerolog.InfoLevel
+	global  = &entry{level: zerolog.InfoLevel, base: zerolog.InfoLevel}
+	modules = map[string]*entry{}
+)
+
+// Init задаёт стартовый уровень и сбрасывает все переопределения
+func Init(level zerolog.Level) {
+	mu.Lock()
+	defer mu.Unlock()
+	stop(global)
+	for _, e := range modules {
+		stop(e)
+	}
+	def = level
+	global = &entry{level: level, base: level}
+	modules = map[string]*entry{}
+}
+
+// Global возвращает текущий глобальный уровень
+func Global() zerolog.Level {
+	mu.RLock()
+	defer mu.RUnlock()
+	return global.level
+}
+
+// For возвращает уровень модуля: переопределение или глобальный
+func For(module string) zerolog.Level {
+	mu.RLock()
+	defer mu.RUnlock()
+	if e, ok := modules[module]; ok {
+		return e.level
+	}
+	return global.level
+}
+
+// Logger — глобальный логгер модуля с текущим уровнем. Для кода, у которого
+// нет запроса; в обработчиках используется middleware.Log.
+func Logger(module string) zerolog.Logger {
+	return log.Logger.With().Str("module", module).Logger().Level(For(module))
+}
+
+// ParseLevel разбирает уровень из API; допускаются trace..error
+func ParseLevel(s string) (zerolog.Level, error) {
+	level, err := zerolog.ParseLevel(s)
+	if err != nil || s == "" || level > zerolog.ErrorLevel {
+		return zerolog.NoLevel, errors.New("invalid level")
+	}
+	return level, nil
+}
+
+func knownModule(module string) bool {
+	for _, m := range Modules {
+		if m == module {
+			return true
+		}
+	}
+	return false
+}
+
+// Set меняет уровень модуля (module == "" — глобальный). При ttl > 0 уровень
+// откатится к последнему постоянному значению через ttl.
+func Set(module string, level zerolog.Level, ttl time.Duration) error {
+	if module != "" && !knownModule(module) {
+		return ErrUnknownModule
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	cur := global
+	if module != "" {
+		cur = modules[module]
+	}
+
+	next := &entry{level: level, base: level}
+	if ttl > 0 {
+		next.base = zerolog.NoLevel
+		if cur != nil {
+			next.base = cur.base
+		}
+		next.expires = time.Now().Add(ttl)
+		next.timer = time.AfterFunc(ttl, func() { revert(module, next) })
+	}
+
+	if cur != nil {
+		stop(cur)
+	}
+	if module == "" {
+		global = next
+	} else {
+		modules[module] = next
+	}
+	return nil
+}
+
+// Reset снимает переопределение модуля; для module == "" возвращает
+// глобальный уровень к стартовому.
+func Reset(module string) error {
+	if module != "" && !knownModule(module) {
+		return ErrUnknownModule
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if module == "" {
+		stop(global)
+		global = &entry{level: def, base: def}
+		return nil
+	}
+	if e, ok := modules[module]; ok {
+		stop(e)
+		delete(modules, module)
+	}
+	return nil
+}
+
+// revert вызывается по таймеру; если уровень уже сменили, ничего не делает
+func revert(module string, e *entry) {
+	mu.Lock()
+	if module == "" {
+		if global != e {
+			mu.Unlock()
+			return
+		}
+		global = &entry{level: e.base, base: e.base}
+	} else {
+		if modules[module] != e {
+			mu.Unlock()
+			return
+		}
+		if e.base == zerolog.NoLevel {
+			delete(modules, module)
+		} else {
+			modules[module] = &entry{level: e.base, base: e.base}
+		}
+	}
+	mu.Unlock()
+
+	name := module
+	if name == "" {
+		name = "global"
+	}
+	logger := Logger("system")
+	logger.Info().Str("target", name).Str("from", e.level.String()).Msg("Log level reverted after TTL")
+}
+
+func stop(e *entry) {
+	if e != nil && e.timer != nil {
+		e.timer.Stop()
+	}
+}
+
+// Snapshot возвращает текущие уровни
+func Snapshot() State {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	st := State{
+		Default: def.String(),
+		Global:  override(global),
+		Modules: make(map[string]Override, len(modules)),
+	}
+	for name, e := range modules {
+		st.Modules[name] = override(e)
+	}
+	return st
+}
+
+// Current возвращает уровень модуля (module == "" — глобальный) в виде Override;
+// для модуля без переопределения — nil.
+func Current(module string) *Override {
+	mu.RLock()
+	defer mu.RUnlock()
+	if module == "" {
+		o := override(global)
+		return &o
+	}
+	e, ok := modules[module]
+	if !ok {
+		return nil
+	}
+	o := override(e)
+	return &o
+}
+
+func override(e *entry) Override {
+	o := Override{Level: e.level.String()}
+	if !e.expires.IsZero() {
+		t := e.expires.UTC()
+		o.ExpiresAt = &t
+	}
+	return o
+}
@@ -0,0 +1,74 @@
+package loglevel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFor_ModuleOverridesGlobal(t *testing.T) {
+	Init(zerolog.InfoLevel)
+	defer Init(zerolog.InfoLevel)
+
+	assert.NoError(t, Set("auth", zerolog.DebugLevel, 0))
+	assert.Equal(t, zerolog.DebugLevel, For("auth"))
+	assert.Equal(t, zerolog.InfoLevel, For("admin"))
+
+	assert.NoError(t, Set("", zerolog.WarnLevel, 0))
+	assert.Equal(t, zerolog.WarnLevel, For("admin"))
+	assert.Equal(t, zerolog.DebugLevel, For("auth"))
+
+	assert.NoError(t, Reset("auth"))
+	assert.Equal(t, zerolog.WarnLevel, For("auth"))
+	assert.NoError(t, Reset(""))
+	assert.Equal(t, zerolog.InfoLevel, Global())
+}
+
+func TestSet_UnknownModule(t *testing.T) {
+	assert.ErrorIs(t, Set("tir", zerolog.DebugLevel, 0), ErrUnknownModule)
+	assert.ErrorIs(t, Reset("tir"), ErrUnknownModule)
+}
+
+func TestSet_RevertsAfterTTL(t *testing.T) {
+	Init(zerolog.InfoLevel)
+	defer Init(zerolog.InfoLevel)
+
+	// постоянное значение модуля, затем временное поверх него
+	assert.NoError(t, Set("system", zerolog.WarnLevel, 0))
+	assert.NoError(t, Set("system", zerolog.TraceLevel, 20*time.Millisecond))
+	assert.NoError(t, Set("auth", zerolog.DebugLevel, 20*time.Millisecond))
+	assert.NoError(t, Set("", zerolog.DebugLevel, 20*time.Millisecond))
+
+	st := Snapshot()
+	assert.Equal(t, "trace", st.Modules["system"].Level)
+	assert.NotNil(t, st.Modules["system"].ExpiresAt)
+	assert.NotNil(t, st.Global.ExpiresAt)
+
+	assert.Eventually(t, func() bool {
+		return For("system") == zerolog.WarnLevel && Current("auth") == nil && Global() == zerolog.InfoLevel
+	}, time.Second, 5*time.Millisecond)
+	assert.Nil(t, Snapshot().Global.ExpiresAt)
+}
+
+func TestSet_NewerChangeCancelsRevert(t *testing.T) {
+	Init(zerolog.InfoLevel)
+	defer Init(zerolog.InfoLevel)
+
+	assert.NoError(t, Set("auth", zerolog.DebugLevel, 10*time.Millisecond))
+	assert.NoError(t, Set("auth", zerolog.ErrorLevel, 0))
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, zerolog.ErrorLevel, For("auth"))
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("debug")
+	assert.NoError(t, err)
+	assert.Equal(t, zerolog.DebugLevel, level)
+
+	for _, s := range []string{"", "fatal", "disabled", "verbose"} {
+		_, err := ParseLevel(s)
+		assert.Error(t, err, s)
+	}
+}
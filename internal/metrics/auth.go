@@ -0,0 +1,20 @@
+package metrics
+
+// Результаты входа для метки result
+const (
+	LoginSuccess   = "success"
+	LoginFailure   = "failure"    // неверный пароль, неизвестный пользователь или код MFA
+	LoginLockedOut = "locked_out" // отказ блокировкой перебора
+	LoginDenied    = "denied"     // учётная запись неактивна или без роли
+	LoginMFA       = "mfa_required"
+	LoginError     = "error"
+)
+
+var (
+	// LoginAttempts — попытки входа по результату
+	LoginAttempts = NewCounterVec("rim_auth_login_total",
+		"Login attempts by result.", "result")
+	// RefreshRotations — обновления refresh-токена: rotated или rejected
+	RefreshRotations = NewCounterVec("rim_auth_refresh_total",
+		"Refresh token rotations by result.", "result")
+)
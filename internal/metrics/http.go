@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+var (
+	httpRequests = NewCounterVec("rim_http_requests_total",
+		"HTTP requests by chi route pattern, method and status.", "route", "method", "status")
+	httpDuration = NewHistogramVec("rim_http_request_duration_seconds",
+		"HTTP request latency by chi route pattern, method and status.", DefBuckets, "route", "method", "status")
+)
+
+// Middleware считает запросы и их длительность. Метка route — шаблон chi
+// (/api/v2/admin/users/{id}), а не путь, чтобы число рядов не росло.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := strconv.Itoa(ww.Status())
+		httpRequests.Inc(route, r.Method, status)
+		httpDuration.Observe(time.Since(start).Seconds(), route, r.Method, status)
+	})
+}
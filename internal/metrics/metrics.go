@@ -0,0 +1,289 @@
+// Package metrics — метрики сервиса в текстовом формате Prometheus.
+//
+// Сторонний клиент не используется: на устройстве хватает счётчиков,
+// гистограмм и значений, вычисляемых в момент опроса.
+package metrics
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"rim-router-service-ver-cgo/internal/apierr"
+)
+
+// Collector выводит свои метрики в текстовом формате
+type Collector interface {
+	Write(w io.Writer)
+}
+
+// Registry — набор метрик, отдаваемых на /metrics
+type Registry struct {
+	mu         sync.Mutex
+	collectors []Collector
+}
+
+// Default — реестр сервиса
+var Default = &Registry{}
+
+// Register добавляет метрику в реестр
+func (r *Registry) Register(c Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// Write выводит все метрики реестра
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	collectors := append([]Collector(nil), r.collectors...)
+	r.mu.Unlock()
+	for _, c := range collectors {
+		c.Write(w)
+	}
+}
+
+// Handler отдаёт метрики реестра
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.Write(w)
+	})
+}
+
+// =============================
+//   Счётчики
+// =============================
+
+// CounterVec — монотонный счётчик с метками
+type CounterVec struct {
+	name, help string
+	labels     []string
+
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// NewCounterVec создаёт счётчик и регистрирует его в Default
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{name: name, help: help, labels: labels, values: map[string]float64{}}
+	Default.Register(c)
+	return c
+}
+
+// Inc увеличивает счётчик на 1
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add увеличивает счётчик на v
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	key := labelKey(labelValues)
+	c.mu.Lock()
+	c.values[key] += v
+	c.mu.Unlock()
+}
+
+// Value — текущее значение (для тестов и диагностики)
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[labelKey(labelValues)]
+}
+
+func (c *CounterVec) Write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	writeHeader(w, c.name, c.help, "counter")
+	for _, key := range sortedKeys(c.values) {
+		writeSample(w, c.name, c.labels, splitKey(key), "", "", c.values[key])
+	}
+}
+
+// =============================
+//   Гистограммы
+// =============================
+
+// DefBuckets — границы по умолчанию для длительности запросов, секунды
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type histogram struct {
+	counts []uint64 // по корзинам, не накопительно
+	sum    float64
+	count  uint64
+}
+
+// HistogramVec — гистограмма с метками
+type HistogramVec struct {
+	name, help string
+	labels     []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	values map[string]*histogram
+}
+
+// NewHistogramVec создаёт гистограмму и регистрирует её в Default
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{name: name, help: help, labels: labels, buckets: buckets, values: map[string]*histogram{}}
+	Default.Register(h)
+	return h
+}
+
+// Observe добавляет наблюдение
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	key := labelKey(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hist, ok := h.values[key]
+	if !ok {
+		hist = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.values[key] = hist
+	}
+	for i, b := range h.buckets {
+		if v <= b {
+			hist.counts[i]++
+			break
+		}
+	}
+	hist.sum += v
+	hist.count++
+}
+
+func (h *HistogramVec) Write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	writeHeader(w, h.name, h.help, "histogram")
+	for _, key := range sortedKeys(h.values) {
+		hist := h.values[key]
+		values := splitKey(key)
+		var cumulative uint64
+		for i, b := range h.buckets {
+			cumulative += hist.counts[i]
+			writeSample(w, h.name+"_bucket", h.labels, values, "le", formatFloat(b), float64(cumulative))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, values, "le", "+Inf", float64(hist.count))
+		writeSample(w, h.name+"_sum", h.labels, values, "", "", hist.sum)
+		writeSample(w, h.name+"_count", h.labels, values, "", "", float64(hist.count))
+	}
+}
+
+// =============================
+//   Значения на момент опроса
+// =============================
+
+// Emit передаёт одно значение метрики с метками
+type Emit func(value float64, labelValues ...string)
+
+// GaugeFunc — метрика, значения которой вычисляются при каждом опросе
+type GaugeFunc struct {
+	name, help, kind string
+	labels           []string
+	collect          func(emit Emit)
+}
+
+// NewGaugeFunc регистрирует gauge, вычисляемый при опросе
+func NewGaugeFunc(name, help string, labels []string, collect func(emit Emit)) *GaugeFunc {
+	return newFunc(name, help, "gauge", labels, collect)
+}
+
+// NewCounterFunc регистрирует счётчик, который ведётся в другом пакете
+func NewCounterFunc(name, help string, labels []string, collect func(emit Emit)) *GaugeFunc {
+	return newFunc(name, help, "counter", labels, collect)
+}
+
+func newFunc(name, help, kind string, labels []string, collect func(emit Emit)) *GaugeFunc {
+	g := &GaugeFunc{name: name, help: help, kind: kind, labels: labels, collect: collect}
+	Default.Register(g)
+	return g
+}
+
+func (g *GaugeFunc) Write(w io.Writer) {
+	writeHeader(w, g.name, g.help, g.kind)
+	g.collect(func(value float64, labelValues ...string) {
+		writeSample(w, g.name, g.labels, labelValues, "", "", value)
+	})
+}
+
+// =============================
+//   Формат вывода
+// =============================
+
+const keySep = "\xff"
+
+func labelKey(values []string) string { return strings.Join(values, keySep) }
+
+func splitKey(key string) []string {
+	if key == "" {
+		return nil
+	}
+	return strings.Split(key, keySep)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeHeader(w io.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func writeSample(w io.Writer, name string, labels, values []string, extraLabel, extraValue string, v float64) {
+	var pairs []string
+	for i, l := range labels {
+		val := ""
+		if i < len(values) {
+			val = values[i]
+		}
+		pairs = append(pairs, l+`="`+escapeLabel(val)+`"`)
+	}
+	if extraLabel != "" {
+		pairs = append(pairs, extraLabel+`="`+extraValue+`"`)
+	}
+	if len(pairs) > 0 {
+		name += "{" + strings.Join(pairs, ",") + "}"
+	}
+	fmt.Fprintf(w, "%s %s\n", name, formatFloat(v))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Handler отдаёт метрики Default; при непустом token требует его в
+// Authorization: Bearer (bearer_token в конфигурации scrape).
+func Handler(token string) http.Handler {
+	h := Default.Handler()
+	if token == "" {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			apierr.Write(w, r, apierr.New(http.StatusUnauthorized, apierr.CodeInvalidToken, "Invalid metrics token"))
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
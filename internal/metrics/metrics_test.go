@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func render(c Collector) string {
+	var b strings.Builder
+	c.Write(&b)
+	return b.String()
+}
+
+func TestCounterVec_Format(t *testing.T) {
+	c := &CounterVec{name: "test_total", help: "Test counter.", labels: []string{"result"}, values: map[string]float64{}}
+	c.Inc("ok")
+	c.Add(2, "ok")
+	c.Inc(`with "quote"`)
+
+	out := render(c)
+	assert.Contains(t, out, "# TYPE test_total counter\n")
+	assert.Contains(t, out, `test_total{result="ok"} 3`+"\n")
+	assert.Contains(t, out, `test_total{result="with \"quote\""} 1`+"\n")
+}
+
+func TestHistogramVec_CumulativeBuckets(t *testing.T) {
+	h := &HistogramVec{name: "lat_seconds", help: "Latency.", labels: []string{"route"},
+		buckets: []float64{0.1, 1}, values: map[string]*histogram{}}
+	h.Observe(0.05, "/a")
+	h.Observe(0.5, "/a")
+	h.Observe(3, "/a")
+
+	out := render(h)
+	assert.Contains(t, out, `lat_seconds_bucket{route="/a",le="0.1"} 1`)
+	assert.Contains(t, out, `lat_seconds_bucket{route="/a",le="1"} 2`)
+	assert.Contains(t, out, `lat_seconds_bucket{route="/a",le="+Inf"} 3`)
+	assert.Contains(t, out, `lat_seconds_sum{route="/a"} 3.55`)
+	assert.Contains(t, out, `lat_seconds_count{route="/a"} 3`)
+}
+
+func TestGaugeFunc_CollectsOnScrape(t *testing.T) {
+	value := 1.0
+	g := &GaugeFunc{name: "tir_running", help: "TIR.", kind: "gauge", collect: func(emit Emit) { emit(value) }}
+
+	assert.Contains(t, render(g), "tir_running 1\n")
+	value = 0
+	assert.Contains(t, render(g), "tir_running 0\n")
+}
+
+func TestMiddleware_LabelsByRoutePattern(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+
+	before := httpRequests.Value("/users/{id}", http.MethodGet, "204")
+	for _, path := range []string{"/users/1", "/users/2"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nope", nil))
+
+	assert.Equal(t, before+2, httpRequests.Value("/users/{id}", http.MethodGet, "204"))
+	assert.Equal(t, float64(1), httpRequests.Value("unmatched", http.MethodGet, "404"))
+}
+
+func TestHandler_Token(t *testing.T) {
+	h := Handler("secret")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "version=0.0.4")
+	assert.Contains(t, w.Body.String(), "# TYPE rim_http_requests_total counter")
+}
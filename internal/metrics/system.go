@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"database/sql"
+	"syscall"
+
+	"rim-router-service-ver-cgo/internal/middleware"
+	"rim-router-service-ver-cgo/internal/utils"
+)
+
+// RegisterSystemCollectors регистрирует метрики, которые снимаются при опросе:
+// запись логов, свободное место в корнях логов, пул SQLite, ограничители
+// запросов и состояние ТИР.
+func RegisterSystemCollectors(db *sql.DB, tirRunning func() bool) {
+	NewCounterFunc("rim_log_bytes_written_total", "Bytes written to api.log.", nil, func(emit Emit) {
+		emit(float64(utils.GetLogWriterStats().BytesWritten))
+	})
+	NewCounterFunc("rim_log_rotations_total", "api.log rotations.", nil, func(emit Emit) {
+		emit(float64(utils.GetLogWriterStats().Rotations))
+	})
+	NewCounterFunc("rim_log_dropped_writes_total", "Log writes skipped because of low disk space.", nil, func(emit Emit) {
+		emit(float64(utils.GetLogWriterStats().DroppedWrites))
+	})
+
+	NewGaugeFunc("rim_log_root_free_bytes", "Free disk space available to each log root.", []string{"root"}, func(emit Emit) {
+		for _, root := range utils.ListRoots() {
+			var st syscall.Statfs_t
+			if err := syscall.Statfs(root.Path, &st); err != nil {
+				continue
+			}
+			emit(float64(st.Bavail)*float64(st.Bsize), root.ID)
+		}
+	})
+
+	if db != nil {
+		NewGaugeFunc("rim_db_open_connections", "Open SQLite connections by state.", []string{"state"}, func(emit Emit) {
+			s := db.Stats()
+			emit(float64(s.InUse), "in_use")
+			emit(float64(s.Idle), "idle")
+		})
+		NewCounterFunc("rim_db_wait_count_total", "Waits for a free SQLite connection.", nil, func(emit Emit) {
+			emit(float64(db.Stats().WaitCount))
+		})
+		NewCounterFunc("rim_db_wait_seconds_total", "Time spent waiting for a free SQLite connection.", nil, func(emit Emit) {
+			emit(db.Stats().WaitDuration.Seconds())
+		})
+	}
+
+	NewCounterFunc("rim_ratelimit_requests_total", "Requests seen by rate limiters by decision.", []string{"limiter", "decision"}, func(emit Emit) {
+		for _, s := range middleware.RateLimitStats() {
+			emit(float64(s.Allowed), s.Name, "allowed")
+			emit(float64(s.Limited), s.Name, "limited")
+		}
+	})
+
+	if tirRunning != nil {
+		NewGaugeFunc("rim_tir_running", "1 when the TIR process is running.", nil, func(emit Emit) {
+			if tirRunning() {
+				emit(1)
+			} else {
+				emit(0)
+			}
+		})
+	}
+}
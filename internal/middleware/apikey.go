@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/apierr"
+	"rim-router-service-ver-cgo/internal/models"
+	"rim-router-service-ver-cgo/internal/utils"
+)
+
+// APIKeyHeader — заголовок с API-ключом. Также принимается Authorization: ApiKey <key>.
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyTouchInterval — как часто обновлять last_used_at, чтобы не писать в БД на каждый запрос.
+const apiKeyTouchInterval = time.Minute
+
+// APIKeyStore — хранилище ключей (в приложении — *models.APIKeyRepository).
+type APIKeyStore interface {
+	GetAPIKey(key string) (*models.APIKey, error)
+	TouchAPIKey(id int64, at time.Time) error
+}
+
+type apiKeyAuth struct {
+	keys  APIKeyStore
+	users UserLookup
+}
+
+var (
+	apiKeysMu sync.RWMutex
+	apiKeys   *apiKeyAuth // nil — вход по API-ключам выключен
+)
+
+// EnableAPIKeys разрешает AuthMiddleware принимать API-ключи. Ключ действует
+// от имени владельца с его текущей ролью, но только в пределах своих областей.
+func EnableAPIKeys(keys APIKeyStore, users UserLookup) {
+	apiKeysMu.Lock()
+	defer apiKeysMu.Unlock()
+	if keys == nil || users == nil {
+		apiKeys = nil
+		return
+	}
+	apiKeys = &apiKeyAuth{keys: keys, users: users}
+}
+
+func currentAPIKeys() *apiKeyAuth {
+	apiKeysMu.RLock()
+	defer apiKeysMu.RUnlock()
+	return apiKeys
+}
+
+// apiKeyFromRequest достаёт ключ из X-API-Key или Authorization: ApiKey <key>.
+func apiKeyFromRequest(r *http.Request) (string, bool) {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return key, true
+	}
+	parts := strings.Split(r.Header.Get("Authorization"), " ")
+	if len(parts) == 2 && parts[0] == "ApiKey" {
+		return parts[1], true
+	}
+	return "", false
+}
+
+// authenticateAPIKey проверяет ключ и строит по нему claims владельца.
+// При ошибке ответ уже записан и возвращается nil.
+func authenticateAPIKey(w http.ResponseWriter, r *http.Request, key string) *utils.Claims {
+	auth := currentAPIKeys()
+	if auth == nil {
+		apierr.Write(w, r, apierr.New(http.StatusUnauthorized, apierr.CodeFeatureDisabled, "API keys are disabled"))
+		return nil
+	}
+
+	now := time.Now()
+	k, err := auth.keys.GetAPIKey(key)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !k.Active(now)) {
+		apierr.Write(w, r, apierr.New(http.StatusUnauthorized, apierr.CodeInvalidAPIKey, "Invalid API key"))
+		return nil
+	}
+	if err != nil {
+		apierr.Write(w, r, apierr.ErrDatabase)
+		return nil
+	}
+
+	owner, err := auth.users(k.UserID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && owner.Status != models.UserStatusActive) {
+		apierr.Write(w, r, apierr.New(http.StatusUnauthorized, apierr.CodeInvalidAPIKey, "Invalid API key"))
+		return nil
+	}
+	if err != nil {
+		apierr.Write(w, r, apierr.ErrDatabase)
+		return nil
+	}
+
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= apiKeyTouchInterval {
+		if err := auth.keys.TouchAPIKey(k.ID, now); err != nil {
+			logger := Log(r, "auth")
+			(&logger).Warn().Err(err).Int64("api_key_id", k.ID).Msg("Failed to update API key usage")
+		}
+	}
+
+	return &utils.Claims{
+		UserID:             owner.ID,
+		Username:           owner.Username,
+		Role:               owner.Role,
+		MustChangePassword: owner.MustChangePassword,
+		Locale:             owner.Locale,
+		APIKeyID:           k.ID,
+		Scopes:             k.Scopes,
+	}
+}
+
+// RejectAPIKeys закрывает маршрут для API-ключей: настройки учётной записи
+// меняются только из сессии самого пользователя.
+func RejectAPIKeys(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := apiKeyFromRequest(r); ok {
+			apierr.Write(w, r, apierr.New(http.StatusUnauthorized, apierr.CodeAPIKeyNotAllowed, "API keys are not accepted here"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireScope ограничивает маршрут для API-ключей: ключ должен иметь область scope.
+// Запросы с access-токеном пользователя проходят без проверки.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := GetUserFromContext(r.Context())
+			if claims == nil {
+				apierr.Write(w, r, apierr.ErrAuthRequired)
+				return
+			}
+			if claims.APIKeyID != 0 && !models.HasScope(claims.Scopes, scope) {
+				apierr.Write(w, r, apierr.New(http.StatusForbidden, apierr.CodeInsufficientScope, "API key lacks required scope"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memAPIKeys — хранилище ключей в памяти вместо SQLite
+type memAPIKeys struct {
+	keys    map[string]models.APIKey
+	touched []int64
+}
+
+func (s *memAPIKeys) GetAPIKey(key string) (*models.APIKey, error) {
+	k, ok := s.keys[key]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &k, nil
+}
+
+func (s *memAPIKeys) TouchAPIKey(id int64, at time.Time) error {
+	s.touched = append(s.touched, id)
+	return nil
+}
+
+func withAPIKeys(t *testing.T, keys map[string]models.APIKey) *memAPIKeys {
+	store := &memAPIKeys{keys: keys}
+	owner := models.User{ID: 1, Username: "admin", Role: 1, Status: models.UserStatusActive}
+	EnableAPIKeys(store, func(id int64) (*models.User, error) {
+		if id != owner.ID {
+			return nil, sql.ErrNoRows
+		}
+		return &owner, nil
+	})
+	t.Cleanup(func() { EnableAPIKeys(nil, nil) })
+	return store
+}
+
+func TestAPIKey_HeaderAndScheme(t *testing.T) {
+	store := withAPIKeys(t, map[string]models.APIKey{
+		"rim_good": {ID: 5, UserID: 1, Scopes: []string{models.ScopeLogsRead}},
+	})
+
+	var gotID int64
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotID = GetUserFromContext(r.Context()).APIKeyID
+	})
+	chain := AuthMiddleware(RoleMiddleware(1)(RequireScope(models.ScopeLogsRead)(handler)))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(APIKeyHeader, "rim_good")
+	w := httptest.NewRecorder()
+	chain.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, int64(5), gotID)
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "ApiKey rim_good")
+	w = httptest.NewRecorder()
+	chain.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, []int64{5, 5}, store.touched)
+}
+
+func TestAPIKey_InsufficientScope(t *testing.T) {
+	withAPIKeys(t, map[string]models.APIKey{
+		"rim_logs": {ID: 5, UserID: 1, Scopes: []string{models.ScopeLogsRead}},
+	})
+
+	handler, called := makeHandlerCalledFlag()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(APIKeyHeader, "rim_logs")
+	w := httptest.NewRecorder()
+	AuthMiddleware(RequireScope(models.ScopeAdmin)(handler)).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "insufficient_scope")
+	assert.False(t, *called)
+}
+
+func TestAPIKey_RejectsUnknownRevokedAndExpired(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	withAPIKeys(t, map[string]models.APIKey{
+		"rim_revoked": {ID: 6, UserID: 1, RevokedAt: &past},
+		"rim_expired": {ID: 7, UserID: 1, ExpiresAt: &past},
+		"rim_orphan":  {ID: 8, UserID: 2},
+	})
+
+	handler, called := makeHandlerCalledFlag()
+	for _, key := range []string{"rim_unknown", "rim_revoked", "rim_expired", "rim_orphan"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(APIKeyHeader, key)
+		w := httptest.NewRecorder()
+		AuthMiddleware(handler).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code, key)
+		assert.Contains(t, w.Body.String(), "invalid_api_key", key)
+	}
+	assert.False(t, *called)
+}
+
+func TestAPIKey_OwnerMustChangePassword(t *testing.T) {
+	store := &memAPIKeys{keys: map[string]models.APIKey{
+		"rim_good": {ID: 5, UserID: 1, Scopes: []string{models.ScopeLogsRead}},
+	}}
+	EnableAPIKeys(store, func(id int64) (*models.User, error) {
+		return &models.User{ID: id, Username: "admin", Role: 1, Status: models.UserStatusActive, MustChangePassword: true}, nil
+	})
+	defer EnableAPIKeys(nil, nil)
+
+	handler, called := makeHandlerCalledFlag()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(APIKeyHeader, "rim_good")
+	w := httptest.NewRecorder()
+	AuthMiddleware(RequireScope(models.ScopeLogsRead)(handler)).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "password_change_required")
+	assert.False(t, *called)
+}
+
+func TestAPIKey_CannotChangePassword(t *testing.T) {
+	withAPIKeys(t, map[string]models.APIKey{"rim_good": {ID: 5, UserID: 1}})
+
+	handler, called := makeHandlerCalledFlag()
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set(APIKeyHeader, "rim_good")
+	w := httptest.NewRecorder()
+	PasswordChangeAuthMiddleware(handler).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.False(t, *called)
+}
+
+func TestRejectAPIKeys(t *testing.T) {
+	withAPIKeys(t, map[string]models.APIKey{
+		"rim_logs": {ID: 5, UserID: 1, Scopes: []string{models.ScopeLogsRead}},
+	})
+
+	handler, called := makeHandlerCalledFlag()
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/locale", nil)
+	req.Header.Set(APIKeyHeader, "rim_logs")
+	w := httptest.NewRecorder()
+	RejectAPIKeys(AuthMiddleware(handler)).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "api_key_not_allowed")
+	assert.False(t, *called)
+}
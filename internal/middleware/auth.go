@@ -2,9 +2,11 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 
+	"rim-router-service-ver-cgo/internal/apierr"
 	"rim-router-service-ver-cgo/internal/utils"
 )
 
@@ -14,33 +16,102 @@ const (
 	UserContextKey contextKey = "user"
 )
 
-// AuthMiddleware проверяет JWT токен
+// AuthMiddleware проверяет JWT токен или API-ключ. Пока пользователь обязан сменить
+// пароль, запрос отклоняется с кодом password_change_required.
 func AuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, `{"code": 401, "message": "Authorization header required"}`, http.StatusUnauthorized)
-			return
+	return authenticate(next, false)
+}
+
+// PasswordChangeAuthMiddleware — как AuthMiddleware, но пропускает
+// пользователей с обязательной сменой пароля (только для её эндпоинта).
+func PasswordChangeAuthMiddleware(next http.Handler) http.Handler {
+	return authenticate(next, true)
+}
+
+// MFAEnrollmentAuthMiddleware — для эндпоинтов подключения TOTP. Кроме
+// access-токена принимает токен подключения, который выдаётся при входе,
+// если MFA для роли обязательна, но ещё не подключена.
+func MFAEnrollmentAuthMiddleware(next http.Handler) http.Handler {
+	auth := AuthMiddleware(next)
+	// подтверждение выдаёт полноценную сессию, поэтому API-ключ здесь не годится
+	return RejectAPIKeys(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(r.Header.Get("Authorization"), " ")
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			if claims, err := utils.ValidateMFAToken(parts[1], utils.TokenPurposeMFAEnroll); err == nil {
+				next.ServeHTTP(w, r.WithContext(withUser(r.Context(), claims)))
+				return
+			}
 		}
+		auth.ServeHTTP(w, r)
+	}))
+}
 
-		// Формат: Bearer <token>
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			http.Error(w, `{"code": 401, "message": "Invalid authorization format"}`, http.StatusUnauthorized)
+func authenticate(next http.Handler, allowPasswordChange bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// API-ключ: владелец уже загружен из БД, повторная проверка не нужна.
+		// Сменить пароль владельца по ключу нельзя, а пока смена обязательна,
+		// ключ не действует.
+		if key, ok := apiKeyFromRequest(r); ok && !allowPasswordChange {
+			claims := authenticateAPIKey(w, r, key)
+			if claims == nil {
+				return
+			}
+			if claims.MustChangePassword {
+				apierr.Write(w, r, apierr.New(http.StatusForbidden, apierr.CodePasswordChangeRequired, "Password change required"))
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(withUser(r.Context(), claims)))
 			return
 		}
 
-		tokenString := parts[1]
+		var tokenString string
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			// Режим cookie-сессий: токен в HttpOnly cookie, браузер шлёт его сам,
+			// поэтому меняющие состояние запросы проверяются на CSRF
+			cookieToken, ok := AccessTokenFromCookie(r)
+			if !ok {
+				apierr.Write(w, r, apierr.ErrAuthRequired.WithMessage("Authorization header required"))
+				return
+			}
+			if err := verifyCSRF(r); err != nil {
+				writeCSRFError(w, r)
+				return
+			}
+			tokenString = cookieToken
+		} else {
+			// Формат: Bearer <token>
+			parts := strings.Split(authHeader, " ")
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				apierr.Write(w, r, apierr.New(http.StatusUnauthorized, apierr.CodeInvalidAuthFormat, "Invalid authorization format"))
+				return
+			}
+			tokenString = parts[1]
+		}
 
 		// ✅ теперь используем хук вместо прямого вызова
 		claims, err := utils.ValidateTokenFunc(tokenString)
+		if errors.Is(err, utils.ErrTokenRevoked) {
+			apierr.Write(w, r, apierr.New(http.StatusUnauthorized, apierr.CodeTokenRevoked, "Token revoked"))
+			return
+		}
 		if err != nil {
-			http.Error(w, `{"code": 401, "message": "Invalid token"}`, http.StatusUnauthorized)
+			apierr.Write(w, r, apierr.New(http.StatusUnauthorized, apierr.CodeInvalidToken, "Invalid token"))
+			return
+		}
+
+		// Режим повторной проверки: роль и состояние берутся из БД
+		claims, ctx := revalidate(w, r, claims)
+		if claims == nil {
+			return
+		}
+
+		if claims.MustChangePassword && !allowPasswordChange {
+			apierr.Write(w, r, apierr.New(http.StatusForbidden, apierr.CodePasswordChangeRequired, "Password change required"))
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), UserContextKey, claims)
-		next.ServeHTTP(w, r.WithContext(ctx))
+		next.ServeHTTP(w, r.WithContext(withUser(ctx, claims)))
 	})
 }
 
@@ -50,12 +121,12 @@ func RoleMiddleware(requiredRole int) func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			claims, ok := r.Context().Value(UserContextKey).(*utils.Claims)
 			if !ok {
-				http.Error(w, `{"code": 401, "message": "Authentication required"}`, http.StatusUnauthorized)
+				apierr.Write(w, r, apierr.ErrAuthRequired)
 				return
 			}
 
 			if claims.Role < requiredRole {
-				http.Error(w, `{"code": 403, "message": "Insufficient permissions"}`, http.StatusForbidden)
+				apierr.Write(w, r, apierr.ErrForbidden)
 				return
 			}
 
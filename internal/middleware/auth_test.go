@@ -37,7 +37,9 @@ func TestAuthMiddleware_NoHeader(t *testing.T) {
 	middleware.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
 	assert.Contains(t, w.Body.String(), "Authorization header required")
+	assert.Contains(t, w.Body.String(), `"error":"auth_required"`)
 }
 
 func TestAuthMiddleware_InvalidFormat(t *testing.T) {
@@ -158,3 +160,49 @@ func TestGetUserFromContext(t *testing.T) {
 		assert.Nil(t, got)
 	})
 }
+
+func TestAuthMiddleware_PasswordChangeRequired(t *testing.T) {
+	oldValidate := utils.ValidateTokenFunc
+	defer func() { utils.ValidateTokenFunc = oldValidate }()
+
+	utils.ValidateTokenFunc = func(token string) (*utils.Claims, error) {
+		return &utils.Claims{UserID: 1, Username: "admin", Role: 1, MustChangePassword: true}, nil
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer valid_token")
+
+	handler, called := makeHandlerCalledFlag()
+	w := httptest.NewRecorder()
+	AuthMiddleware(handler).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "password_change_required")
+	assert.False(t, *called)
+
+	w = httptest.NewRecorder()
+	PasswordChangeAuthMiddleware(handler).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, *called)
+}
+
+func TestAuthMiddleware_RevokedToken(t *testing.T) {
+	oldValidate := utils.ValidateTokenFunc
+	defer func() { utils.ValidateTokenFunc = oldValidate }()
+
+	utils.ValidateTokenFunc = func(token string) (*utils.Claims, error) {
+		return nil, utils.ErrTokenRevoked
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer revoked_token")
+
+	handler, called := makeHandlerCalledFlag()
+	w := httptest.NewRecorder()
+	AuthMiddleware(handler).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "token_revoked")
+	assert.False(t, *called)
+}
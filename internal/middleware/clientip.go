@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+)
+
+var (
+	proxiesMu      sync.RWMutex
+	trustedProxies []netip.Prefix
+)
+
+// SetTrustedProxies задаёт прокси, чьим заголовкам X-Forwarded-For и
+// X-Real-IP можно верить. Пустой список — заголовки игнорируются.
+func SetTrustedProxies(prefixes []netip.Prefix) {
+	proxiesMu.Lock()
+	defer proxiesMu.Unlock()
+	trustedProxies = append([]netip.Prefix(nil), prefixes...)
+}
+
+func isTrustedProxy(a netip.Addr) bool {
+	proxiesMu.RLock()
+	defer proxiesMu.RUnlock()
+	for _, p := range trustedProxies {
+		if p.Contains(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP возвращает IP клиента для блокировок, лимитов, аудита и логов.
+// Это адрес TCP-соединения; только если соединение пришло от доверенного
+// прокси, берётся X-Forwarded-For (справа налево до первого недоверенного
+// адреса) или X-Real-IP.
+func ClientIP(r *http.Request) string {
+	peer := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(peer); err == nil {
+		peer = host
+	}
+	addr, err := netip.ParseAddr(peer)
+	if err != nil || !isTrustedProxy(addr.Unmap()) {
+		return peer
+	}
+
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				break
+			}
+			hop = hop.Unmap()
+			if !isTrustedProxy(hop) {
+				return hop.String()
+			}
+		}
+	}
+	if ip, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return ip.Unmap().String()
+	}
+	return addr.Unmap().String()
+}
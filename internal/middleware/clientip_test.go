@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIP(t *testing.T) {
+	SetTrustedProxies([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")})
+	t.Cleanup(func() { SetTrustedProxies(nil) })
+
+	cases := []struct {
+		name   string
+		remote string
+		xff    string
+		realIP string
+		want   string
+	}{
+		{"direct client", "192.0.2.7:5000", "", "", "192.0.2.7"},
+		{"spoofed header from untrusted peer", "192.0.2.7:5000", "203.0.113.1", "203.0.113.2", "192.0.2.7"},
+		{"trusted proxy", "10.0.0.1:443", "203.0.113.1", "", "203.0.113.1"},
+		{"client prepends fake hop", "10.0.0.1:443", "198.51.100.9, 203.0.113.1, 10.0.0.5", "", "203.0.113.1"},
+		{"trusted proxy with X-Real-IP", "10.0.0.1:443", "", "203.0.113.3", "203.0.113.3"},
+		{"trusted proxy without headers", "10.0.0.1:443", "", "", "10.0.0.1"},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = c.remote
+		if c.xff != "" {
+			req.Header.Set("X-Forwarded-For", c.xff)
+		}
+		if c.realIP != "" {
+			req.Header.Set("X-Real-IP", c.realIP)
+		}
+		assert.Equal(t, c.want, ClientIP(req), c.name)
+	}
+}
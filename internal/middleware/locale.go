@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+
+	"rim-router-service-ver-cgo/internal/i18n"
+)
+
+// Language выбирает язык сообщений по Accept-Language (иначе — язык по
+// умолчанию) и кладёт его в контекст. Настройка пользователя применяется
+// позже, при аутентификации.
+func Language(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang, ok := i18n.FromAcceptLanguage(r.Header.Get("Accept-Language"))
+		if !ok {
+			lang = i18n.Default()
+		}
+		w.Header().Add("Vary", "Accept-Language")
+		next.ServeHTTP(w, r.WithContext(i18n.WithLang(r.Context(), lang)))
+	})
+}
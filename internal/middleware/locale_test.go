@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"rim-router-service-ver-cgo/internal/i18n"
+	"rim-router-service-ver-cgo/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLanguage_AcceptLanguage(t *testing.T) {
+	var got i18n.Lang
+	handler := Language(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = i18n.FromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/locale", nil)
+	req.Header.Set("Accept-Language", "ru-RU,ru;q=0.9,en;q=0.8")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, i18n.RU, got)
+	assert.Equal(t, "Accept-Language", w.Header().Get("Vary"))
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/locale", nil)
+	req.Header.Set("Accept-Language", "de-DE")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, i18n.Default(), got)
+}
+
+func TestWithUser_LocaleOverridesHeader(t *testing.T) {
+	ctx := i18n.WithLang(context.Background(), i18n.EN)
+
+	ctx = withUser(ctx, &utils.Claims{UserID: 1, Username: "admin", Role: 1, Locale: "ru"})
+	assert.Equal(t, i18n.RU, i18n.FromContext(ctx))
+
+	ctx = withUser(i18n.WithLang(context.Background(), i18n.RU), &utils.Claims{UserID: 2, Username: "op", Role: 2})
+	assert.Equal(t, i18n.RU, i18n.FromContext(ctx))
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/i18n"
+	"rim-router-service-ver-cgo/internal/loglevel"
+	"rim-router-service-ver-cgo/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// RequestIDHeader — заголовок, в котором клиент получает ID запроса
+const RequestIDHeader = "X-Request-ID"
+
+const loggerContextKey contextKey = "logger"
+
+// RequestLogger кладёт в контекст логгер запроса с request_id и remote_ip
+// (после аутентификации к ним добавляются user и role), возвращает ID запроса
+// в X-Request-ID и по завершении пишет строку access-лога. Ставится после
+// chimiddleware.RequestID; remote_ip — адрес из ClientIP.
+func RequestLogger(base zerolog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			reqID := chimiddleware.GetReqID(r.Context())
+			if reqID != "" {
+				w.Header().Set(RequestIDHeader, reqID)
+			}
+
+			logger := base.With().Str("request_id", reqID).Str("remote_ip", ClientIP(r)).Logger()
+			ctx := context.WithValue(r.Context(), loggerContextKey, &logger)
+
+			ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			r = r.WithContext(ctx)
+			next.ServeHTTP(ww, r)
+
+			access := logger.Level(loglevel.Global())
+			access.Info().
+				Str("method", r.Method).
+				Str("url", r.URL.Path).
+				Str("route", routePattern(r)).
+				Int("status", ww.Status()).
+				Int("size", ww.BytesWritten()).
+				Dur("duration", time.Since(start)).
+				Msg("request")
+		})
+	}
+}
+
+// Log возвращает логгер запроса для модуля (auth, admin, system, ...) с
+// шаблоном маршрута и текущим уровнем модуля. Без RequestLogger — глобальный логгер.
+func Log(r *http.Request, module string) zerolog.Logger {
+	return LogFromContext(r.Context(), module).With().Str("route", routePattern(r)).Logger()
+}
+
+// LogFromContext — логгер запроса из контекста, когда *http.Request недоступен
+func LogFromContext(ctx context.Context, module string) zerolog.Logger {
+	base := &log.Logger
+	if l, ok := ctx.Value(loggerContextKey).(*zerolog.Logger); ok {
+		base = l
+	}
+	return base.With().Str("module", module).Logger().Level(loglevel.For(module))
+}
+
+// withUser кладёт пользователя в контекст и дописывает его в логгер запроса,
+// чтобы строка access-лога тоже содержала user и role. Язык из настроек
+// пользователя заменяет выбранный по Accept-Language.
+func withUser(ctx context.Context, claims *utils.Claims) context.Context {
+	if lang, ok := i18n.Parse(claims.Locale); ok {
+		ctx = i18n.WithLang(ctx, lang)
+	}
+	if l, ok := ctx.Value(loggerContextKey).(*zerolog.Logger); ok {
+		l.UpdateContext(func(c zerolog.Context) zerolog.Context {
+			return c.Str("user", claims.Username).Int("role", claims.Role)
+		})
+	}
+	return context.WithValue(ctx, UserContextKey, claims)
+}
+
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+	return ""
+}
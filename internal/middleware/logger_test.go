@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"rim-router-service-ver-cgo/internal/loglevel"
+	"rim-router-service-ver-cgo/internal/utils"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logLines разбирает JSON-строки, записанные zerolog в буфер
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &m))
+		lines = append(lines, m)
+	}
+	return lines
+}
+
+func TestRequestLogger_EchoesRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	handler := chimiddleware.RequestID(RequestLogger(zerolog.New(&buf))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})))
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.Header.Set(chimiddleware.RequestIDHeader, "support-42")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, "support-42", w.Header().Get(RequestIDHeader))
+
+	lines := logLines(t, &buf)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "request", lines[0]["message"])
+	assert.Equal(t, "support-42", lines[0]["request_id"])
+	assert.Equal(t, float64(http.StatusNoContent), lines[0]["status"])
+	assert.NotContains(t, lines[0], "user")
+}
+
+func TestRequestLogger_AddsUserAfterAuth(t *testing.T) {
+	oldValidate := utils.ValidateTokenFunc
+	defer func() { utils.ValidateTokenFunc = oldValidate }()
+	utils.ValidateTokenFunc = func(token string) (*utils.Claims, error) {
+		return &utils.Claims{UserID: 7, Username: "operator", Role: 1}, nil
+	}
+
+	var buf bytes.Buffer
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := Log(r, "system")
+		logger.Info().Msg("handled")
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := chimiddleware.RequestID(RequestLogger(zerolog.New(&buf))(AuthMiddleware(inner)))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/tir/start", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	lines := logLines(t, &buf)
+	require.Len(t, lines, 2)
+	reqID := w.Header().Get(RequestIDHeader)
+	assert.NotEmpty(t, reqID)
+
+	handled, access := lines[0], lines[1]
+	assert.Equal(t, "handled", handled["message"])
+	assert.Equal(t, "system", handled["module"])
+	assert.Equal(t, reqID, handled["request_id"])
+	assert.Equal(t, "operator", handled["user"])
+
+	assert.Equal(t, "request", access["message"])
+	assert.Equal(t, reqID, access["request_id"])
+	assert.Equal(t, "operator", access["user"])
+	assert.Equal(t, float64(1), access["role"])
+}
+
+func TestLogFromContext_FallsBackToGlobal(t *testing.T) {
+	logger := LogFromContext(context.Background(), "auth")
+	// без RequestLogger логгер всё равно пригоден к использованию
+	logger.Debug().Msg("no request logger")
+}
+
+func TestLog_UsesModuleLevel(t *testing.T) {
+	loglevel.Init(zerolog.InfoLevel)
+	defer loglevel.Init(zerolog.InfoLevel)
+	assert.NoError(t, loglevel.Set("auth", zerolog.DebugLevel, 0))
+
+	var buf bytes.Buffer
+	handler := RequestLogger(zerolog.New(&buf))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authLog := Log(r, "auth")
+		authLog.Debug().Msg("auth debug")
+		sysLog := Log(r, "system")
+		sysLog.Debug().Msg("system debug")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Contains(t, buf.String(), "auth debug")
+	assert.NotContains(t, buf.String(), "system debug")
+}
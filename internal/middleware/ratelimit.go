@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/apierr"
+	"rim-router-service-ver-cgo/internal/config"
+)
+
+// idleBucketTTL — через сколько простоя бакет клиента удаляется из памяти.
+const idleBucketTTL = 10 * time.Minute
+
+// KeyFunc возвращает ключ, по которому считается лимит (IP, пользователь).
+type KeyFunc func(r *http.Request) string
+
+// KeyByIP — лимит на IP клиента (см. ClientIP).
+func KeyByIP(r *http.Request) string {
+	return "ip:" + ClientIP(r)
+}
+
+// KeyByUser — лимит на аутентифицированного пользователя, иначе на IP.
+func KeyByUser(r *http.Request) string {
+	if claims := GetUserFromContext(r.Context()); claims != nil {
+		return "user:" + strconv.FormatInt(claims.UserID, 10)
+	}
+	return KeyByIP(r)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter — token bucket на каждый ключ.
+type RateLimiter struct {
+	name    string
+	rate    float64
+	burst   float64
+	keyFunc KeyFunc
+	now     func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+
+	allowed atomic.Uint64
+	limited atomic.Uint64
+}
+
+// RateLimitStat — счётчики одного лимитера.
+type RateLimitStat struct {
+	Name    string  `json:"name"`
+	Rate    float64 `json:"rate"`
+	Burst   int     `json:"burst"`
+	Clients int     `json:"clients"`
+	Allowed uint64  `json:"allowed"`
+	Limited uint64  `json:"limited"`
+}
+
+var (
+	limitersMu sync.Mutex
+	limiters   []*RateLimiter
+)
+
+// NewRateLimiter создаёт лимитер и регистрирует его для RateLimitStats.
+func NewRateLimiter(name string, limit config.RateLimit, keyFunc KeyFunc) *RateLimiter {
+	l := &RateLimiter{
+		name:    name,
+		rate:    limit.Rate,
+		burst:   float64(limit.Burst),
+		keyFunc: keyFunc,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+	if l.burst < 1 {
+		l.burst = 1
+	}
+
+	limitersMu.Lock()
+	limiters = append(limiters, l)
+	limitersMu.Unlock()
+	return l
+}
+
+// Allow списывает токен для ключа. Если токенов нет — возвращает время до следующего.
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	if l.rate <= 0 {
+		return true, 0
+	}
+
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > idleBucketTTL {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		l.allowed.Add(1)
+		return true, 0
+	}
+
+	l.limited.Add(1)
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// sweep удаляет бакеты, которые давно не использовались (они уже полные).
+func (l *RateLimiter) sweep(now time.Time) {
+	for k, b := range l.buckets {
+		if now.Sub(b.last) > idleBucketTTL {
+			delete(l.buckets, k)
+		}
+	}
+	l.lastSweep = now
+}
+
+// Handler — middleware, отвечающее 429 при превышении лимита.
+func (l *RateLimiter) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok, wait := l.Allow(l.keyFunc(r))
+		if !ok {
+			secs := int(math.Ceil(wait.Seconds()))
+			if secs < 1 {
+				secs = 1
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(secs))
+			apierr.Write(w, r, apierr.ErrRateLimited)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Stat возвращает текущие счётчики лимитера.
+func (l *RateLimiter) Stat() RateLimitStat {
+	l.mu.Lock()
+	clients := len(l.buckets)
+	l.mu.Unlock()
+	return RateLimitStat{
+		Name:    l.name,
+		Rate:    l.rate,
+		Burst:   int(l.burst),
+		Clients: clients,
+		Allowed: l.allowed.Load(),
+		Limited: l.limited.Load(),
+	}
+}
+
+// RateLimitStats возвращает счётчики всех зарегистрированных лимитеров.
+func RateLimitStats() []RateLimitStat {
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+
+	out := make([]RateLimitStat, 0, len(limiters))
+	for _, l := range limiters {
+		out = append(out, l.Stat())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
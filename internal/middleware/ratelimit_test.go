@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/config"
+	"rim-router-service-ver-cgo/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter_BurstThenLimited(t *testing.T) {
+	l := NewRateLimiter("test-burst", config.RateLimit{Rate: 1, Burst: 2}, KeyByIP)
+	handler, _ := makeHandlerCalledFlag()
+	limited := l.Handler(handler)
+
+	codes := make([]int, 0, 3)
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+		limited.ServeHTTP(w, req)
+		codes = append(codes, w.Code)
+		if w.Code == http.StatusTooManyRequests {
+			assert.Equal(t, "1", w.Header().Get("Retry-After"))
+		}
+	}
+
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes)
+
+	stat := l.Stat()
+	assert.Equal(t, uint64(2), stat.Allowed)
+	assert.Equal(t, uint64(1), stat.Limited)
+}
+
+func TestRateLimiter_Refill(t *testing.T) {
+	now := time.Now()
+	l := NewRateLimiter("test-refill", config.RateLimit{Rate: 2, Burst: 1}, KeyByIP)
+	l.now = func() time.Time { return now }
+
+	ok, _ := l.Allow("ip:1")
+	assert.True(t, ok)
+	ok, wait := l.Allow("ip:1")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	// другой клиент не затронут
+	ok, _ = l.Allow("ip:2")
+	assert.True(t, ok)
+
+	now = now.Add(500 * time.Millisecond)
+	ok, _ = l.Allow("ip:1")
+	assert.True(t, ok)
+}
+
+func TestRateLimiter_Disabled(t *testing.T) {
+	l := NewRateLimiter("test-disabled", config.RateLimit{Rate: 0, Burst: 0}, KeyByIP)
+	for i := 0; i < 100; i++ {
+		ok, _ := l.Allow("ip:1")
+		assert.True(t, ok)
+	}
+}
+
+func TestKeyByUser(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.Equal(t, "ip:192.0.2.1", KeyByUser(req))
+
+	claims := &utils.Claims{UserID: 7, Username: "bob"}
+	req = req.WithContext(context.WithValue(req.Context(), UserContextKey, claims))
+	assert.Equal(t, "user:7", KeyByUser(req))
+}
+
+func TestRateLimitStats(t *testing.T) {
+	NewRateLimiter("test-stats", config.RateLimit{Rate: 1, Burst: 1}, KeyByIP)
+
+	var names []string
+	for _, s := range RateLimitStats() {
+		names = append(names, s.Name)
+	}
+	assert.Contains(t, names, "test-stats")
+}
@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/apierr"
+	"rim-router-service-ver-cgo/internal/models"
+	"rim-router-service-ver-cgo/internal/utils"
+)
+
+const CurrentUserContextKey contextKey = "current_user"
+
+// UserLookup загружает пользователя по ID (в приложении — UserRepository.GetUserByID).
+type UserLookup func(id int64) (*models.User, error)
+
+type cachedUser struct {
+	user    *models.User // nil — пользователь удалён
+	expires time.Time
+}
+
+// userCache — короткий кэш пользователей, чтобы не ходить в БД на каждый запрос.
+type userCache struct {
+	lookup UserLookup
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[int64]cachedUser
+}
+
+var (
+	revalidationMu sync.RWMutex
+	revalidation   *userCache // nil — режим выключен, доверяем claims
+)
+
+// EnableUserRevalidation включает загрузку пользователя из БД в AuthMiddleware:
+// удалённые и отключённые пользователи отклоняются, роль берётся из БД.
+func EnableUserRevalidation(lookup UserLookup, ttl time.Duration) {
+	revalidationMu.Lock()
+	defer revalidationMu.Unlock()
+	if lookup == nil {
+		revalidation = nil
+		return
+	}
+	revalidation = &userCache{lookup: lookup, ttl: ttl, now: time.Now, entries: map[int64]cachedUser{}}
+}
+
+// InvalidateUser сбрасывает пользователя из кэша, чтобы изменения администратора
+// применились к следующему же запросу.
+func InvalidateUser(id int64) {
+	revalidationMu.RLock()
+	c := revalidation
+	revalidationMu.RUnlock()
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	delete(c.entries, id)
+	c.mu.Unlock()
+}
+
+func currentRevalidation() *userCache {
+	revalidationMu.RLock()
+	defer revalidationMu.RUnlock()
+	return revalidation
+}
+
+func (c *userCache) get(id int64) (*models.User, error) {
+	now := c.now()
+
+	c.mu.Lock()
+	e, ok := c.entries[id]
+	c.mu.Unlock()
+	if ok && now.Before(e.expires) {
+		return e.user, nil
+	}
+
+	user, err := c.lookup(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		user, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[id] = cachedUser{user: user, expires: now.Add(c.ttl)}
+	// удаляем устаревшие записи, чтобы кэш не рос
+	for k, v := range c.entries {
+		if !now.Before(v.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.mu.Unlock()
+	return user, nil
+}
+
+// revalidate сверяет claims с актуальным пользователем. Возвращает claims
+// с ролью и флагом смены пароля из БД или пишет ответ об ошибке и возвращает nil.
+func revalidate(w http.ResponseWriter, r *http.Request, claims *utils.Claims) (*utils.Claims, context.Context) {
+	c := currentRevalidation()
+	if c == nil {
+		return claims, r.Context()
+	}
+
+	user, err := c.get(claims.UserID)
+	if err != nil {
+		apierr.Write(w, r, apierr.ErrDatabase)
+		return nil, nil
+	}
+	if user == nil {
+		apierr.Write(w, r, apierr.New(http.StatusUnauthorized, apierr.CodeUserNotFound, "User not found"))
+		return nil, nil
+	}
+	if user.Status != models.UserStatusActive {
+		apierr.Write(w, r, apierr.New(http.StatusForbidden, apierr.CodeAccountInactive, "Account is not active"))
+		return nil, nil
+	}
+
+	fresh := *claims
+	fresh.Username = user.Username
+	fresh.Role = user.Role
+	fresh.MustChangePassword = user.MustChangePassword
+	fresh.Locale = user.Locale
+
+	u := *user
+	return &fresh, context.WithValue(r.Context(), CurrentUserContextKey, &u)
+}
+
+// GetCurrentUser возвращает пользователя, загруженного из БД. nil, если
+// повторная проверка выключена.
+func GetCurrentUser(ctx context.Context) *models.User {
+	user, ok := ctx.Value(CurrentUserContextKey).(*models.User)
+	if !ok {
+		return nil
+	}
+	return user
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/models"
+	"rim-router-service-ver-cgo/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// withRevalidation включает повторную проверку с заданными пользователями
+// и возвращает счётчик обращений к «БД».
+func withRevalidation(t *testing.T, users map[int64]models.User) *int {
+	calls := 0
+	EnableUserRevalidation(func(id int64) (*models.User, error) {
+		calls++
+		u, ok := users[id]
+		if !ok {
+			return nil, sql.ErrNoRows
+		}
+		return &u, nil
+	}, time.Minute)
+	t.Cleanup(func() { EnableUserRevalidation(nil, 0) })
+
+	oldValidate := utils.ValidateTokenFunc
+	t.Cleanup(func() { utils.ValidateTokenFunc = oldValidate })
+	utils.ValidateTokenFunc = func(token string) (*utils.Claims, error) {
+		return &utils.Claims{UserID: 1, Username: "tester", Role: 1}, nil
+	}
+	return &calls
+}
+
+func authRequest() *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	return req
+}
+
+func TestRevalidation_UsesCurrentRole(t *testing.T) {
+	users := map[int64]models.User{1: {ID: 1, Username: "tester", Role: 0, Status: models.UserStatusActive}}
+	withRevalidation(t, users)
+
+	handler, called := makeHandlerCalledFlag()
+	w := httptest.NewRecorder()
+	AuthMiddleware(RoleMiddleware(1)(handler)).ServeHTTP(w, authRequest())
+
+	assert.Equal(t, http.StatusForbidden, w.Code, "роль из токена устарела, действует роль из БД")
+	assert.False(t, *called)
+}
+
+func TestRevalidation_ExposesCurrentUser(t *testing.T) {
+	users := map[int64]models.User{1: {ID: 1, Username: "tester", Role: 1, Status: models.UserStatusActive}}
+	withRevalidation(t, users)
+
+	var current *models.User
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current = GetCurrentUser(r.Context())
+	})
+	AuthMiddleware(handler).ServeHTTP(httptest.NewRecorder(), authRequest())
+
+	if assert.NotNil(t, current) {
+		assert.Equal(t, int64(1), current.ID)
+		assert.Equal(t, 1, current.Role)
+	}
+}
+
+func TestRevalidation_RejectsDeletedAndInactiveUsers(t *testing.T) {
+	withRevalidation(t, map[int64]models.User{})
+	handler, called := makeHandlerCalledFlag()
+
+	w := httptest.NewRecorder()
+	AuthMiddleware(handler).ServeHTTP(w, authRequest())
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "user_not_found")
+
+	withRevalidation(t, map[int64]models.User{1: {ID: 1, Status: models.UserStatusDisabled}})
+	w = httptest.NewRecorder()
+	AuthMiddleware(handler).ServeHTTP(w, authRequest())
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "account_inactive")
+	assert.False(t, *called)
+}
+
+func TestRevalidation_CachesAndInvalidates(t *testing.T) {
+	users := map[int64]models.User{1: {ID: 1, Role: 1, Status: models.UserStatusActive}}
+	calls := withRevalidation(t, users)
+	handler, _ := makeHandlerCalledFlag()
+
+	AuthMiddleware(handler).ServeHTTP(httptest.NewRecorder(), authRequest())
+	AuthMiddleware(handler).ServeHTTP(httptest.NewRecorder(), authRequest())
+	assert.Equal(t, 1, *calls, "второй запрос обслуживается из кэша")
+
+	InvalidateUser(1)
+	AuthMiddleware(handler).ServeHTTP(httptest.NewRecorder(), authRequest())
+	assert.Equal(t, 2, *calls)
+}
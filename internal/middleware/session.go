@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"rim-router-service-ver-cgo/internal/apierr"
+	"rim-router-service-ver-cgo/internal/config"
+)
+
+// Имена cookie и заголовка режима cookie-сессий
+const (
+	AccessCookieName  = "access_token"
+	RefreshCookieName = "refresh_token"
+	CSRFCookieName    = "csrf_token" // не HttpOnly: интерфейс читает его и повторяет в заголовке
+	CSRFHeader        = "X-CSRF-Token"
+)
+
+var (
+	sessionMu  sync.RWMutex
+	sessionCfg *config.SessionConfig // nil — cookie-сессии выключены, только Bearer
+)
+
+// EnableCookieSessions разрешает AuthMiddleware брать access-токен из HttpOnly cookie.
+func EnableCookieSessions(cfg config.SessionConfig) {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	if !cfg.CookieMode {
+		sessionCfg = nil
+		return
+	}
+	sessionCfg = &cfg
+}
+
+func currentSessionConfig() *config.SessionConfig {
+	sessionMu.RLock()
+	defer sessionMu.RUnlock()
+	return sessionCfg
+}
+
+// CookieSessionsEnabled сообщает, включён ли режим cookie-сессий.
+func CookieSessionsEnabled() bool {
+	return currentSessionConfig() != nil
+}
+
+// AccessTokenFromCookie возвращает access-токен из cookie, если режим включён.
+func AccessTokenFromCookie(r *http.Request) (string, bool) {
+	if currentSessionConfig() == nil {
+		return "", false
+	}
+	c, err := r.Cookie(AccessCookieName)
+	if err != nil || c.Value == "" {
+		return "", false
+	}
+	return c.Value, true
+}
+
+// CSRFProtect проверяет CSRF для запросов, которые опираются на cookie сессии
+// (обновление токена, выход). Запросы без cookie пропускаются.
+func CSRFProtect(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if currentSessionConfig() != nil && hasSessionCookie(r) {
+			if err := verifyCSRF(r); err != nil {
+				writeCSRFError(w, r)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func hasSessionCookie(r *http.Request) bool {
+	for _, name := range []string{AccessCookieName, RefreshCookieName} {
+		if c, err := r.Cookie(name); err == nil && c.Value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func writeCSRFError(w http.ResponseWriter, r *http.Request) {
+	apierr.Write(w, r, apierr.New(http.StatusForbidden, apierr.CodeCSRFFailed, "CSRF validation failed"))
+}
+
+// verifyCSRF — для методов, меняющих состояние: Origin (или Referer) должен быть
+// своим или доверенным, а заголовок X-CSRF-Token — совпадать с cookie csrf_token.
+func verifyCSRF(r *http.Request) error {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return nil
+	}
+
+	cfg := currentSessionConfig()
+	if cfg == nil {
+		return nil
+	}
+
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		if ref, err := url.Parse(r.Header.Get("Referer")); err == nil && ref.Host != "" {
+			origin = ref.Scheme + "://" + ref.Host
+		}
+	}
+	if origin != "" && !originAllowed(origin, r.Host, cfg.TrustedOrigins) {
+		return errors.New("origin not allowed")
+	}
+
+	cookie, err := r.Cookie(CSRFCookieName)
+	header := r.Header.Get(CSRFHeader)
+	if err != nil || cookie.Value == "" || header == "" ||
+		subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(header)) != 1 {
+		return errors.New("csrf token mismatch")
+	}
+	return nil
+}
+
+func originAllowed(origin, host string, trusted []string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if strings.EqualFold(u.Host, host) {
+		return true
+	}
+	for _, t := range trusted {
+		if strings.EqualFold(strings.TrimSuffix(t, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
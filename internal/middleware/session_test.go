@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"rim-router-service-ver-cgo/internal/config"
+	"rim-router-service-ver-cgo/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// withCookieSessions включает режим cookie-сессий; токен "cookie-token" валиден.
+func withCookieSessions(t *testing.T, trusted ...string) {
+	EnableCookieSessions(config.SessionConfig{CookieMode: true, TrustedOrigins: trusted})
+	t.Cleanup(func() { EnableCookieSessions(config.SessionConfig{}) })
+
+	oldValidate := utils.ValidateTokenFunc
+	t.Cleanup(func() { utils.ValidateTokenFunc = oldValidate })
+	utils.ValidateTokenFunc = func(token string) (*utils.Claims, error) {
+		if token != "cookie-token" {
+			return nil, errors.New("invalid token")
+		}
+		return &utils.Claims{UserID: 1, Username: "tester", Role: 1}, nil
+	}
+}
+
+func cookieRequest(method, csrfCookie, csrfHeader string) *http.Request {
+	req := httptest.NewRequest(method, "http://router.local/api/v2/tir/start", nil)
+	req.AddCookie(&http.Cookie{Name: AccessCookieName, Value: "cookie-token"})
+	if csrfCookie != "" {
+		req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: csrfCookie})
+	}
+	if csrfHeader != "" {
+		req.Header.Set(CSRFHeader, csrfHeader)
+	}
+	return req
+}
+
+func TestCookieSession_DisabledByDefault(t *testing.T) {
+	handler, called := makeHandlerCalledFlag()
+	w := httptest.NewRecorder()
+	AuthMiddleware(handler).ServeHTTP(w, cookieRequest(http.MethodGet, "", ""))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.False(t, *called)
+}
+
+func TestCookieSession_SafeMethodNeedsNoCSRF(t *testing.T) {
+	withCookieSessions(t)
+
+	handler, called := makeHandlerCalledFlag()
+	w := httptest.NewRecorder()
+	AuthMiddleware(handler).ServeHTTP(w, cookieRequest(http.MethodGet, "", ""))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, *called)
+}
+
+func TestCookieSession_DoubleSubmit(t *testing.T) {
+	withCookieSessions(t)
+
+	cases := []struct {
+		name         string
+		cookie, head string
+		want         int
+	}{
+		{"match", "csrf-1", "csrf-1", http.StatusOK},
+		{"missing header", "csrf-1", "", http.StatusForbidden},
+		{"missing cookie", "", "csrf-1", http.StatusForbidden},
+		{"mismatch", "csrf-1", "csrf-2", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler, called := makeHandlerCalledFlag()
+			w := httptest.NewRecorder()
+			AuthMiddleware(handler).ServeHTTP(w, cookieRequest(http.MethodPost, tc.cookie, tc.head))
+
+			assert.Equal(t, tc.want, w.Code)
+			assert.Equal(t, tc.want == http.StatusOK, *called)
+		})
+	}
+}
+
+func TestCookieSession_OriginCheck(t *testing.T) {
+	withCookieSessions(t, "https://ui.example.com")
+
+	cases := []struct {
+		header, value string
+		want          int
+	}{
+		{"Origin", "http://router.local", http.StatusOK},
+		{"Origin", "https://ui.example.com", http.StatusOK},
+		{"Origin", "https://evil.example.com", http.StatusForbidden},
+		{"Referer", "https://evil.example.com/page", http.StatusForbidden},
+		{"Referer", "http://router.local/ui/", http.StatusOK},
+	}
+	for _, tc := range cases {
+		t.Run(tc.header+" "+tc.value, func(t *testing.T) {
+			req := cookieRequest(http.MethodPost, "csrf-1", "csrf-1")
+			req.Header.Set(tc.header, tc.value)
+
+			handler, _ := makeHandlerCalledFlag()
+			w := httptest.NewRecorder()
+			AuthMiddleware(handler).ServeHTTP(w, req)
+			assert.Equal(t, tc.want, w.Code)
+		})
+	}
+}
+
+func TestCookieSession_BearerSkipsCSRF(t *testing.T) {
+	withCookieSessions(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Authorization", "Bearer cookie-token")
+
+	handler, called := makeHandlerCalledFlag()
+	w := httptest.NewRecorder()
+	AuthMiddleware(handler).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, "заголовок браузер сам не подставляет — CSRF не нужен")
+	assert.True(t, *called)
+}
+
+func TestCSRFProtect_RefreshCookie(t *testing.T) {
+	withCookieSessions(t)
+
+	handler, called := makeHandlerCalledFlag()
+	protected := CSRFProtect(handler)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/refresh", nil)
+	req.AddCookie(&http.Cookie{Name: RefreshCookieName, Value: "refresh"})
+	w := httptest.NewRecorder()
+	protected.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.False(t, *called)
+
+	req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: "csrf-1"})
+	req.Header.Set(CSRFHeader, "csrf-1")
+	w = httptest.NewRecorder()
+	protected.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, *called)
+}
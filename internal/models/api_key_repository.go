@@ -0,0 +1,159 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Области действия API-ключей
+const (
+	ScopeLogsRead  = "logs:read"  // просмотр и скачивание логов
+	ScopeAuditRead = "audit:read" // журнал аудита
+	ScopeAdmin     = "admin"      // управление пользователями, приглашениями и ключами
+)
+
+// APIKeyScopes — все допустимые области действия.
+var APIKeyScopes = []string{ScopeLogsRead, ScopeAuditRead, ScopeAdmin}
+
+// IsValidScope сообщает, известна ли область действия.
+func IsValidScope(scope string) bool {
+	for _, s := range APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey — долгоживущий ключ для скриптов и мониторинга. Ключ действует от
+// имени владельца (UserID) и ограничен списком областей. Сам ключ не хранится,
+// только его дайджест; Prefix помогает узнать ключ в списке.
+type APIKey struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	UserID     int64      `json:"user_id"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Active сообщает, можно ли сейчас пользоваться ключом.
+func (k *APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// HasScope проверяет, есть ли scope среди областей действия ключа.
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type APIKeyRepository struct {
+	DB *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{DB: db}
+}
+
+const apiKeyColumns = "id, name, prefix, user_id, scopes, created_at, expires_at, last_used_at, revoked_at"
+
+// CreateAPIKey сохраняет дайджест ключа и возвращает ID записи.
+func (r *APIKeyRepository) CreateAPIKey(k APIKey, key string) (int64, error) {
+	var expiresAt sql.NullTime
+	if k.ExpiresAt != nil {
+		expiresAt = sql.NullTime{Time: k.ExpiresAt.UTC(), Valid: true}
+	}
+	res, err := r.DB.Exec(`
+		INSERT INTO api_keys (name, prefix, key_hash, user_id, scopes, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, k.Name, k.Prefix, HashToken(key), k.UserID, strings.Join(k.Scopes, ","), k.CreatedAt.UTC(), expiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// GetAPIKey ищет ключ по его значению. Отозванные и истёкшие ключи тоже
+// возвращаются — решение принимает вызывающий код.
+func (r *APIKeyRepository) GetAPIKey(key string) (*APIKey, error) {
+	row := r.DB.QueryRow("SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash = ?", HashToken(key))
+	return scanAPIKey(row)
+}
+
+// ListAPIKeys возвращает все ключи, новые сверху.
+func (r *APIKeyRepository) ListAPIKeys() ([]APIKey, error) {
+	rows, err := r.DB.Query("SELECT " + apiKeyColumns + " FROM api_keys ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *k)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey помечает ключ отозванным. Возвращает false, если ключа нет
+// или он уже отозван.
+func (r *APIKeyRepository) RevokeAPIKey(id int64, at time.Time) (bool, error) {
+	res, err := r.DB.Exec(
+		"UPDATE api_keys SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL",
+		at.UTC(), id,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// TouchAPIKey запоминает время последнего использования ключа.
+func (r *APIKeyRepository) TouchAPIKey(id int64, at time.Time) error {
+	_, err := r.DB.Exec("UPDATE api_keys SET last_used_at = ? WHERE id = ?", at.UTC(), id)
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAPIKey(row rowScanner) (*APIKey, error) {
+	var k APIKey
+	var scopes string
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	if err := row.Scan(&k.ID, &k.Name, &k.Prefix, &k.UserID, &scopes, &k.CreatedAt, &expiresAt, &lastUsedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	k.Scopes = []string{}
+	if scopes != "" {
+		k.Scopes = strings.Split(scopes, ",")
+	}
+	if expiresAt.Valid {
+		k.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		k.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		k.RevokedAt = &revokedAt.Time
+	}
+	return &k, nil
+}
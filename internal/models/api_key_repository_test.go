@@ -0,0 +1,84 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupAPIKeyRepo(t *testing.T) (*sql.DB, sqlmock.Sqlmock, *APIKeyRepository) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Ошибка создания mock DB: %v", err)
+	}
+	return db, mock, NewAPIKeyRepository(db)
+}
+
+var apiKeyRowColumns = []string{"id", "name", "prefix", "user_id", "scopes", "created_at", "expires_at", "last_used_at", "revoked_at"}
+
+func TestCreateAPIKey_StoresHash(t *testing.T) {
+	db, mock, repo := setupAPIKeyRepo(t)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectExec("INSERT INTO api_keys").
+		WithArgs("monitoring", "rim_abcdefgh", HashToken("rim_abcdefgh-secret"), int64(1), "logs:read,audit:read", now.UTC(), nil).
+		WillReturnResult(sqlmock.NewResult(4, 1))
+
+	id, err := repo.CreateAPIKey(APIKey{
+		Name:      "monitoring",
+		Prefix:    "rim_abcdefgh",
+		UserID:    1,
+		Scopes:    []string{ScopeLogsRead, ScopeAuditRead},
+		CreatedAt: now,
+	}, "rim_abcdefgh-secret")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAPIKey(t *testing.T) {
+	db, mock, repo := setupAPIKeyRepo(t)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT id, name, prefix, user_id, scopes").
+		WithArgs(HashToken("key")).
+		WillReturnRows(sqlmock.NewRows(apiKeyRowColumns).
+			AddRow(4, "monitoring", "rim_abcdefgh", 1, "logs:read,audit:read", now, nil, now, now))
+
+	k, err := repo.GetAPIKey("key")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{ScopeLogsRead, ScopeAuditRead}, k.Scopes)
+		assert.True(t, HasScope(k.Scopes, ScopeAuditRead))
+		assert.False(t, HasScope(k.Scopes, ScopeAdmin))
+		assert.NotNil(t, k.LastUsedAt)
+		assert.False(t, k.Active(now), "отозванный ключ неактивен")
+	}
+}
+
+func TestAPIKey_Active(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+
+	assert.True(t, (&APIKey{}).Active(now))
+	assert.True(t, (&APIKey{ExpiresAt: &future}).Active(now))
+	assert.False(t, (&APIKey{ExpiresAt: &past}).Active(now))
+}
+
+func TestRevokeAPIKey_AlreadyRevoked(t *testing.T) {
+	db, mock, repo := setupAPIKeyRepo(t)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectExec("UPDATE api_keys SET revoked_at").
+		WithArgs(now.UTC(), int64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ok, err := repo.RevokeAPIKey(4, now)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
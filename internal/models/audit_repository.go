@@ -0,0 +1,195 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// AuditEvent — запись журнала аудита. Before/After — произвольный JSON
+// с состоянием объекта до и после действия.
+type AuditEvent struct {
+	ID        int64           `json:"id"`
+	Timestamp time.Time       `json:"ts"`
+	Actor     string          `json:"actor"`
+	ActorID   *int64          `json:"actor_id,omitempty"`
+	Action    string          `json:"action"`
+	Target    string          `json:"target,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+	IP        string          `json:"ip,omitempty"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+}
+
+// AuditFilter — условия выборки. Пустые поля не ограничивают выборку.
+type AuditFilter struct {
+	Actor  string
+	Action string
+	Target string
+	From   time.Time
+	To     time.Time
+	Limit  int
+	Offset int
+}
+
+type AuditRepository struct {
+	DB *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{DB: db}
+}
+
+// Insert сохраняет событие и возвращает его ID.
+func (r *AuditRepository) Insert(e AuditEvent) (int64, error) {
+	res, err := r.DB.Exec(`
+		INSERT INTO audit_events (ts, actor, actor_id, action, target, request_id, ip, before, after)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, e.Timestamp.UTC(), e.Actor, nullInt64(e.ActorID), e.Action, e.Target, e.RequestID, e.IP,
+		nullJSON(e.Before), nullJSON(e.After))
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// List возвращает страницу событий (новые сверху) и общее число подходящих.
+func (r *AuditRepository) List(f AuditFilter) ([]AuditEvent, int, error) {
+	where, args := f.where()
+
+	var total int
+	if err := r.DB.QueryRow("SELECT COUNT(*) FROM audit_events"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := auditSelect + where + " ORDER BY id DESC LIMIT ? OFFSET ?"
+	rows, err := r.DB.Query(query, append(args, f.Limit, f.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	events := []AuditEvent{}
+	for rows.Next() {
+		e, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		events = append(events, e)
+	}
+	return events, total, rows.Err()
+}
+
+// Each вызывает fn для каждого подходящего события в хронологическом порядке
+// (без пагинации) — для потоковой выгрузки.
+func (r *AuditRepository) Each(f AuditFilter, fn func(AuditEvent) error) error {
+	where, args := f.where()
+	rows, err := r.DB.Query(auditSelect+where+" ORDER BY id ASC", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanAuditEvent(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Prune удаляет события старше before и всё, что не входит в keep последних
+// (нулевой before — без ограничения по сроку, keep = 0 — по числу).
+// Возвращает число удалённых строк.
+func (r *AuditRepository) Prune(before time.Time, keep int) (int64, error) {
+	var deleted int64
+	if !before.IsZero() {
+		res, err := r.DB.Exec("DELETE FROM audit_events WHERE ts < ?", before.UTC())
+		if err != nil {
+			return 0, err
+		}
+		deleted, _ = res.RowsAffected()
+	}
+	if keep <= 0 {
+		return deleted, nil
+	}
+	res, err := r.DB.Exec(`
+		DELETE FROM audit_events
+		WHERE id <= (SELECT id FROM audit_events ORDER BY id DESC LIMIT 1 OFFSET ?)
+	`, keep)
+	if err != nil {
+		return deleted, err
+	}
+	n, _ := res.RowsAffected()
+	return deleted + n, nil
+}
+
+const auditSelect = `SELECT id, ts, actor, actor_id, action, target, request_id, ip, before, after FROM audit_events`
+
+func (f AuditFilter) where() (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if f.Actor != "" {
+		conds = append(conds, "actor = ?")
+		args = append(args, f.Actor)
+	}
+	if f.Action != "" {
+		conds = append(conds, "action = ?")
+		args = append(args, f.Action)
+	}
+	if f.Target != "" {
+		conds = append(conds, "target = ?")
+		args = append(args, f.Target)
+	}
+	if !f.From.IsZero() {
+		conds = append(conds, "ts >= ?")
+		args = append(args, f.From.UTC())
+	}
+	if !f.To.IsZero() {
+		conds = append(conds, "ts < ?")
+		args = append(args, f.To.UTC())
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+func scanAuditEvent(rows *sql.Rows) (AuditEvent, error) {
+	var e AuditEvent
+	var actorID sql.NullInt64
+	var before, after sql.NullString
+	if err := rows.Scan(&e.ID, &e.Timestamp, &e.Actor, &actorID, &e.Action, &e.Target,
+		&e.RequestID, &e.IP, &before, &after); err != nil {
+		return e, err
+	}
+	if actorID.Valid {
+		e.ActorID = &actorID.Int64
+	}
+	if before.Valid {
+		e.Before = json.RawMessage(before.String)
+	}
+	if after.Valid {
+		e.After = json.RawMessage(after.String)
+	}
+	return e, nil
+}
+
+func nullInt64(v *int64) sql.NullInt64 {
+	if v == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *v, Valid: true}
+}
+
+func nullJSON(v json.RawMessage) sql.NullString {
+	if len(v) == 0 {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: string(v), Valid: true}
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupAuditRepo(t *testing.T) (*sql.DB, sqlmock.Sqlmock, *AuditRepository) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Ошибка создания mock DB: %v", err)
+	}
+	return db, mock, NewAuditRepository(db)
+}
+
+func TestAuditInsert(t *testing.T) {
+	db, mock, repo := setupAuditRepo(t)
+	defer db.Close()
+
+	now := time.Now()
+	actorID := int64(1)
+	mock.ExpectExec("INSERT INTO audit_events").
+		WithArgs(now.UTC(), "admin", actorID, "user.delete", "bob", "req-1", "10.0.0.1",
+			`{"role":0}`, nil).
+		WillReturnResult(sqlmock.NewResult(11, 1))
+
+	id, err := repo.Insert(AuditEvent{
+		Timestamp: now,
+		Actor:     "admin",
+		ActorID:   &actorID,
+		Action:    "user.delete",
+		Target:    "bob",
+		RequestID: "req-1",
+		IP:        "10.0.0.1",
+		Before:    json.RawMessage(`{"role":0}`),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuditList_NoFilter(t *testing.T) {
+	db, mock, repo := setupAuditRepo(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM audit_events$").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("FROM audit_events ORDER BY id DESC LIMIT \\? OFFSET \\?").
+		WithArgs(50, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "ts", "actor", "actor_id", "action", "target", "request_id", "ip", "before", "after"}).
+			AddRow(1, time.Now(), "ghost", nil, "auth.login_failed", "ghost", "", "", nil, `{"reason":"unknown_user"}`))
+
+	events, total, err := repo.List(AuditFilter{Limit: 50})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+	if assert.Len(t, events, 1) {
+		assert.Nil(t, events[0].ActorID)
+		assert.Nil(t, events[0].Before)
+	}
+}
+
+func TestAuditPrune(t *testing.T) {
+	db, mock, repo := setupAuditRepo(t)
+	defer db.Close()
+
+	before := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectExec("DELETE FROM audit_events WHERE ts < \\?").
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 5))
+	mock.ExpectExec("DELETE FROM audit_events\\s+WHERE id <= \\(SELECT id FROM audit_events ORDER BY id DESC LIMIT 1 OFFSET \\?\\)").
+		WithArgs(100).
+		WillReturnResult(sqlmock.NewResult(0, 7))
+
+	n, err := repo.Prune(before, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12), n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuditPrune_CountOnly(t *testing.T) {
+	db, mock, repo := setupAuditRepo(t)
+	defer db.Close()
+
+	// срок не ограничен — удаляется только превышение предела
+	mock.ExpectExec("DELETE FROM audit_events\\s+WHERE id <= ").
+		WithArgs(100).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	n, err := repo.Prune(time.Time{}, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
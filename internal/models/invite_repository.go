@@ -0,0 +1,104 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Invite — одноразовый код приглашения. Сам код не хранится, только его дайджест.
+type Invite struct {
+	ID        int64      `json:"id"`
+	CreatedBy *int64     `json:"created_by,omitempty"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedBy    *string    `json:"used_by,omitempty"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type InviteRepository struct {
+	DB *sql.DB
+}
+
+func NewInviteRepository(db *sql.DB) *InviteRepository {
+	return &InviteRepository{DB: db}
+}
+
+// CreateInvite сохраняет дайджест кода и возвращает ID приглашения.
+// createdBy == 0 — автор неизвестен.
+func (r *InviteRepository) CreateInvite(code string, createdBy int64, expiresAt time.Time) (int64, error) {
+	res, err := r.DB.Exec(
+		"INSERT INTO invite_codes (code_hash, created_by, expires_at) VALUES (?, ?, ?)",
+		HashToken(code), sql.NullInt64{Int64: createdBy, Valid: createdBy != 0}, expiresAt.UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// ConsumeInvite помечает код использованным. Возвращает false, если код
+// не найден, уже использован или истёк.
+func (r *InviteRepository) ConsumeInvite(code, username string, now time.Time) (bool, error) {
+	res, err := r.DB.Exec(`
+		UPDATE invite_codes SET used_by = ?, used_at = ?
+		WHERE code_hash = ? AND used_at IS NULL AND expires_at > ?
+	`, username, now.UTC(), HashToken(code), now.UTC())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// ReleaseInvite возвращает код в оборот, если регистрация не удалась.
+func (r *InviteRepository) ReleaseInvite(code string) error {
+	_, err := r.DB.Exec(
+		"UPDATE invite_codes SET used_by = NULL, used_at = NULL WHERE code_hash = ?",
+		HashToken(code),
+	)
+	return err
+}
+
+// ListInvites возвращает все приглашения, новые сверху.
+func (r *InviteRepository) ListInvites() ([]Invite, error) {
+	rows, err := r.DB.Query(`
+		SELECT id, created_by, expires_at, used_by, used_at, created_at
+		FROM invite_codes ORDER BY id DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invites := []Invite{}
+	for rows.Next() {
+		var inv Invite
+		var createdBy sql.NullInt64
+		var usedBy sql.NullString
+		var usedAt sql.NullTime
+		if err := rows.Scan(&inv.ID, &createdBy, &inv.ExpiresAt, &usedBy, &usedAt, &inv.CreatedAt); err != nil {
+			return nil, err
+		}
+		if createdBy.Valid {
+			inv.CreatedBy = &createdBy.Int64
+		}
+		if usedBy.Valid {
+			inv.UsedBy = &usedBy.String
+		}
+		if usedAt.Valid {
+			inv.UsedAt = &usedAt.Time
+		}
+		invites = append(invites, inv)
+	}
+	return invites, rows.Err()
+}
+
+// DeleteInvite отзывает приглашение.
+func (r *InviteRepository) DeleteInvite(id int64) (bool, error) {
+	res, err := r.DB.Exec("DELETE FROM invite_codes WHERE id = ?", id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupInviteRepo(t *testing.T) (*sql.DB, sqlmock.Sqlmock, *InviteRepository) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Ошибка создания mock DB: %v", err)
+	}
+	return db, mock, NewInviteRepository(db)
+}
+
+func TestCreateInvite_StoresHash(t *testing.T) {
+	db, mock, repo := setupInviteRepo(t)
+	defer db.Close()
+
+	expires := time.Now().Add(time.Hour)
+	mock.ExpectExec("INSERT INTO invite_codes").
+		WithArgs(HashToken("secret-code"), int64(1), expires.UTC()).
+		WillReturnResult(sqlmock.NewResult(5, 1))
+
+	id, err := repo.CreateInvite("secret-code", 1, expires)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumeInvite_AlreadyUsed(t *testing.T) {
+	db, mock, repo := setupInviteRepo(t)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectExec("UPDATE invite_codes SET used_by").
+		WithArgs("bob", now.UTC(), HashToken("code"), now.UTC()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ok, err := repo.ConsumeInvite("code", "bob", now)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestListInvites(t *testing.T) {
+	db, mock, repo := setupInviteRepo(t)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT id, created_by, expires_at, used_by, used_at, created_at").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_by", "expires_at", "used_by", "used_at", "created_at"}).
+			AddRow(2, 1, now, "bob", now, now).
+			AddRow(1, nil, now, nil, nil, now))
+
+	invites, err := repo.ListInvites()
+	assert.NoError(t, err)
+	if assert.Len(t, invites, 2) {
+		assert.Equal(t, "bob", *invites[0].UsedBy)
+		assert.Nil(t, invites[1].CreatedBy)
+		assert.Nil(t, invites[1].UsedAt)
+	}
+}
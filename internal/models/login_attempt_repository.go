@@ -0,0 +1,89 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// LoginAttempt — счётчик неудачных входов для ключа "user:<name>" или "ip:<addr>".
+type LoginAttempt struct {
+	Key         string     `json:"key"`
+	Failures    int        `json:"failures"`
+	LastFailure time.Time  `json:"last_failure"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+}
+
+type LoginAttemptRepository struct {
+	DB *sql.DB
+}
+
+func NewLoginAttemptRepository(db *sql.DB) *LoginAttemptRepository {
+	return &LoginAttemptRepository{DB: db}
+}
+
+// Get возвращает счётчик по ключу; если записи нет — (nil, nil).
+func (r *LoginAttemptRepository) Get(key string) (*LoginAttempt, error) {
+	var a LoginAttempt
+	var locked sql.NullTime
+	err := r.DB.QueryRow(
+		"SELECT key, failures, last_failure, locked_until FROM login_attempts WHERE key = ?",
+		key,
+	).Scan(&a.Key, &a.Failures, &a.LastFailure, &locked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if locked.Valid {
+		a.LockedUntil = &locked.Time
+	}
+	return &a, nil
+}
+
+// RegisterFailure увеличивает счётчик одним запросом и возвращает новое
+// значение, так что параллельные ошибки не затирают друг друга. Если
+// предыдущая ошибка была раньше windowStart, счётчик и блокировка
+// начинаются заново.
+func (r *LoginAttemptRepository) RegisterFailure(key string, now, windowStart time.Time) (int, error) {
+	var failures int
+	err := r.DB.QueryRow(`
+		INSERT INTO login_attempts (key, failures, last_failure) VALUES (?, 1, ?)
+		ON CONFLICT(key) DO UPDATE SET
+			failures = CASE WHEN last_failure < ? THEN 1 ELSE failures + 1 END,
+			locked_until = CASE WHEN last_failure < ? THEN NULL ELSE locked_until END,
+			last_failure = excluded.last_failure
+		RETURNING failures
+	`, key, now.UTC(), windowStart.UTC(), windowStart.UTC()).Scan(&failures)
+	return failures, err
+}
+
+// Lock блокирует ключ до until; более долгая блокировка, выставленная
+// параллельным запросом, не сокращается.
+func (r *LoginAttemptRepository) Lock(key string, until time.Time) error {
+	_, err := r.DB.Exec(
+		"UPDATE login_attempts SET locked_until = ? WHERE key = ? AND (locked_until IS NULL OR locked_until < ?)",
+		until.UTC(), key, until.UTC(),
+	)
+	return err
+}
+
+// Reset удаляет счётчик (успешный вход или разблокировка администратором).
+func (r *LoginAttemptRepository) Reset(key string) error {
+	_, err := r.DB.Exec("DELETE FROM login_attempts WHERE key = ?", key)
+	return err
+}
+
+// DeleteStale удаляет счётчики без ошибок после failedBefore, блокировка
+// которых истекла к now. Возвращает число удалённых записей.
+func (r *LoginAttemptRepository) DeleteStale(failedBefore, now time.Time) (int64, error) {
+	res, err := r.DB.Exec(
+		"DELETE FROM login_attempts WHERE last_failure < ? AND (locked_until IS NULL OR locked_until < ?)",
+		failedBefore.UTC(), now.UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupAttemptRepo(t *testing.T) (*sql.DB, sqlmock.Sqlmock, *LoginAttemptRepository) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Ошибка создания mock DB: %v", err)
+	}
+	return db, mock, NewLoginAttemptRepository(db)
+}
+
+func TestLoginAttemptGet_NotFound(t *testing.T) {
+	db, mock, repo := setupAttemptRepo(t)
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT key, failures, last_failure, locked_until FROM login_attempts WHERE key = ?")).
+		WithArgs("user:ghost").
+		WillReturnError(sql.ErrNoRows)
+
+	a, err := repo.Get("user:ghost")
+	assert.NoError(t, err)
+	assert.Nil(t, a)
+}
+
+func TestLoginAttemptGet_Locked(t *testing.T) {
+	db, mock, repo := setupAttemptRepo(t)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT key, failures").
+		WithArgs("user:bob").
+		WillReturnRows(sqlmock.NewRows([]string{"key", "failures", "last_failure", "locked_until"}).
+			AddRow("user:bob", 5, now, now.Add(time.Minute)))
+
+	a, err := repo.Get("user:bob")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, a.Failures)
+	assert.NotNil(t, a.LockedUntil)
+}
+
+func TestLoginAttemptRegisterFailure(t *testing.T) {
+	db, mock, repo := setupAttemptRepo(t)
+	defer db.Close()
+
+	now := time.Now()
+	windowStart := now.Add(-time.Hour)
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO login_attempts (key, failures, last_failure) VALUES (?, 1, ?)")).
+		WithArgs("ip:10.0.0.1", now.UTC(), windowStart.UTC(), windowStart.UTC()).
+		WillReturnRows(sqlmock.NewRows([]string{"failures"}).AddRow(4))
+
+	failures, err := repo.RegisterFailure("ip:10.0.0.1", now, windowStart)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, failures)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoginAttemptLock(t *testing.T) {
+	db, mock, repo := setupAttemptRepo(t)
+	defer db.Close()
+
+	until := time.Now().Add(time.Minute)
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE login_attempts SET locked_until = ? WHERE key = ?")).
+		WithArgs(until.UTC(), "user:bob", until.UTC()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.Lock("user:bob", until))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoginAttemptReset(t *testing.T) {
+	db, mock, repo := setupAttemptRepo(t)
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM login_attempts WHERE key = ?")).
+		WithArgs("user:bob").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.Reset("user:bob"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
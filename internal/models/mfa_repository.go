@@ -0,0 +1,132 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// UserMFA — TOTP-секрет пользователя. Пока Enabled == false, подключение
+// не подтверждено и при входе не учитывается.
+type UserMFA struct {
+	UserID      int64
+	Secret      string
+	Enabled     bool
+	LastStep    int64 // последний принятый шаг TOTP — защита от повторного кода
+	CreatedAt   time.Time
+	ConfirmedAt *time.Time
+}
+
+type MFARepository struct {
+	DB *sql.DB
+}
+
+func NewMFARepository(db *sql.DB) *MFARepository {
+	return &MFARepository{DB: db}
+}
+
+// GetMFA возвращает настройки MFA пользователя или sql.ErrNoRows.
+func (r *MFARepository) GetMFA(userID int64) (*UserMFA, error) {
+	var m UserMFA
+	var confirmedAt sql.NullTime
+	err := r.DB.QueryRow(
+		"SELECT user_id, secret, enabled, last_step, created_at, confirmed_at FROM user_mfa WHERE user_id = ?",
+		userID,
+	).Scan(&m.UserID, &m.Secret, &m.Enabled, &m.LastStep, &m.CreatedAt, &confirmedAt)
+	if err != nil {
+		return nil, err
+	}
+	if confirmedAt.Valid {
+		m.ConfirmedAt = &confirmedAt.Time
+	}
+	return &m, nil
+}
+
+// SavePendingMFA начинает подключение: сохраняет новый секрет с enabled = 0.
+// Уже подтверждённая MFA не перезаписывается — возвращается false.
+func (r *MFARepository) SavePendingMFA(userID int64, secret string, now time.Time) (bool, error) {
+	res, err := r.DB.Exec(`
+		INSERT INTO user_mfa (user_id, secret, enabled, last_step, created_at) VALUES (?, ?, 0, 0, ?)
+		ON CONFLICT(user_id) DO UPDATE SET secret = excluded.secret, created_at = excluded.created_at
+		WHERE user_mfa.enabled = 0
+	`, userID, secret, now.UTC())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// EnableMFA подтверждает подключение и заменяет коды восстановления.
+func (r *MFARepository) EnableMFA(userID int64, recoveryCodes []string, now time.Time) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		"UPDATE user_mfa SET enabled = 1, confirmed_at = ? WHERE user_id = ?",
+		now.UTC(), userID,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM mfa_recovery_codes WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	for _, code := range recoveryCodes {
+		if _, err := tx.Exec(
+			"INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES (?, ?)",
+			userID, HashToken(code),
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// UseTOTPStep запоминает принятый шаг TOTP. Возвращает false, если этот
+// или более поздний шаг уже использован (повтор кода).
+func (r *MFARepository) UseTOTPStep(userID, step int64) (bool, error) {
+	res, err := r.DB.Exec(
+		"UPDATE user_mfa SET last_step = ? WHERE user_id = ? AND last_step < ?",
+		step, userID, step,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// ConsumeRecoveryCode гасит одноразовый код восстановления.
+func (r *MFARepository) ConsumeRecoveryCode(userID int64, code string, now time.Time) (bool, error) {
+	res, err := r.DB.Exec(`
+		UPDATE mfa_recovery_codes SET used_at = ?
+		WHERE user_id = ? AND code_hash = ? AND used_at IS NULL
+	`, now.UTC(), userID, HashToken(code))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// RecoveryCodesLeft — сколько кодов восстановления ещё не использовано.
+func (r *MFARepository) RecoveryCodesLeft(userID int64) (int, error) {
+	var n int
+	err := r.DB.QueryRow(
+		"SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id = ? AND used_at IS NULL",
+		userID,
+	).Scan(&n)
+	return n, err
+}
+
+// DisableMFA удаляет секрет и коды восстановления (каскадно).
+func (r *MFARepository) DisableMFA(userID int64) (bool, error) {
+	res, err := r.DB.Exec("DELETE FROM user_mfa WHERE user_id = ?", userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
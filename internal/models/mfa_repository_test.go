@@ -0,0 +1,80 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupMFARepo(t *testing.T) (*sql.DB, sqlmock.Sqlmock, *MFARepository) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Ошибка создания mock DB: %v", err)
+	}
+	return db, mock, NewMFARepository(db)
+}
+
+func TestSavePendingMFA_AlreadyEnabled(t *testing.T) {
+	db, mock, repo := setupMFARepo(t)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectExec("INSERT INTO user_mfa").
+		WithArgs(int64(1), "SECRET", now.UTC()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ok, err := repo.SavePendingMFA(1, "SECRET", now)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestEnableMFA_ReplacesRecoveryCodes(t *testing.T) {
+	db, mock, repo := setupMFARepo(t)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE user_mfa SET enabled = 1").
+		WithArgs(now.UTC(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM mfa_recovery_codes").
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO mfa_recovery_codes").
+		WithArgs(int64(1), HashToken("aaaaa-bbbbb")).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.EnableMFA(1, []string{"aaaaa-bbbbb"}, now))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUseTOTPStep_Replay(t *testing.T) {
+	db, mock, repo := setupMFARepo(t)
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE user_mfa SET last_step").
+		WithArgs(int64(100), int64(1), int64(100)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ok, err := repo.UseTOTPStep(1, 100)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestConsumeRecoveryCode(t *testing.T) {
+	db, mock, repo := setupMFARepo(t)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectExec("UPDATE mfa_recovery_codes SET used_at").
+		WithArgs(now.UTC(), int64(1), HashToken("aaaaa-bbbbb")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ok, err := repo.ConsumeRecoveryCode(1, "aaaaa-bbbbb", now)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
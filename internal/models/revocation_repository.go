@@ -0,0 +1,90 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Revocations — действующие записи списка отзыва access-токенов.
+type Revocations struct {
+	Tokens map[string]time.Time // jti → когда истекает сам токен
+	Users  map[int64]UserRevocation
+}
+
+// UserRevocation — все токены пользователя, выданные раньше RevokedBefore, недействительны.
+type UserRevocation struct {
+	RevokedBefore time.Time
+	ExpiresAt     time.Time // после этого момента запись не нужна
+}
+
+type RevocationRepository struct {
+	DB *sql.DB
+}
+
+func NewRevocationRepository(db *sql.DB) *RevocationRepository {
+	return &RevocationRepository{DB: db}
+}
+
+func (r *RevocationRepository) RevokeToken(jti string, expiresAt time.Time) error {
+	_, err := r.DB.Exec(
+		`INSERT OR REPLACE INTO revoked_tokens (jti, expires_at) VALUES (?, ?)`,
+		jti, expiresAt.UTC(),
+	)
+	return err
+}
+
+func (r *RevocationRepository) RevokeUser(userID int64, before, expiresAt time.Time) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO revoked_user_tokens (user_id, revoked_before, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET revoked_before = excluded.revoked_before, expires_at = excluded.expires_at
+	`, userID, before.UTC(), expiresAt.UTC())
+	return err
+}
+
+// LoadRevocations возвращает записи, которые ещё не истекли.
+func (r *RevocationRepository) LoadRevocations(now time.Time) (Revocations, error) {
+	out := Revocations{Tokens: map[string]time.Time{}, Users: map[int64]UserRevocation{}}
+
+	rows, err := r.DB.Query(`SELECT jti, expires_at FROM revoked_tokens WHERE expires_at > ?`, now.UTC())
+	if err != nil {
+		return out, err
+	}
+	for rows.Next() {
+		var jti string
+		var exp time.Time
+		if err := rows.Scan(&jti, &exp); err != nil {
+			rows.Close()
+			return out, err
+		}
+		out.Tokens[jti] = exp
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return out, err
+	}
+	rows.Close()
+
+	rows, err = r.DB.Query(`SELECT user_id, revoked_before, expires_at FROM revoked_user_tokens WHERE expires_at > ?`, now.UTC())
+	if err != nil {
+		return out, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var u UserRevocation
+		if err := rows.Scan(&id, &u.RevokedBefore, &u.ExpiresAt); err != nil {
+			return out, err
+		}
+		out.Users[id] = u
+	}
+	return out, rows.Err()
+}
+
+// DeleteExpiredRevocations удаляет записи, срок которых прошёл.
+func (r *RevocationRepository) DeleteExpiredRevocations(now time.Time) error {
+	if _, err := r.DB.Exec(`DELETE FROM revoked_tokens WHERE expires_at <= ?`, now.UTC()); err != nil {
+		return err
+	}
+	_, err := r.DB.Exec(`DELETE FROM revoked_user_tokens WHERE expires_at <= ?`, now.UTC())
+	return err
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadRevocations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Ошибка создания mock DB: %v", err)
+	}
+	defer db.Close()
+	repo := NewRevocationRepository(db)
+
+	now := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT jti, expires_at FROM revoked_tokens WHERE expires_at > ?")).
+		WithArgs(now.UTC()).
+		WillReturnRows(sqlmock.NewRows([]string{"jti", "expires_at"}).AddRow("abc", now.Add(time.Minute)))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT user_id, revoked_before, expires_at FROM revoked_user_tokens WHERE expires_at > ?")).
+		WithArgs(now.UTC()).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "revoked_before", "expires_at"}).
+			AddRow(3, now, now.Add(15*time.Minute)))
+
+	rev, err := repo.LoadRevocations(now)
+	assert.NoError(t, err)
+	assert.Contains(t, rev.Tokens, "abc")
+	assert.Equal(t, now, rev.Users[3].RevokedBefore)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRevokeUser_Upsert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Ошибка создания mock DB: %v", err)
+	}
+	defer db.Close()
+	repo := NewRevocationRepository(db)
+
+	before := time.Now()
+	mock.ExpectExec("INSERT INTO revoked_user_tokens").
+		WithArgs(int64(3), before.UTC(), before.Add(time.Minute).UTC()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.RevokeUser(3, before, before.Add(time.Minute)))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,175 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SigningKey — ключ подписи JWT. PrivateKey — PKCS#8 DER для RS256/EdDSA
+// или сырой секрет для HS256; nil — файл ключа не найден. RetiredAt != nil —
+// ключ больше не подписывает, но проверяет ранее выданные токены.
+type SigningKey struct {
+	KID        string
+	Algorithm  string
+	PrivateKey []byte
+	CreatedAt  time.Time
+	RetiredAt  *time.Time
+}
+
+// SigningKeyRepository хранит в БД только метаданные ключей (kid, алгоритм,
+// время создания и вывода из оборота). Сам ключ лежит в KeyDir в файле
+// <kid>.key с правами 0600, чтобы копия базы не раскрывала ключи подписи.
+type SigningKeyRepository struct {
+	DB     *sql.DB
+	KeyDir string
+}
+
+func NewSigningKeyRepository(db *sql.DB, keyDir string) *SigningKeyRepository {
+	return &SigningKeyRepository{DB: db, KeyDir: keyDir}
+}
+
+// ListSigningKeys возвращает все сохранённые ключи, старые сверху.
+func (r *SigningKeyRepository) ListSigningKeys() ([]SigningKey, error) {
+	rows, err := r.DB.Query(`SELECT kid, algorithm, created_at, retired_at FROM signing_keys ORDER BY created_at ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []SigningKey
+	for rows.Next() {
+		var k SigningKey
+		var retired sql.NullTime
+		if err := rows.Scan(&k.KID, &k.Algorithm, &k.CreatedAt, &retired); err != nil {
+			return nil, err
+		}
+		if retired.Valid {
+			k.RetiredAt = &retired.Time
+		}
+		keys = append(keys, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range keys {
+		b, err := os.ReadFile(r.keyPath(keys[i].KID))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys[i].PrivateKey = b
+	}
+	return keys, nil
+}
+
+// SaveSigningKey записывает файл ключа, затем его метаданные в БД.
+func (r *SigningKeyRepository) SaveSigningKey(k SigningKey) error {
+	if err := r.ensureKeyDir(); err != nil {
+		return err
+	}
+	path := r.keyPath(k.KID)
+	// временный файл и rename — чтобы при сбое не остался обрезанный ключ
+	tmp, err := os.CreateTemp(r.KeyDir, k.KID+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(k.PrivateKey); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	if _, err := r.DB.Exec(
+		`INSERT INTO signing_keys (kid, algorithm, created_at) VALUES (?, ?, ?)`,
+		k.KID, k.Algorithm, k.CreatedAt.UTC(),
+	); err != nil {
+		os.Remove(path)
+		return err
+	}
+	return nil
+}
+
+// RetireSigningKey выводит ключ из подписи.
+func (r *SigningKeyRepository) RetireSigningKey(kid string, at time.Time) error {
+	_, err := r.DB.Exec(`UPDATE signing_keys SET retired_at = ? WHERE kid = ? AND retired_at IS NULL`, at.UTC(), kid)
+	return err
+}
+
+// DeleteSigningKeysRetiredBefore удаляет ключи, которыми не может быть подписан ни один живой токен,
+// вместе с их файлами.
+func (r *SigningKeyRepository) DeleteSigningKeysRetiredBefore(t time.Time) error {
+	rows, err := r.DB.Query(`SELECT kid FROM signing_keys WHERE retired_at IS NOT NULL AND retired_at < ?`, t.UTC())
+	if err != nil {
+		return err
+	}
+	var kids []string
+	for rows.Next() {
+		var kid string
+		if err := rows.Scan(&kid); err != nil {
+			rows.Close()
+			return err
+		}
+		kids = append(kids, kid)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return err
+	}
+	rows.Close()
+
+	for _, kid := range kids {
+		if _, err := r.DB.Exec(`DELETE FROM signing_keys WHERE kid = ?`, kid); err != nil {
+			return err
+		}
+		if err := os.Remove(r.keyPath(kid)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *SigningKeyRepository) keyPath(kid string) string {
+	return filepath.Join(r.KeyDir, kid+".key")
+}
+
+// ensureKeyDir создаёт каталог ключей и оставляет доступ к нему только владельцу.
+func (r *SigningKeyRepository) ensureKeyDir() error {
+	if r.KeyDir == "" {
+		return errors.New("signing key directory is not set")
+	}
+	if err := os.MkdirAll(r.KeyDir, 0o700); err != nil {
+		return fmt.Errorf("create signing key directory: %w", err)
+	}
+	info, err := os.Stat(r.KeyDir)
+	if err != nil {
+		return err
+	}
+	if info.Mode().Perm()&0o077 != 0 {
+		if err := os.Chmod(r.KeyDir, 0o700); err != nil {
+			return fmt.Errorf("restrict signing key directory: %w", err)
+		}
+	}
+	return nil
+}
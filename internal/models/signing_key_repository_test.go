@@ -0,0 +1,111 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListSigningKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Ошибка создания mock DB: %v", err)
+	}
+	defer db.Close()
+	dir := t.TempDir()
+	repo := NewSigningKeyRepository(db, dir)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "new.key"), []byte{1, 2, 3}, 0o600))
+
+	now := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT kid, algorithm, created_at, retired_at FROM signing_keys")).
+		WillReturnRows(sqlmock.NewRows([]string{"kid", "algorithm", "created_at", "retired_at"}).
+			AddRow("old", "HS256", now.Add(-time.Hour), now).
+			AddRow("new", "EdDSA", now, nil))
+
+	keys, err := repo.ListSigningKeys()
+	assert.NoError(t, err)
+	if assert.Len(t, keys, 2) {
+		assert.NotNil(t, keys[0].RetiredAt)
+		assert.Nil(t, keys[0].PrivateKey, "файла ключа нет")
+		assert.Nil(t, keys[1].RetiredAt)
+		assert.Equal(t, []byte{1, 2, 3}, keys[1].PrivateKey)
+	}
+}
+
+func TestSaveSigningKey_WritesKeyFile(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Ошибка создания mock DB: %v", err)
+	}
+	defer db.Close()
+	dir := filepath.Join(t.TempDir(), "keys")
+	repo := NewSigningKeyRepository(db, dir)
+
+	now := time.Now()
+	// в БД уходят только метаданные
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO signing_keys (kid, algorithm, created_at) VALUES (?, ?, ?)")).
+		WithArgs("abc", "EdDSA", now.UTC()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	assert.NoError(t, repo.SaveSigningKey(SigningKey{KID: "abc", Algorithm: "EdDSA", PrivateKey: []byte("secret"), CreatedAt: now}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	b, err := os.ReadFile(filepath.Join(dir, "abc.key"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("secret"), b)
+	info, err := os.Stat(filepath.Join(dir, "abc.key"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+	info, err = os.Stat(dir)
+	if assert.NoError(t, err) {
+		assert.Equal(t, os.FileMode(0o700), info.Mode().Perm())
+	}
+}
+
+func TestRetireSigningKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Ошибка создания mock DB: %v", err)
+	}
+	defer db.Close()
+	repo := NewSigningKeyRepository(db, t.TempDir())
+
+	at := time.Now()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE signing_keys SET retired_at = ? WHERE kid = ? AND retired_at IS NULL")).
+		WithArgs(at.UTC(), "abc").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.RetireSigningKey("abc", at))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteSigningKeysRetiredBefore_RemovesFiles(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Ошибка создания mock DB: %v", err)
+	}
+	defer db.Close()
+	dir := t.TempDir()
+	repo := NewSigningKeyRepository(db, dir)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "old.key"), []byte("x"), 0o600))
+
+	cutoff := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT kid FROM signing_keys WHERE retired_at IS NOT NULL AND retired_at < ?")).
+		WithArgs(cutoff.UTC()).
+		WillReturnRows(sqlmock.NewRows([]string{"kid"}).AddRow("old").AddRow("gone"))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM signing_keys WHERE kid = ?")).
+		WithArgs("old").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM signing_keys WHERE kid = ?")).
+		WithArgs("gone").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.DeleteSigningKeysRetiredBefore(cutoff))
+	assert.NoError(t, mock.ExpectationsWereMet())
+	_, err = os.Stat(filepath.Join(dir, "old.key"))
+	assert.True(t, os.IsNotExist(err))
+}
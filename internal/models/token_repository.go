@@ -1,7 +1,9 @@
 package models
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"time"
 )
 
@@ -13,22 +15,30 @@ func NewTokenRepository(db *sql.DB) *TokenRepository {
 	return &TokenRepository{DB: db}
 }
 
+// HashToken возвращает SHA-256 дайджест токена в hex. В базе хранится
+// только дайджест, поэтому копия data.db не позволяет выдать себя за
+// пользователя.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func (r *TokenRepository) SaveRefreshToken(userID int64, token string, expiresAt time.Time) error {
 	_, err := r.DB.Exec(`
         INSERT INTO refresh_tokens (user_id, token, expires_at) VALUES (?, ?, ?)
-    `, userID, token, expiresAt.UTC())
+    `, userID, HashToken(token), expiresAt.UTC())
 	return err
 }
 
 func (r *TokenRepository) GetRefreshToken(token string) (userID int64, expiresAt time.Time, err error) {
 	err = r.DB.QueryRow(`
         SELECT user_id, expires_at FROM refresh_tokens WHERE token = ?
-    `, token).Scan(&userID, &expiresAt)
+    `, HashToken(token)).Scan(&userID, &expiresAt)
 	return
 }
 
 func (r *TokenRepository) DeleteRefreshToken(token string) error {
-	_, err := r.DB.Exec(`DELETE FROM refresh_tokens WHERE token = ?`, token)
+	_, err := r.DB.Exec(`DELETE FROM refresh_tokens WHERE token = ?`, HashToken(token))
 	return err
 }
 
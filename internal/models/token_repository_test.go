@@ -28,7 +28,7 @@ func TestSaveRefreshToken(t *testing.T) {
 
 	mock.ExpectExec(regexp.QuoteMeta(
 		"INSERT INTO refresh_tokens (user_id, token, expires_at) VALUES (?, ?, ?)")).
-		WithArgs(int64(1), "token123", expires).
+		WithArgs(int64(1), HashToken("token123"), expires).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	err := repo.SaveRefreshToken(1, "token123", expires)
@@ -46,7 +46,7 @@ func TestGetRefreshToken(t *testing.T) {
 
 	mock.ExpectQuery(regexp.QuoteMeta(
 		"SELECT user_id, expires_at FROM refresh_tokens WHERE token = ?")).
-		WithArgs("abc123").
+		WithArgs(HashToken("abc123")).
 		WillReturnRows(rows)
 
 	userID, exp, err := repo.GetRefreshToken("abc123")
@@ -61,7 +61,7 @@ func TestGetRefreshToken_NotFound(t *testing.T) {
 
 	mock.ExpectQuery(regexp.QuoteMeta(
 		"SELECT user_id, expires_at FROM refresh_tokens WHERE token = ?")).
-		WithArgs("missing").
+		WithArgs(HashToken("missing")).
 		WillReturnError(sql.ErrNoRows)
 
 	userID, exp, err := repo.GetRefreshToken("missing")
@@ -76,7 +76,7 @@ func TestDeleteRefreshToken(t *testing.T) {
 
 	mock.ExpectExec(regexp.QuoteMeta(
 		"DELETE FROM refresh_tokens WHERE token = ?")).
-		WithArgs("todelete").
+		WithArgs(HashToken("todelete")).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
 	err := repo.DeleteRefreshToken("todelete")
@@ -97,3 +97,13 @@ func TestDeleteAllForUser(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestHashToken(t *testing.T) {
+	h1 := HashToken("abc123")
+	h2 := HashToken("abc123")
+
+	assert.Len(t, h1, 64)
+	assert.Equal(t, h1, h2)
+	assert.NotEqual(t, "abc123", h1)
+	assert.NotEqual(t, h1, HashToken("abc124"))
+}
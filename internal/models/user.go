@@ -5,12 +5,26 @@ import (
 	"time"
 )
 
+// Состояния учётной записи
+const (
+	UserStatusActive   = "active"
+	UserStatusPending  = "pending" // ждёт одобрения администратора
+	UserStatusDisabled = "disabled"
+)
+
+// ExternalPasswordHash — хэш-заглушка для пользователей из внешнего каталога.
+// Ни один пароль с ним не совпадает, поэтому локальный вход невозможен.
+const ExternalPasswordHash = "!external"
+
 type User struct {
-	ID           int64     `json:"id"`
-	Username     string    `json:"username"`
-	PasswordHash string    `json:"-"`
-	Role         int       `json:"role"` // 0=user, 1=admin
-	CreatedAt    time.Time `json:"created_at"`
+	ID                 int64     `json:"id"`
+	Username           string    `json:"username"`
+	PasswordHash       string    `json:"-"`
+	Role               int       `json:"role"` // 0=user, 1=admin
+	MustChangePassword bool      `json:"must_change_password"`
+	Status             string    `json:"status"`
+	CreatedAt          time.Time `json:"created_at"`
+	Locale             string    `json:"locale,omitempty"` // ru, en; пусто — по Accept-Language
 }
 
 type UserRepository struct {
@@ -29,12 +43,21 @@ func (r *UserRepository) CreateUser(username, passwordHash string, role int) err
 	return err
 }
 
+// CreateUserWithStatus создаёт пользователя в заданном состоянии (например, pending)
+func (r *UserRepository) CreateUserWithStatus(username, passwordHash string, role int, status string) error {
+	_, err := r.DB.Exec(
+		"INSERT INTO users (username, password_hash, role, status) VALUES (?, ?, ?, ?)",
+		username, passwordHash, role, status,
+	)
+	return err
+}
+
 func (r *UserRepository) GetUserByUsername(username string) (*User, error) {
 	var user User
 	err := r.DB.QueryRow(
-		"SELECT id, username, password_hash, role, created_at FROM users WHERE username = ?",
+		"SELECT id, username, password_hash, role, must_change_password, status, created_at, locale FROM users WHERE username = ?",
 		username,
-	).Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Role, &user.CreatedAt)
+	).Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Role, &user.MustChangePassword, &user.Status, &user.CreatedAt, &user.Locale)
 
 	if err != nil {
 		return nil, err
@@ -45,9 +68,9 @@ func (r *UserRepository) GetUserByUsername(username string) (*User, error) {
 func (r *UserRepository) GetUserByID(id int64) (*User, error) {
 	var user User
 	err := r.DB.QueryRow(
-		"SELECT id, username, password_hash, role, created_at FROM users WHERE id = ?",
+		"SELECT id, username, password_hash, role, must_change_password, status, created_at, locale FROM users WHERE id = ?",
 		id,
-	).Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Role, &user.CreatedAt)
+	).Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Role, &user.MustChangePassword, &user.Status, &user.CreatedAt, &user.Locale)
 	if err != nil {
 		return nil, err
 	}
@@ -74,7 +97,7 @@ func (r *UserRepository) AdminExists() (bool, error) {
 
 // GetAllUsers возвращает список всех пользователей (без паролей)
 func (r *UserRepository) GetAllUsers() ([]User, error) {
-	rows, err := r.DB.Query(`SELECT id, username, role, created_at FROM users ORDER BY id ASC`)
+	rows, err := r.DB.Query(`SELECT id, username, role, must_change_password, status, created_at FROM users ORDER BY id ASC`)
 	if err != nil {
 		return nil, err
 	}
@@ -83,7 +106,7 @@ func (r *UserRepository) GetAllUsers() ([]User, error) {
 	var users []User
 	for rows.Next() {
 		var u User
-		if err := rows.Scan(&u.ID, &u.Username, &u.Role, &u.CreatedAt); err != nil {
+		if err := rows.Scan(&u.ID, &u.Username, &u.Role, &u.MustChangePassword, &u.Status, &u.CreatedAt); err != nil {
 			return nil, err
 		}
 		users = append(users, u)
@@ -96,3 +119,64 @@ func (r *UserRepository) UpdateUserRole(id int, role int) error {
 	_, err := r.DB.Exec(`UPDATE users SET role = ? WHERE id = ?`, role, id)
 	return err
 }
+
+// UpdatePasswordHash заменяет хэш пароля пользователя
+func (r *UserRepository) UpdatePasswordHash(id int64, passwordHash string) error {
+	_, err := r.DB.Exec(`UPDATE users SET password_hash = ? WHERE id = ?`, passwordHash, id)
+	return err
+}
+
+// UpdatePassword меняет пароль и снимает требование его смены
+func (r *UserRepository) UpdatePassword(id int64, passwordHash string) error {
+	_, err := r.DB.Exec(`UPDATE users SET password_hash = ?, must_change_password = 0 WHERE id = ?`, passwordHash, id)
+	return err
+}
+
+// UpdateLocale сохраняет язык сообщений пользователя ("" — по Accept-Language)
+func (r *UserRepository) UpdateLocale(id int64, locale string) error {
+	_, err := r.DB.Exec(`UPDATE users SET locale = ? WHERE id = ?`, locale, id)
+	return err
+}
+
+// MarkMustChangePassword требует сменить пароль при следующем входе
+func (r *UserRepository) MarkMustChangePassword(username string) error {
+	_, err := r.DB.Exec(`UPDATE users SET must_change_password = 1 WHERE username = ?`, username)
+	return err
+}
+
+// UpdateUserStatus меняет состояние учётной записи, если текущее равно from.
+// Возвращает false, если пользователь не найден или состояние другое.
+func (r *UserRepository) UpdateUserStatus(id int64, from, to string) (bool, error) {
+	res, err := r.DB.Exec(`UPDATE users SET status = ? WHERE id = ? AND status = ?`, to, id, from)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// UserCount — число учётных записей с данной ролью и состоянием
+type UserCount struct {
+	Role   int    `json:"role"`
+	Status string `json:"status"`
+	Count  int    `json:"count"`
+}
+
+// CountUsers возвращает число пользователей по ролям и состояниям (без самих записей)
+func (r *UserRepository) CountUsers() ([]UserCount, error) {
+	rows, err := r.DB.Query(`SELECT role, status, COUNT(*) FROM users GROUP BY role, status ORDER BY role, status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []UserCount{}
+	for rows.Next() {
+		var c UserCount
+		if err := rows.Scan(&c.Role, &c.Status, &c.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
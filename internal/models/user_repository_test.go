@@ -36,11 +36,11 @@ func TestGetUserByUsername(t *testing.T) {
 	db, mock, repo := setupMockDB(t)
 	defer db.Close()
 
-	rows := sqlmock.NewRows([]string{"id", "username", "password_hash", "role", "created_at"}).
-		AddRow(1, "bob", "hash123", 0, time.Now())
+	rows := sqlmock.NewRows([]string{"id", "username", "password_hash", "role", "must_change_password", "status", "created_at", "locale"}).
+		AddRow(1, "bob", "hash123", 0, false, "active", time.Now(), "")
 
 	mock.ExpectQuery(regexp.QuoteMeta(
-		"SELECT id, username, password_hash, role, created_at FROM users WHERE username = ?")).
+		"SELECT id, username, password_hash, role, must_change_password, status, created_at, locale FROM users WHERE username = ?")).
 		WithArgs("bob").
 		WillReturnRows(rows)
 
@@ -57,7 +57,7 @@ func TestGetUserByUsername_NoRows(t *testing.T) {
 	defer db.Close()
 
 	mock.ExpectQuery(regexp.QuoteMeta(
-		"SELECT id, username, password_hash, role, created_at FROM users WHERE username = ?")).
+		"SELECT id, username, password_hash, role, must_change_password, status, created_at, locale FROM users WHERE username = ?")).
 		WithArgs("ghost").
 		WillReturnError(sql.ErrNoRows)
 
@@ -109,12 +109,12 @@ func TestGetAllUsers(t *testing.T) {
 	db, mock, repo := setupMockDB(t)
 	defer db.Close()
 
-	rows := sqlmock.NewRows([]string{"id", "username", "role", "created_at"}).
-		AddRow(1, "admin", 1, time.Now()).
-		AddRow(2, "user", 0, time.Now())
+	rows := sqlmock.NewRows([]string{"id", "username", "role", "must_change_password", "status", "created_at"}).
+		AddRow(1, "admin", 1, false, "active", time.Now()).
+		AddRow(2, "user", 0, false, "active", time.Now())
 
 	mock.ExpectQuery(regexp.QuoteMeta(
-		"SELECT id, username, role, created_at FROM users ORDER BY id ASC")).
+		"SELECT id, username, role, must_change_password, status, created_at FROM users ORDER BY id ASC")).
 		WillReturnRows(rows)
 
 	users, err := repo.GetAllUsers()
@@ -125,3 +125,41 @@ func TestGetAllUsers(t *testing.T) {
 	assert.Equal(t, "user", users[1].Username)
 	assert.Equal(t, 0, users[1].Role)
 }
+
+func TestUpdatePassword(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET password_hash = ?, must_change_password = 0 WHERE id = ?")).
+		WithArgs("newhash", int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.UpdatePassword(3, "newhash"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMarkMustChangePassword(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET must_change_password = 1 WHERE username = ?")).
+		WithArgs("admin").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.MarkMustChangePassword("admin"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCountUsers(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT role, status, COUNT(*) FROM users GROUP BY role, status")).
+		WillReturnRows(sqlmock.NewRows([]string{"role", "status", "count"}).
+			AddRow(0, "active", 7).
+			AddRow(1, "active", 2))
+
+	counts, err := repo.CountUsers()
+	assert.NoError(t, err)
+	assert.Equal(t, []UserCount{{Role: 0, Status: "active", Count: 7}, {Role: 1, Status: "active", Count: 2}}, counts)
+}
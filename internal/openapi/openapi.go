@@ -0,0 +1,66 @@
+// Package openapi — спецификация API в формате OpenAPI 3. Документ
+// openapi.json ведётся вручную и встраивается в бинарник; тест в cmd/server
+// сверяет его с маршрутами роутера, так что новый маршрут без описания не
+// пройдёт сборку.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+
+	"rim-router-service-ver-cgo/internal/version"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+var (
+	docOnce sync.Once
+	doc     []byte
+	docErr  error
+)
+
+// Document возвращает спецификацию с версией сборки в info.version
+func Document() ([]byte, error) {
+	docOnce.Do(func() {
+		var m map[string]interface{}
+		if docErr = json.Unmarshal(spec, &m); docErr != nil {
+			return
+		}
+		if info, ok := m["info"].(map[string]interface{}); ok {
+			info["version"] = version.Get().Version
+		}
+		doc, docErr = json.Marshal(m)
+	})
+	return doc, docErr
+}
+
+// methods — ключи операций в объекте пути OpenAPI
+var methods = map[string]bool{
+	"get": true, "put": true, "post": true, "delete": true,
+	"options": true, "head": true, "patch": true, "trace": true,
+}
+
+// Operations возвращает описанные операции в виде "GET /api/v1/locale"
+func Operations() ([]string, error) {
+	var s struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(spec, &s); err != nil {
+		return nil, err
+	}
+
+	var ops []string
+	for path, item := range s.Paths {
+		for method := range item {
+			if methods[method] {
+				ops = append(ops, strings.ToUpper(method)+" "+path)
+			}
+		}
+	}
+	sort.Strings(ops)
+	return ops, nil
+}
@@ -0,0 +1,85 @@
+package openapi
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collectRefs собирает все значения $ref документа
+func collectRefs(v interface{}, refs *[]string) {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for k, val := range x {
+			if s, ok := val.(string); ok && k == "$ref" {
+				*refs = append(*refs, s)
+				continue
+			}
+			collectRefs(val, refs)
+		}
+	case []interface{}:
+		for _, val := range x {
+			collectRefs(val, refs)
+		}
+	}
+}
+
+func TestSpec_RefsResolve(t *testing.T) {
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(spec, &doc))
+	assert.Equal(t, "3.0.3", doc["openapi"])
+
+	var refs []string
+	collectRefs(doc, &refs)
+	require.NotEmpty(t, refs)
+
+	for _, ref := range refs {
+		require.True(t, strings.HasPrefix(ref, "#/"), ref)
+		var node interface{} = doc
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			m, ok := node.(map[string]interface{})
+			require.True(t, ok, "ссылка %s не разрешается", ref)
+			node, ok = m[part]
+			require.True(t, ok, "ссылка %s не разрешается", ref)
+		}
+	}
+}
+
+func TestSpec_OperationsHaveIDs(t *testing.T) {
+	var s struct {
+		Paths map[string]map[string]struct {
+			OperationID string                 `json:"operationId"`
+			Responses   map[string]interface{} `json:"responses"`
+		} `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(spec, &s))
+
+	seen := map[string]string{}
+	for path, item := range s.Paths {
+		for method, op := range item {
+			where := strings.ToUpper(method) + " " + path
+			assert.NotEmpty(t, op.OperationID, where)
+			assert.NotEmpty(t, op.Responses, where)
+			if prev, dup := seen[op.OperationID]; dup {
+				t.Errorf("operationId %s повторяется: %s и %s", op.OperationID, prev, where)
+			}
+			seen[op.OperationID] = where
+		}
+	}
+}
+
+func TestDocument_SetsVersion(t *testing.T) {
+	b, err := Document()
+	require.NoError(t, err)
+
+	var doc struct {
+		Info struct {
+			Version string `json:"version"`
+		} `json:"info"`
+	}
+	require.NoError(t, json.Unmarshal(b, &doc))
+	assert.NotEmpty(t, doc.Info.Version)
+}
@@ -0,0 +1,330 @@
+// Package sysinfo собирает состояние устройства из /proc и statfs для
+// диагностики на месте. Корень /proc задаётся полем, чтобы тесты могли
+// подложить подготовленное дерево.
+package sysinfo
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"rim-router-service-ver-cgo/internal/utils"
+)
+
+// Info — снимок состояния устройства и сервиса
+type Info struct {
+	Hostname   string      `json:"hostname"`
+	Uptime     float64     `json:"uptime_seconds"`
+	LoadAvg    [3]float64  `json:"load_avg"`
+	Memory     Memory      `json:"memory"`
+	CPUCount   int         `json:"cpu_count"`
+	Process    Process     `json:"process"`
+	Disks      []Disk      `json:"disks"`
+	Interfaces []Interface `json:"interfaces"`
+	Database   Database    `json:"database"`
+	LogDir     string      `json:"log_dir"`
+
+	// Errors — источники, которые не удалось прочитать; остальные поля заполнены
+	Errors []string `json:"errors,omitempty"`
+}
+
+type Memory struct {
+	TotalBytes     uint64 `json:"total_bytes"`
+	FreeBytes      uint64 `json:"free_bytes"`
+	AvailableBytes uint64 `json:"available_bytes"`
+}
+
+type Process struct {
+	PID        int    `json:"pid"`
+	RSSBytes   uint64 `json:"rss_bytes"`
+	Goroutines int    `json:"goroutines"`
+}
+
+type Disk struct {
+	Root           string  `json:"root"`
+	Path           string  `json:"path"`
+	TotalBytes     uint64  `json:"total_bytes"`
+	AvailableBytes uint64  `json:"available_bytes"`
+	UsedPercent    float64 `json:"used_percent"`
+}
+
+type Interface struct {
+	Name  string   `json:"name"`
+	MAC   string   `json:"mac,omitempty"`
+	Up    bool     `json:"up"`
+	Addrs []string `json:"addrs"`
+}
+
+type Database struct {
+	Path      string `json:"path"`
+	SizeBytes int64  `json:"size_bytes"`
+	WALBytes  int64  `json:"wal_bytes"`
+}
+
+// Collector читает состояние; нулевые поля заменяются значениями по умолчанию
+type Collector struct {
+	ProcRoot string // по умолчанию /proc
+	DBPath   string
+
+	Roots      func() []utils.Root             // по умолчанию utils.ListRoots
+	LogDir     func() string                   // по умолчанию utils.LogDirFunc
+	Interfaces func() ([]net.Interface, error) // по умолчанию net.Interfaces
+}
+
+func NewCollector(dbPath string) *Collector {
+	return &Collector{ProcRoot: "/proc", DBPath: dbPath}
+}
+
+// Collect собирает снимок. Ошибки отдельных источников не прерывают сбор.
+func (c *Collector) Collect() Info {
+	var info Info
+	fail := func(source string, err error) {
+		info.Errors = append(info.Errors, fmt.Sprintf("%s: %v", source, err))
+	}
+
+	info.Hostname, _ = os.Hostname()
+
+	if up, err := c.uptime(); err != nil {
+		fail("uptime", err)
+	} else {
+		info.Uptime = up
+	}
+	if load, err := c.loadAvg(); err != nil {
+		fail("loadavg", err)
+	} else {
+		info.LoadAvg = load
+	}
+	if mem, err := c.memory(); err != nil {
+		fail("meminfo", err)
+	} else {
+		info.Memory = mem
+	}
+	info.CPUCount = c.cpuCount()
+
+	info.Process = Process{PID: os.Getpid(), Goroutines: runtime.NumGoroutine()}
+	if rss, err := c.rss(); err != nil {
+		fail("self/status", err)
+	} else {
+		info.Process.RSSBytes = rss
+	}
+
+	roots := c.Roots
+	if roots == nil {
+		roots = utils.ListRoots
+	}
+	info.Disks = []Disk{}
+	for _, root := range roots() {
+		d, err := diskUsage(root)
+		if err != nil {
+			fail("statfs "+root.Path, err)
+			continue
+		}
+		info.Disks = append(info.Disks, d)
+	}
+
+	if ifaces, err := c.interfaces(); err != nil {
+		fail("interfaces", err)
+	} else {
+		info.Interfaces = ifaces
+	}
+
+	info.Database = Database{Path: c.DBPath, SizeBytes: fileSize(c.DBPath), WALBytes: fileSize(c.DBPath + "-wal")}
+
+	logDir := c.LogDir
+	if logDir == nil {
+		logDir = utils.LogDirFunc
+	}
+	info.LogDir = logDir()
+	return info
+}
+
+func (c *Collector) proc(name string) string {
+	root := c.ProcRoot
+	if root == "" {
+		root = "/proc"
+	}
+	return filepath.Join(root, name)
+}
+
+// /proc/uptime: "12345.67 54321.00"
+func (c *Collector) uptime() (float64, error) {
+	data, err := os.ReadFile(c.proc("uptime"))
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("unexpected format")
+	}
+	return strconv.ParseFloat(fields[0], 64)
+}
+
+// /proc/loadavg: "0.52 0.58 0.59 1/467 12345"
+func (c *Collector) loadAvg() ([3]float64, error) {
+	var load [3]float64
+	data, err := os.ReadFile(c.proc("loadavg"))
+	if err != nil {
+		return load, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 3 {
+		return load, fmt.Errorf("unexpected format")
+	}
+	for i := range load {
+		if load[i], err = strconv.ParseFloat(fields[i], 64); err != nil {
+			return load, err
+		}
+	}
+	return load, nil
+}
+
+// /proc/meminfo: "MemTotal:  1024000 kB"
+func (c *Collector) memory() (Memory, error) {
+	values, err := readKB(c.proc("meminfo"), "MemTotal", "MemFree", "MemAvailable")
+	if err != nil {
+		return Memory{}, err
+	}
+	return Memory{
+		TotalBytes:     values["MemTotal"],
+		FreeBytes:      values["MemFree"],
+		AvailableBytes: values["MemAvailable"],
+	}, nil
+}
+
+// /proc/self/status: "VmRSS:     12345 kB"
+func (c *Collector) rss() (uint64, error) {
+	values, err := readKB(c.proc("self/status"), "VmRSS")
+	if err != nil {
+		return 0, err
+	}
+	return values["VmRSS"], nil
+}
+
+// cpuCount — число строк "processor" в /proc/cpuinfo, иначе runtime.NumCPU
+func (c *Collector) cpuCount() int {
+	f, err := os.Open(c.proc("cpuinfo"))
+	if err != nil {
+		return runtime.NumCPU()
+	}
+	defer f.Close()
+
+	n := 0
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if strings.HasPrefix(sc.Text(), "processor") {
+			n++
+		}
+	}
+	if n == 0 {
+		return runtime.NumCPU()
+	}
+	return n
+}
+
+// readKB читает строки "Key: value kB" и возвращает значения в байтах
+func readKB(path string, keys ...string) (map[string]uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	want := map[string]bool{}
+	for _, k := range keys {
+		want[k] = true
+	}
+	out := map[string]uint64{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		key, rest, ok := strings.Cut(sc.Text(), ":")
+		if !ok || !want[key] {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		if len(fields) > 1 && fields[1] == "kB" {
+			v *= 1024
+		}
+		out[key] = v
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		if _, ok := out[k]; !ok {
+			return nil, fmt.Errorf("%s not found", k)
+		}
+	}
+	return out, nil
+}
+
+func diskUsage(root utils.Root) (Disk, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(root.Path, &st); err != nil {
+		return Disk{}, err
+	}
+	total := st.Blocks * uint64(st.Bsize)
+	free := st.Bfree * uint64(st.Bsize)
+	d := Disk{
+		Root:           root.ID,
+		Path:           root.Path,
+		TotalBytes:     total,
+		AvailableBytes: st.Bavail * uint64(st.Bsize),
+	}
+	if total > 0 {
+		d.UsedPercent = float64(total-free) / float64(total) * 100
+	}
+	return d, nil
+}
+
+func (c *Collector) interfaces() ([]Interface, error) {
+	list := c.Interfaces
+	if list == nil {
+		list = net.Interfaces
+	}
+	ifaces, err := list()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Interface, 0, len(ifaces))
+	for _, ifc := range ifaces {
+		item := Interface{
+			Name:  ifc.Name,
+			MAC:   ifc.HardwareAddr.String(),
+			Up:    ifc.Flags&net.FlagUp != 0,
+			Addrs: []string{},
+		}
+		if addrs, err := ifc.Addrs(); err == nil {
+			for _, a := range addrs {
+				item.Addrs = append(item.Addrs, a.String())
+			}
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+// fileSize — размер файла или 0, если его нет
+func fileSize(path string) int64 {
+	if path == "" {
+		return 0
+	}
+	st, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return st.Size()
+}
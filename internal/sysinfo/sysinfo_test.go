@@ -0,0 +1,81 @@
+package sysinfo
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rim-router-service-ver-cgo/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeProc создаёт дерево /proc с заданными файлами
+func fakeProc(t *testing.T, files map[string]string) string {
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return root
+}
+
+func testCollector(t *testing.T, procRoot string) (*Collector, string) {
+	logs := t.TempDir()
+	db := filepath.Join(t.TempDir(), "data.db")
+	assert.NoError(t, os.WriteFile(db, make([]byte, 4096), 0o644))
+	assert.NoError(t, os.WriteFile(db+"-wal", make([]byte, 1024), 0o644))
+
+	return &Collector{
+		ProcRoot:   procRoot,
+		DBPath:     db,
+		Roots:      func() []utils.Root { return []utils.Root{{ID: "local", Path: logs}} },
+		LogDir:     func() string { return logs },
+		Interfaces: func() ([]net.Interface, error) { return nil, nil },
+	}, logs
+}
+
+func TestCollect_FakeProc(t *testing.T) {
+	proc := fakeProc(t, map[string]string{
+		"uptime":      "3600.50 7000.00\n",
+		"loadavg":     "0.52 0.58 0.61 1/467 12345\n",
+		"meminfo":     "MemTotal:        1024000 kB\nMemFree:          256000 kB\nMemAvailable:     512000 kB\nBuffers: 1 kB\n",
+		"cpuinfo":     "processor\t: 0\nmodel name\t: ARMv7\n\nprocessor\t: 1\nmodel name\t: ARMv7\n",
+		"self/status": "Name:\tserver\nVmRSS:\t   20480 kB\n",
+	})
+	c, logs := testCollector(t, proc)
+
+	info := c.Collect()
+
+	assert.Empty(t, info.Errors)
+	assert.Equal(t, 3600.5, info.Uptime)
+	assert.Equal(t, [3]float64{0.52, 0.58, 0.61}, info.LoadAvg)
+	assert.Equal(t, uint64(1024000*1024), info.Memory.TotalBytes)
+	assert.Equal(t, uint64(512000*1024), info.Memory.AvailableBytes)
+	assert.Equal(t, 2, info.CPUCount)
+	assert.Equal(t, uint64(20480*1024), info.Process.RSSBytes)
+	assert.Positive(t, info.Process.Goroutines)
+	assert.Equal(t, int64(4096), info.Database.SizeBytes)
+	assert.Equal(t, int64(1024), info.Database.WALBytes)
+	assert.Equal(t, logs, info.LogDir)
+	if assert.Len(t, info.Disks, 1) {
+		assert.Equal(t, "local", info.Disks[0].Root)
+		assert.Positive(t, info.Disks[0].TotalBytes)
+	}
+}
+
+func TestCollect_MissingSourcesArePartial(t *testing.T) {
+	proc := fakeProc(t, map[string]string{
+		"uptime":  "10.00 5.00\n",
+		"meminfo": "MemTotal: 1000 kB\n",
+	})
+	c, _ := testCollector(t, proc)
+
+	info := c.Collect()
+
+	assert.Equal(t, 10.0, info.Uptime, "доступные источники прочитаны")
+	assert.Positive(t, info.CPUCount, "без cpuinfo — runtime.NumCPU")
+	assert.Len(t, info.Errors, 3) // loadavg, meminfo без MemFree, self/status
+}
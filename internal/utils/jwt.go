@@ -16,9 +16,22 @@ import (
 // ============================
 
 type Claims struct {
-	UserID   int64  `json:"user_id"`
-	Username string `json:"username"`
-	Role     int    `json:"role"`
+	UserID             int64  `json:"user_id"`
+	Username           string `json:"username"`
+	Role               int    `json:"role"`
+	MustChangePassword bool   `json:"mcp,omitempty"` // до смены пароля доступен только её эндпоинт
+	Locale             string `json:"loc,omitempty"` // язык сообщений из настроек пользователя
+
+	// Purpose — назначение служебного токена (второй шаг входа); у access-токена пусто
+	Purpose string `json:"pur,omitempty"`
+
+	// IssuedAtNano — время выдачи в наносекундах. iat хранит секунды, и по нему
+	// нельзя отличить токен, выданный в ту же секунду до отзыва, от выданного после.
+	IssuedAtNano int64 `json:"iat_ns,omitempty"`
+
+	// Заполняются только при входе по API-ключу и в токен не попадают
+	APIKeyID int64    `json:"-"`
+	Scopes   []string `json:"-"`
 	jwt.StandardClaims
 }
 
@@ -39,37 +52,66 @@ var (
 func GenerateAccessToken(user *models.User) (string, error) {
 	jwtConfig := config.GetJWTConfig()
 
-	expirationTime := time.Now().Add(jwtConfig.AccessExpiration)
+	now := time.Now()
+	expirationTime := now.Add(jwtConfig.AccessExpiration)
+	// jti — по нему токен можно отозвать до истечения
+	jti, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
 	claims := &Claims{
-		UserID:   user.ID,
-		Username: user.Username,
-		Role:     user.Role,
+		UserID:             user.ID,
+		Username:           user.Username,
+		Role:               user.Role,
+		MustChangePassword: user.MustChangePassword,
+		Locale:             user.Locale,
+		IssuedAtNano:       now.UnixNano(),
 		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
 			ExpiresAt: expirationTime.Unix(),
-			IssuedAt:  time.Now().Unix(),
+			IssuedAt:  now.Unix(),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(jwtConfig.Secret))
+	keys, err := currentKeyManager()
+	if err != nil {
+		return "", err
+	}
+	return keys.Sign(claims)
 }
 
 func ValidateToken(tokenString string) (*Claims, error) {
-	jwtConfig := config.GetJWTConfig()
+	claims, err := parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
 
-	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(jwtConfig.Secret), nil
-	})
+	if claims.Purpose != "" {
+		return nil, ErrNotAccessToken
+	}
+
+	return claims, nil
+}
 
+// parseToken проверяет подпись, срок и список отзыва токена любого назначения.
+func parseToken(tokenString string) (*Claims, error) {
+	keys, err := currentKeyManager()
 	if err != nil {
 		return nil, err
 	}
 
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, keys.Keyfunc)
+	if err != nil {
+		return nil, err
+	}
 	if !token.Valid {
 		return nil, jwt.ErrSignatureInvalid
 	}
 
+	if currentRevocationList().IsRevoked(claims) {
+		return nil, ErrTokenRevoked
+	}
 	return claims, nil
 }
 
@@ -79,7 +121,11 @@ func ValidateToken(tokenString string) (*Claims, error) {
 
 // Случайная строка длиной 64 символа, 256 бит энтропии
 func GenerateSecureToken() (string, error) {
-	buf := make([]byte, 32)
+	return randomHex(32)
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
 	if _, err := rand.Read(buf); err != nil {
 		return "", err
 	}
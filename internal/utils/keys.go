@@ -0,0 +1,438 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/config"
+	"rim-router-service-ver-cgo/internal/loglevel"
+	"rim-router-service-ver-cgo/internal/models"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// keyCheckInterval — как часто фоновая задача проверяет, не пора ли ротировать ключ.
+const keyCheckInterval = time.Hour
+
+// KeyStore — хранилище ключей подписи (в приложении — *models.SigningKeyRepository).
+type KeyStore interface {
+	ListSigningKeys() ([]models.SigningKey, error)
+	SaveSigningKey(k models.SigningKey) error
+	RetireSigningKey(kid string, at time.Time) error
+	DeleteSigningKeysRetiredBefore(t time.Time) error
+}
+
+type signingKey struct {
+	kid     string
+	alg     string
+	method  jwt.SigningMethod
+	sign    interface{}
+	verify  interface{}
+	created time.Time
+	retired *time.Time
+}
+
+// KeyManager хранит ключи подписи JWT: один активный (подписывает новые токены)
+// и выведенные из оборота, которые проверяют ранее выданные токены до их истечения.
+type KeyManager struct {
+	store       KeyStore // nil — ключи живут только в памяти
+	alg         string
+	rotateEvery time.Duration
+	tokenTTL    time.Duration
+	now         func() time.Time
+
+	// legacyUntil — до этого момента принимаются токены без kid: время
+	// создания первого ключа плюс срок жизни access-токена, так что к нему
+	// истекают все токены, выданные до перехода на ротацию
+	legacyUntil time.Time
+
+	mu     sync.RWMutex
+	active *signingKey
+	keys   map[string]*signingKey
+}
+
+// NewKeyManager загружает ключи из хранилища. Если активного ключа нужного
+// алгоритма нет (первый запуск или смена JWT_ALGORITHM) — создаёт его.
+func NewKeyManager(store KeyStore, cfg config.JWTConfig) (*KeyManager, error) {
+	m := &KeyManager{
+		store:       store,
+		alg:         cfg.Algorithm,
+		rotateEvery: cfg.RotationInterval,
+		tokenTTL:    cfg.AccessExpiration,
+		now:         time.Now,
+		keys:        make(map[string]*signingKey),
+	}
+
+	var firstKey time.Time
+	if store != nil {
+		stored, err := store.ListSigningKeys()
+		if err != nil {
+			return nil, fmt.Errorf("load signing keys: %w", err)
+		}
+		for _, sk := range stored {
+			if firstKey.IsZero() || sk.CreatedAt.Before(firstKey) {
+				firstKey = sk.CreatedAt
+			}
+			if sk.PrivateKey == nil {
+				// файл ключа пропал (или ключ остался от хранения в БД): токены,
+				// подписанные им, больше не проверяются, а сам ключ удалит pruneLocked
+				logger := loglevel.Logger("auth")
+				(&logger).Warn().Str("kid", sk.KID).Msg("Signing key file missing, key dropped")
+				if sk.RetiredAt == nil {
+					if err := store.RetireSigningKey(sk.KID, m.now()); err != nil {
+						return nil, fmt.Errorf("retire signing key: %w", err)
+					}
+				}
+				continue
+			}
+			k, err := parseSigningKey(sk)
+			if err != nil {
+				return nil, fmt.Errorf("signing key %s: %w", sk.KID, err)
+			}
+			m.keys[k.kid] = k
+			// ключи отсортированы по времени создания — побеждает самый новый
+			if k.retired == nil && k.alg == m.alg {
+				m.active = k
+			}
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.active == nil || m.rotationDue() {
+		if err := m.rotateLocked(); err != nil {
+			return nil, err
+		}
+	} else if err := m.retireOthersLocked(); err != nil {
+		return nil, err
+	}
+	if err := m.pruneLocked(); err != nil {
+		return nil, err
+	}
+
+	if firstKey.IsZero() {
+		firstKey = m.active.created
+	}
+	m.legacyUntil = firstKey.Add(m.tokenTTL)
+	if cfg.Secret != "" {
+		logger := loglevel.Logger("auth")
+		if m.now().Before(m.legacyUntil) {
+			(&logger).Warn().Time("until", m.legacyUntil).
+				Msg("JWT_SECRET is set: tokens without key id are accepted until the upgrade window closes")
+		} else {
+			(&logger).Warn().Msg("JWT_SECRET is set but no longer used; remove it from the environment")
+		}
+	}
+	return m, nil
+}
+
+// Start запускает фоновую ротацию по расписанию.
+func (m *KeyManager) Start() {
+	if m.rotateEvery <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(keyCheckInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := m.RotateIfDue(); err != nil {
+				logger := loglevel.Logger("auth")
+				(&logger).Error().Err(err).Msg("Signing key rotation failed")
+			}
+		}
+	}()
+}
+
+// RotateIfDue ротирует активный ключ, если он старше интервала ротации,
+// и удаляет ключи, которыми уже не может быть подписан ни один живой токен.
+func (m *KeyManager) RotateIfDue() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.rotationDue() {
+		if err := m.rotateLocked(); err != nil {
+			return err
+		}
+	}
+	return m.pruneLocked()
+}
+
+// Rotate немедленно создаёт новый активный ключ.
+func (m *KeyManager) Rotate() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rotateLocked()
+}
+
+// ActiveKID возвращает идентификатор ключа, которым подписываются новые токены.
+func (m *KeyManager) ActiveKID() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.active.kid
+}
+
+// Sign подписывает claims активным ключом и ставит его kid в заголовок.
+func (m *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	m.mu.RLock()
+	k := m.active
+	m.mu.RUnlock()
+
+	token := jwt.NewWithClaims(k.method, claims)
+	token.Header["kid"] = k.kid
+	return token.SignedString(k.sign)
+}
+
+// Keyfunc выбирает ключ проверки по kid. Токены без kid (выданные до
+// появления ротации) проверяются HMAC-секретом из JWT_SECRET, если он задан,
+// и только пока не истёк срок жизни таких токенов — иначе владелец старого
+// секрета мог бы выпускать токены бессрочно.
+func (m *KeyManager) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		secret := config.GetJWTConfig().Secret
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || secret == "" {
+			return nil, errors.New("token has no key id")
+		}
+		if !m.now().Before(m.legacyUntil) {
+			return nil, errors.New("tokens without key id are no longer accepted")
+		}
+		return []byte(secret), nil
+	}
+
+	m.mu.RLock()
+	k, ok := m.keys[kid]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	// alg из заголовка должен совпадать с алгоритмом ключа
+	if token.Method.Alg() != k.alg {
+		return nil, fmt.Errorf("unexpected signing method %s for key %s", token.Method.Alg(), kid)
+	}
+	return k.verify, nil
+}
+
+// JWK — публичный ключ в формате RFC 7517 для /.well-known/jwks.json.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// PublicJWKs возвращает публичные ключи проверки: активный и ещё не удалённые
+// выведенные из оборота. HS256-ключи симметричные и не публикуются.
+func (m *KeyManager) PublicJWKs() []JWK {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := make([]*signingKey, 0, len(m.keys))
+	for _, k := range m.keys {
+		keys = append(keys, k)
+	}
+	// новые ключи первыми
+	sort.Slice(keys, func(i, j int) bool { return keys[i].created.After(keys[j].created) })
+
+	out := []JWK{}
+	for _, k := range keys {
+		jwk := JWK{Kid: k.kid, Use: "sig", Alg: k.alg}
+		switch pub := k.verify.(type) {
+		case ed25519.PublicKey:
+			jwk.Kty, jwk.Crv = "OKP", "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		default:
+			continue
+		}
+		out = append(out, jwk)
+	}
+	return out
+}
+
+func (m *KeyManager) rotationDue() bool {
+	return m.active != nil && m.rotateEvery > 0 && m.now().Sub(m.active.created) >= m.rotateEvery
+}
+
+func (m *KeyManager) rotateLocked() error {
+	sk, err := generateSigningKey(m.alg, m.now())
+	if err != nil {
+		return fmt.Errorf("generate signing key: %w", err)
+	}
+	k, err := parseSigningKey(sk)
+	if err != nil {
+		return err
+	}
+	if m.store != nil {
+		if err := m.store.SaveSigningKey(sk); err != nil {
+			return fmt.Errorf("save signing key: %w", err)
+		}
+	}
+
+	m.keys[k.kid] = k
+	m.active = k
+	if err := m.retireOthersLocked(); err != nil {
+		return err
+	}
+
+	logger := loglevel.Logger("auth")
+	(&logger).Info().Str("kid", k.kid).Str("alg", k.alg).Msg("New JWT signing key activated")
+	return nil
+}
+
+// retireOthersLocked выводит из оборота все ключи, кроме активного.
+func (m *KeyManager) retireOthersLocked() error {
+	now := m.now()
+	for _, k := range m.keys {
+		if k == m.active || k.retired != nil {
+			continue
+		}
+		if m.store != nil {
+			if err := m.store.RetireSigningKey(k.kid, now); err != nil {
+				return fmt.Errorf("retire signing key: %w", err)
+			}
+		}
+		retired := now
+		k.retired = &retired
+	}
+	return nil
+}
+
+// pruneLocked удаляет ключи, выведенные из оборота раньше, чем живёт access-токен.
+func (m *KeyManager) pruneLocked() error {
+	cutoff := m.now().Add(-m.tokenTTL)
+	if m.store != nil {
+		if err := m.store.DeleteSigningKeysRetiredBefore(cutoff); err != nil {
+			return fmt.Errorf("prune signing keys: %w", err)
+		}
+	}
+	for kid, k := range m.keys {
+		if k.retired != nil && k.retired.Before(cutoff) {
+			delete(m.keys, kid)
+		}
+	}
+	return nil
+}
+
+func generateSigningKey(alg string, now time.Time) (models.SigningKey, error) {
+	sk := models.SigningKey{Algorithm: alg, CreatedAt: now}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return sk, err
+	}
+	sk.KID = hex.EncodeToString(id)
+
+	var err error
+	switch alg {
+	case config.JWTAlgHS256:
+		sk.PrivateKey = make([]byte, 32)
+		_, err = rand.Read(sk.PrivateKey)
+	case config.JWTAlgRS256:
+		var priv *rsa.PrivateKey
+		if priv, err = rsa.GenerateKey(rand.Reader, 2048); err == nil {
+			sk.PrivateKey, err = x509.MarshalPKCS8PrivateKey(priv)
+		}
+	case config.JWTAlgEdDSA:
+		var priv ed25519.PrivateKey
+		if _, priv, err = ed25519.GenerateKey(rand.Reader); err == nil {
+			sk.PrivateKey, err = x509.MarshalPKCS8PrivateKey(priv)
+		}
+	default:
+		err = fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	return sk, err
+}
+
+func parseSigningKey(sk models.SigningKey) (*signingKey, error) {
+	k := &signingKey{
+		kid:     sk.KID,
+		alg:     sk.Algorithm,
+		created: sk.CreatedAt,
+		retired: sk.RetiredAt,
+	}
+
+	if sk.Algorithm == config.JWTAlgHS256 {
+		k.method = jwt.SigningMethodHS256
+		k.sign, k.verify = sk.PrivateKey, sk.PrivateKey
+		return k, nil
+	}
+
+	priv, err := x509.ParsePKCS8PrivateKey(sk.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	switch key := priv.(type) {
+	case *rsa.PrivateKey:
+		if sk.Algorithm != config.JWTAlgRS256 {
+			return nil, fmt.Errorf("RSA key stored as %s", sk.Algorithm)
+		}
+		k.method, k.sign, k.verify = jwt.SigningMethodRS256, key, &key.PublicKey
+	case ed25519.PrivateKey:
+		if sk.Algorithm != config.JWTAlgEdDSA {
+			return nil, fmt.Errorf("Ed25519 key stored as %s", sk.Algorithm)
+		}
+		k.method, k.sign, k.verify = jwt.SigningMethodEdDSA, key, key.Public()
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", priv)
+	}
+	return k, nil
+}
+
+// ============================
+//   Глобальный менеджер ключей
+// ============================
+
+var (
+	keyManagerMu sync.Mutex
+	keyManager   *KeyManager
+)
+
+// SetKeyManager задаёт менеджер ключей приложения (вызывается из main).
+func SetKeyManager(m *KeyManager) {
+	keyManagerMu.Lock()
+	keyManager = m
+	keyManagerMu.Unlock()
+}
+
+// PublicJWKs — публичные ключи менеджера приложения.
+func PublicJWKs() ([]JWK, error) {
+	keys, err := currentKeyManager()
+	if err != nil {
+		return nil, err
+	}
+	return keys.PublicJWKs(), nil
+}
+
+// currentKeyManager возвращает менеджер ключей. Если main его не задал
+// (тесты, утилиты), создаётся менеджер с ключом только в памяти.
+func currentKeyManager() (*KeyManager, error) {
+	keyManagerMu.Lock()
+	defer keyManagerMu.Unlock()
+
+	if keyManager == nil {
+		m, err := NewKeyManager(nil, config.GetJWTConfig())
+		if err != nil {
+			return nil, err
+		}
+		keyManager = m
+	}
+	return keyManager, nil
+}
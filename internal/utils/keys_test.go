@@ -0,0 +1,228 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/config"
+	"rim-router-service-ver-cgo/internal/models"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+// memKeyStore — хранилище ключей в памяти вместо SQLite
+type memKeyStore struct {
+	keys []models.SigningKey
+}
+
+func (s *memKeyStore) ListSigningKeys() ([]models.SigningKey, error) {
+	return append([]models.SigningKey(nil), s.keys...), nil
+}
+
+func (s *memKeyStore) SaveSigningKey(k models.SigningKey) error {
+	s.keys = append(s.keys, k)
+	return nil
+}
+
+func (s *memKeyStore) RetireSigningKey(kid string, at time.Time) error {
+	for i := range s.keys {
+		if s.keys[i].KID == kid && s.keys[i].RetiredAt == nil {
+			t := at
+			s.keys[i].RetiredAt = &t
+		}
+	}
+	return nil
+}
+
+func (s *memKeyStore) DeleteSigningKeysRetiredBefore(t time.Time) error {
+	kept := s.keys[:0]
+	for _, k := range s.keys {
+		if k.RetiredAt == nil || !k.RetiredAt.Before(t) {
+			kept = append(kept, k)
+		}
+	}
+	s.keys = kept
+	return nil
+}
+
+func testJWTConfig(alg string) config.JWTConfig {
+	return config.JWTConfig{
+		Algorithm:        alg,
+		RotationInterval: 24 * time.Hour,
+		AccessExpiration: 15 * time.Minute,
+	}
+}
+
+func parseWith(m *KeyManager, token string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, m.Keyfunc)
+	return claims, err
+}
+
+func testClaims() *Claims {
+	return &Claims{
+		UserID:         1,
+		Username:       "tester",
+		StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(time.Hour).Unix()},
+	}
+}
+
+func TestKeyManager_Algorithms(t *testing.T) {
+	for _, alg := range []string{config.JWTAlgHS256, config.JWTAlgRS256, config.JWTAlgEdDSA} {
+		t.Run(alg, func(t *testing.T) {
+			m, err := NewKeyManager(nil, testJWTConfig(alg))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			token, err := m.Sign(testClaims())
+			assert.NoError(t, err)
+
+			parsed, _ := jwt.Parse(token, m.Keyfunc)
+			if assert.NotNil(t, parsed) {
+				assert.Equal(t, alg, parsed.Method.Alg())
+				assert.Equal(t, m.ActiveKID(), parsed.Header["kid"])
+			}
+
+			claims, err := parseWith(m, token)
+			assert.NoError(t, err)
+			assert.Equal(t, "tester", claims.Username)
+		})
+	}
+}
+
+func TestKeyManager_PersistsKeys(t *testing.T) {
+	store := &memKeyStore{}
+	m1, err := NewKeyManager(store, testJWTConfig(config.JWTAlgEdDSA))
+	assert.NoError(t, err)
+	token, _ := m1.Sign(testClaims())
+
+	// повторный запуск подхватывает тот же ключ
+	m2, err := NewKeyManager(store, testJWTConfig(config.JWTAlgEdDSA))
+	assert.NoError(t, err)
+	assert.Equal(t, m1.ActiveKID(), m2.ActiveKID())
+	assert.Len(t, store.keys, 1)
+
+	_, err = parseWith(m2, token)
+	assert.NoError(t, err)
+}
+
+func TestKeyManager_RotationKeepsRetiredKeyUntilTokensExpire(t *testing.T) {
+	store := &memKeyStore{}
+	m, err := NewKeyManager(store, testJWTConfig(config.JWTAlgEdDSA))
+	assert.NoError(t, err)
+
+	start := time.Now()
+	m.now = func() time.Time { return start }
+	oldKID := m.ActiveKID()
+	oldToken, _ := m.Sign(testClaims())
+
+	// интервал ротации прошёл
+	m.now = func() time.Time { return start.Add(25 * time.Hour) }
+	assert.NoError(t, m.RotateIfDue())
+	assert.NotEqual(t, oldKID, m.ActiveKID())
+
+	_, err = parseWith(m, oldToken)
+	assert.NoError(t, err, "токен старого ключа должен проверяться, пока жив")
+
+	// прошло больше времени жизни access-токена — старый ключ удалён
+	m.now = func() time.Time { return start.Add(25*time.Hour + 16*time.Minute) }
+	assert.NoError(t, m.RotateIfDue())
+	_, err = parseWith(m, oldToken)
+	assert.Error(t, err)
+	assert.Len(t, store.keys, 1)
+}
+
+func TestKeyManager_MissingKeyFileReplaced(t *testing.T) {
+	store := &memKeyStore{}
+	m1, err := NewKeyManager(store, testJWTConfig(config.JWTAlgEdDSA))
+	assert.NoError(t, err)
+	// файл ключа удалён: ListSigningKeys отдаёт метаданные без ключа
+	store.keys[0].PrivateKey = nil
+
+	m2, err := NewKeyManager(store, testJWTConfig(config.JWTAlgEdDSA))
+	assert.NoError(t, err)
+	assert.NotEqual(t, m1.ActiveKID(), m2.ActiveKID())
+	if assert.Len(t, store.keys, 2) {
+		assert.NotNil(t, store.keys[0].RetiredAt, "ключ без файла выводится из оборота")
+	}
+}
+
+func TestKeyManager_AlgorithmChangeRetiresOldKey(t *testing.T) {
+	store := &memKeyStore{}
+	_, err := NewKeyManager(store, testJWTConfig(config.JWTAlgHS256))
+	assert.NoError(t, err)
+
+	m, err := NewKeyManager(store, testJWTConfig(config.JWTAlgEdDSA))
+	assert.NoError(t, err)
+
+	if assert.Len(t, store.keys, 2) {
+		assert.NotNil(t, store.keys[0].RetiredAt)
+		assert.Equal(t, config.JWTAlgEdDSA, store.keys[1].Algorithm)
+		assert.Equal(t, m.ActiveKID(), store.keys[1].KID)
+	}
+}
+
+func TestKeyManager_RejectsUnknownKID(t *testing.T) {
+	m, _ := NewKeyManager(nil, testJWTConfig(config.JWTAlgHS256))
+	other, _ := NewKeyManager(nil, testJWTConfig(config.JWTAlgHS256))
+
+	token, _ := other.Sign(testClaims())
+	_, err := parseWith(m, token)
+	assert.Error(t, err)
+}
+
+func TestKeyManager_LegacyTokenWithoutKID(t *testing.T) {
+	t.Setenv("JWT_SECRET", "legacy-secret")
+
+	m, _ := NewKeyManager(nil, testJWTConfig(config.JWTAlgEdDSA))
+	legacy, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims()).SignedString([]byte("legacy-secret"))
+
+	_, err := parseWith(m, legacy)
+	assert.NoError(t, err)
+
+	t.Setenv("JWT_SECRET", "")
+	_, err = parseWith(m, legacy)
+	assert.Error(t, err, "без JWT_SECRET токены без kid не принимаются")
+}
+
+func TestKeyManager_LegacyWindowCloses(t *testing.T) {
+	t.Setenv("JWT_SECRET", "legacy-secret")
+	cfg := testJWTConfig(config.JWTAlgEdDSA)
+
+	// первый ключ создан раньше, чем живёт access-токен: все токены без kid
+	// уже истекли, новые с тем же секретом не принимаются
+	store := &memKeyStore{}
+	first, _ := NewKeyManager(store, cfg)
+	store.keys[0].CreatedAt = time.Now().Add(-time.Hour)
+	m, err := NewKeyManager(store, cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, first.ActiveKID(), m.ActiveKID())
+
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims()).SignedString([]byte("legacy-secret"))
+	_, err = parseWith(m, forged)
+	assert.Error(t, err)
+}
+
+func TestKeyManager_PublicJWKs(t *testing.T) {
+	ed, _ := NewKeyManager(nil, testJWTConfig(config.JWTAlgEdDSA))
+	jwks := ed.PublicJWKs()
+	if assert.Len(t, jwks, 1) {
+		assert.Equal(t, "OKP", jwks[0].Kty)
+		assert.Equal(t, "Ed25519", jwks[0].Crv)
+		assert.Equal(t, ed.ActiveKID(), jwks[0].Kid)
+		assert.NotEmpty(t, jwks[0].X)
+	}
+
+	rs, _ := NewKeyManager(nil, testJWTConfig(config.JWTAlgRS256))
+	jwks = rs.PublicJWKs()
+	if assert.Len(t, jwks, 1) {
+		assert.Equal(t, "RSA", jwks[0].Kty)
+		assert.Equal(t, "AQAB", jwks[0].E)
+	}
+
+	// симметричные ключи не публикуются
+	hs, _ := NewKeyManager(nil, testJWTConfig(config.JWTAlgHS256))
+	assert.Empty(t, hs.PublicJWKs())
+}
@@ -6,10 +6,11 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
-	"github.com/rs/zerolog/log"
+	"rim-router-service-ver-cgo/internal/loglevel"
 )
 
 const (
@@ -90,6 +91,26 @@ type RotatingWriter struct {
 	file *os.File
 }
 
+// LogWriterStats — счётчики записи в api.log с момента запуска
+type LogWriterStats struct {
+	BytesWritten  uint64
+	Rotations     uint64
+	DroppedWrites uint64 // записи, пропущенные из-за нехватки места
+}
+
+var writerStats struct {
+	bytes, rotations, dropped atomic.Uint64
+}
+
+// GetLogWriterStats возвращает счётчики RotatingWriter
+func GetLogWriterStats() LogWriterStats {
+	return LogWriterStats{
+		BytesWritten:  writerStats.bytes.Load(),
+		Rotations:     writerStats.rotations.Load(),
+		DroppedWrites: writerStats.dropped.Load(),
+	}
+}
+
 func NewRotatingWriter() (*RotatingWriter, error) {
 	dir := LogDir()
 	path := filepath.Join(dir, LogFileName)
@@ -109,23 +130,26 @@ func (w *RotatingWriter) Write(p []byte) (n int, err error) {
 
 	// Проверяем место на диске
 	if err := checkDiskSpaceAndCleanup(); err != nil {
-		log.Error().
-			Str("module", "system").
+		logger := loglevel.Logger("system")
+		logger.Error().
 			Msgf("Insufficient disk space: %v — skipping log write", err)
 		// Не пишем, чтобы не забить диск
+		writerStats.dropped.Add(1)
 		return 0, nil
 	}
 
 	// Проверяем размер файла
 	if stat.Size()+int64(len(p)) > MaxLogSizeBytes {
 		if err := w.rotate(); err != nil {
-			log.Error().
-				Str("module", "system").
+			logger := loglevel.Logger("system")
+			logger.Error().
 				Msgf("Log rotation failed: %v", err)
 		}
 	}
 
-	return w.file.Write(p)
+	n, err = w.file.Write(p)
+	writerStats.bytes.Add(uint64(n))
+	return n, err
 }
 
 func (w *RotatingWriter) rotate() error {
@@ -147,9 +171,10 @@ func (w *RotatingWriter) rotate() error {
 		return fmt.Errorf("reopen log: %w", err)
 	}
 	w.file = f
+	writerStats.rotations.Add(1)
 
-	log.Info().
-		Str("module", "system").
+	logger := loglevel.Logger("system")
+	logger.Info().
 		Str("archived_log", newName).
 		Msg("Log rotated successfully")
 
@@ -216,8 +241,8 @@ func checkDiskSpaceAndCleanup() error {
 	freeMB := float64(freeBytes) / (1024 * 1024)
 
 	if freeMB < MinFreeSpaceMB {
-		log.Warn().
-			Str("module", "system").
+		logger := loglevel.Logger("system")
+		logger.Warn().
 			Float64("free_mb", freeMB).
 			Msg("Low disk space detected: attempting cleanup")
 
@@ -240,6 +265,24 @@ func checkDiskSpaceAndCleanup() error {
 	return nil
 }
 
+// CheckLogDir проверяет, что в каталог логов можно писать и свободного места
+// не меньше MinFreeSpaceMB. Возвращает свободное место в МБ.
+func CheckLogDir() (float64, error) {
+	dir := LogDirFunc()
+	if err := ensureDir(dir); err != nil {
+		return 0, fmt.Errorf("log dir not writable: %w", err)
+	}
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	freeMB := float64(stat.Bavail*uint64(stat.Bsize)) / (1024 * 1024)
+	if freeMB < MinFreeSpaceMB {
+		return freeMB, fmt.Errorf("low disk space (%.2f MB free, minimum %.2f MB required)", freeMB, MinFreeSpaceMB)
+	}
+	return freeMB, nil
+}
+
 // =============================
 //   Вспомогательные функции
 // =============================
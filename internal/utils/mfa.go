@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"errors"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/models"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Назначения служебных токенов входа с MFA
+const (
+	TokenPurposeMFA       = "mfa"        // пароль верен, ждём TOTP-код
+	TokenPurposeMFAEnroll = "mfa_enroll" // MFA обязательна, но ещё не подключена
+)
+
+// ErrNotAccessToken — токен подписан нами, но выдан для другой цели.
+var ErrNotAccessToken = errors.New("token is not an access token")
+
+// GenerateMFAToken выдаёт токен второго шага входа, живущий ttl.
+// Как access-токен он не принимается.
+func GenerateMFAToken(user *models.User, purpose string, ttl time.Duration) (string, error) {
+	jti, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	claims := &Claims{
+		UserID:       user.ID,
+		Username:     user.Username,
+		Role:         user.Role,
+		Purpose:      purpose,
+		IssuedAtNano: now.UnixNano(),
+		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			ExpiresAt: now.Add(ttl).Unix(),
+			IssuedAt:  now.Unix(),
+		},
+	}
+
+	keys, err := currentKeyManager()
+	if err != nil {
+		return "", err
+	}
+	return keys.Sign(claims)
+}
+
+// ValidateMFAToken проверяет служебный токен с заданным назначением.
+func ValidateMFAToken(tokenString, purpose string) (*Claims, error) {
+	claims, err := parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != purpose {
+		return nil, errors.New("unexpected token purpose")
+	}
+	return claims, nil
+}
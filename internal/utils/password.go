@@ -0,0 +1,207 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"rim-router-service-ver-cgo/internal/config"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ============================
+//   Политика паролей
+// ============================
+
+//go:embed common_passwords.txt
+var commonPasswordsRaw string
+
+var commonPasswords = loadCommonPasswords(commonPasswordsRaw)
+
+func loadCommonPasswords(raw string) map[string]struct{} {
+	set := make(map[string]struct{})
+	sc := bufio.NewScanner(strings.NewReader(raw))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		set[strings.ToLower(line)] = struct{}{}
+	}
+	return set
+}
+
+// PolicyViolation — одно нарушение политики паролей.
+type PolicyViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidatePassword проверяет пароль по политике и возвращает все нарушения.
+func ValidatePassword(cfg config.PasswordConfig, username, password string) []PolicyViolation {
+	var out []PolicyViolation
+
+	length := utf8.RuneCountInString(password)
+	if length < cfg.MinLength {
+		out = append(out, PolicyViolation{"min_length",
+			fmt.Sprintf("Password must be at least %d characters", cfg.MinLength)})
+	}
+	if cfg.MaxLength > 0 && len(password) > cfg.MaxLength {
+		out = append(out, PolicyViolation{"max_length",
+			fmt.Sprintf("Password must be at most %d bytes", cfg.MaxLength)})
+	}
+	if classes := characterClasses(password); classes < cfg.MinClasses {
+		out = append(out, PolicyViolation{"character_classes",
+			fmt.Sprintf("Password must contain at least %d of: lowercase, uppercase, digits, symbols", cfg.MinClasses)})
+	}
+	if cfg.DisallowUsername && username != "" && strings.EqualFold(password, username) {
+		out = append(out, PolicyViolation{"username", "Password must not match the username"})
+	}
+	if cfg.CheckDenylist && IsCommonPassword(password) {
+		out = append(out, PolicyViolation{"denylist", "Password is too common"})
+	}
+	return out
+}
+
+// IsCommonPassword — пароль есть во встроенном списке распространённых.
+func IsCommonPassword(password string) bool {
+	_, ok := commonPasswords[strings.ToLower(password)]
+	return ok
+}
+
+func characterClasses(s string) int {
+	var lower, upper, digit, other bool
+	for _, r := range s {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	n := 0
+	for _, b := range []bool{lower, upper, digit, other} {
+		if b {
+			n++
+		}
+	}
+	return n
+}
+
+// ============================
+//   Хэширование паролей
+// ============================
+
+const argon2Prefix = "$argon2id$"
+
+// HashPassword хэширует пароль алгоритмом из конфигурации.
+func HashPassword(password string) (string, error) {
+	cfg := config.GetPasswordConfig()
+	if cfg.Algorithm == config.HashArgon2id {
+		return hashArgon2id(password, cfg)
+	}
+	h, err := bcrypt.GenerateFromPassword([]byte(password), cfg.BcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(h), nil
+}
+
+// CheckPassword сравнивает пароль с хэшем (bcrypt или argon2id).
+func CheckPassword(hash, password string) bool {
+	if strings.HasPrefix(hash, argon2Prefix) {
+		p, salt, key, err := decodeArgon2id(hash)
+		if err != nil {
+			return false
+		}
+		got := argon2.IDKey([]byte(password), salt, p.time, p.memory, p.threads, uint32(len(key)))
+		return subtle.ConstantTimeCompare(got, key) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// NeedsRehash — хэш создан другим алгоритмом или с другими параметрами,
+// чем указаны в текущей конфигурации.
+func NeedsRehash(hash string) bool {
+	cfg := config.GetPasswordConfig()
+
+	if strings.HasPrefix(hash, argon2Prefix) {
+		if cfg.Algorithm != config.HashArgon2id {
+			return true
+		}
+		p, _, _, err := decodeArgon2id(hash)
+		if err != nil {
+			return true
+		}
+		return p.memory != cfg.Argon2Memory || p.time != cfg.Argon2Time || p.threads != cfg.Argon2Threads
+	}
+
+	if cfg.Algorithm != config.HashBcrypt {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return true
+	}
+	want := cfg.BcryptCost
+	if want < bcrypt.MinCost {
+		want = bcrypt.DefaultCost
+	}
+	return cost != want
+}
+
+type argon2Params struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+}
+
+// Формат: $argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>
+func hashArgon2id(password string, cfg config.PasswordConfig) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, cfg.Argon2Time, cfg.Argon2Memory, cfg.Argon2Threads, 32)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2Prefix, argon2.Version,
+		cfg.Argon2Memory, cfg.Argon2Time, cfg.Argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func decodeArgon2id(hash string) (argon2Params, []byte, []byte, error) {
+	var p argon2Params
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, nil, nil, errors.New("invalid argon2id hash")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, errors.New("unsupported argon2 version")
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.time, &p.threads); err != nil {
+		return p, nil, nil, err
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, err
+	}
+	return p, salt, key, nil
+}
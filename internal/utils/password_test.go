@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"rim-router-service-ver-cgo/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func violationRules(vs []PolicyViolation) []string {
+	var rules []string
+	for _, v := range vs {
+		rules = append(rules, v.Rule)
+	}
+	return rules
+}
+
+func TestValidatePassword(t *testing.T) {
+	cfg := config.PasswordConfig{MinLength: 8, MaxLength: 72, MinClasses: 3, CheckDenylist: true, DisallowUsername: true}
+
+	assert.Empty(t, ValidatePassword(cfg, "bob", "Corr3ct-Horse"))
+	assert.Equal(t, []string{"min_length", "character_classes"}, violationRules(ValidatePassword(cfg, "bob", "abc")))
+	assert.Equal(t, []string{"denylist"}, violationRules(ValidatePassword(cfg, "bob", "P@ssw0rd")))
+	assert.Equal(t, []string{"username"}, violationRules(ValidatePassword(cfg, "Tech_User1", "tech_user1")))
+	assert.Contains(t, violationRules(ValidatePassword(cfg, "bob", "Aa1"+strings.Repeat("x", 80))), "max_length")
+}
+
+func TestIsCommonPassword(t *testing.T) {
+	assert.True(t, IsCommonPassword("admin123"))
+	assert.True(t, IsCommonPassword("ADMIN123"))
+	assert.False(t, IsCommonPassword("Corr3ct-Horse"))
+}
+
+func TestHashPassword_Bcrypt(t *testing.T) {
+	t.Setenv("PASSWORD_HASH", "bcrypt")
+	t.Setenv("PASSWORD_BCRYPT_COST", "5")
+
+	hash, err := HashPassword("secret-Pass1")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$2a$05$"))
+	assert.True(t, CheckPassword(hash, "secret-Pass1"))
+	assert.False(t, CheckPassword(hash, "wrong"))
+	assert.False(t, NeedsRehash(hash))
+
+	t.Setenv("PASSWORD_BCRYPT_COST", "6")
+	assert.True(t, NeedsRehash(hash))
+}
+
+func TestHashPassword_Argon2id(t *testing.T) {
+	t.Setenv("PASSWORD_HASH", "argon2id")
+	t.Setenv("PASSWORD_ARGON2_MEMORY_KB", "1024")
+	t.Setenv("PASSWORD_ARGON2_TIME", "1")
+
+	hash, err := HashPassword("secret-Pass1")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"))
+	assert.True(t, CheckPassword(hash, "secret-Pass1"))
+	assert.False(t, CheckPassword(hash, "wrong"))
+	assert.False(t, NeedsRehash(hash))
+
+	// переход обратно на bcrypt требует перехэширования
+	t.Setenv("PASSWORD_HASH", "bcrypt")
+	assert.True(t, NeedsRehash(hash))
+}
+
+func TestNeedsRehash_BcryptToArgon2(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("x"), bcrypt.MinCost)
+	t.Setenv("PASSWORD_HASH", "argon2id")
+	assert.True(t, NeedsRehash(string(hash)))
+}
+
+// Параметры вне диапазона заменяются значениями по умолчанию, а не паникуют
+func TestHashPassword_InvalidParamsFallBack(t *testing.T) {
+	t.Setenv("PASSWORD_HASH", "argon2id")
+	t.Setenv("PASSWORD_ARGON2_MEMORY_KB", "1024")
+	t.Setenv("PASSWORD_ARGON2_TIME", "256")
+	t.Setenv("PASSWORD_ARGON2_THREADS", "0")
+	t.Setenv("PASSWORD_BCRYPT_COST", "99")
+
+	cfg := config.GetPasswordConfig()
+	assert.Equal(t, uint32(2), cfg.Argon2Time)
+	assert.Equal(t, uint8(1), cfg.Argon2Threads)
+	assert.Equal(t, 10, cfg.BcryptCost)
+	assert.Len(t, config.PasswordConfigWarnings(), 3)
+
+	hash, err := HashPassword("Corr3ct-Horse")
+	assert.NoError(t, err)
+	assert.True(t, CheckPassword(hash, "Corr3ct-Horse"))
+}
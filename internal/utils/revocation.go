@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/config"
+	"rim-router-service-ver-cgo/internal/models"
+)
+
+// ErrTokenRevoked — подпись верна, но токен отозван (выход, смена роли или пароля).
+var ErrTokenRevoked = errors.New("token revoked")
+
+// RevocationStore — постоянное хранилище списка отзыва (в приложении — *models.RevocationRepository).
+type RevocationStore interface {
+	RevokeToken(jti string, expiresAt time.Time) error
+	RevokeUser(userID int64, before, expiresAt time.Time) error
+	LoadRevocations(now time.Time) (models.Revocations, error)
+	DeleteExpiredRevocations(now time.Time) error
+}
+
+// RevocationList — список отзыва access-токенов в памяти с копией в БД.
+// Записи живут не дольше самого токена, поэтому список остаётся маленьким.
+type RevocationList struct {
+	store    RevocationStore // nil — только память
+	tokenTTL time.Duration
+	now      func() time.Time
+
+	mu     sync.RWMutex
+	tokens map[string]time.Time
+	users  map[int64]models.UserRevocation
+}
+
+// NewRevocationList загружает действующие записи из хранилища.
+func NewRevocationList(store RevocationStore, cfg config.JWTConfig) (*RevocationList, error) {
+	l := &RevocationList{
+		store:    store,
+		tokenTTL: cfg.AccessExpiration,
+		now:      time.Now,
+		tokens:   map[string]time.Time{},
+		users:    map[int64]models.UserRevocation{},
+	}
+	if store == nil {
+		return l, nil
+	}
+
+	now := l.now()
+	if err := store.DeleteExpiredRevocations(now); err != nil {
+		return nil, err
+	}
+	loaded, err := store.LoadRevocations(now)
+	if err != nil {
+		return nil, err
+	}
+	l.tokens, l.users = loaded.Tokens, loaded.Users
+	return l, nil
+}
+
+// RevokeToken отзывает один токен по jti до момента его истечения.
+func (l *RevocationList) RevokeToken(jti string, expiresAt time.Time) error {
+	if jti == "" || !expiresAt.After(l.now()) {
+		return nil
+	}
+
+	l.mu.Lock()
+	l.tokens[jti] = expiresAt
+	l.pruneLocked()
+	l.mu.Unlock()
+
+	if l.store != nil {
+		return l.store.RevokeToken(jti, expiresAt)
+	}
+	return nil
+}
+
+// RevokeUser отзывает все токены пользователя, выданные до текущего момента.
+func (l *RevocationList) RevokeUser(userID int64) error {
+	now := l.now()
+	u := models.UserRevocation{RevokedBefore: now, ExpiresAt: now.Add(l.tokenTTL)}
+
+	l.mu.Lock()
+	l.users[userID] = u
+	l.pruneLocked()
+	l.mu.Unlock()
+
+	if l.store != nil {
+		if err := l.store.RevokeUser(userID, u.RevokedBefore, u.ExpiresAt); err != nil {
+			return err
+		}
+		return l.store.DeleteExpiredRevocations(now)
+	}
+	return nil
+}
+
+// IsRevoked проверяет токен по jti и по времени выдачи.
+func (l *RevocationList) IsRevoked(c *Claims) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if _, ok := l.tokens[c.Id]; ok && c.Id != "" {
+		return true
+	}
+	if u, ok := l.users[c.UserID]; ok {
+		if c.IssuedAtNano != 0 {
+			return c.IssuedAtNano < u.RevokedBefore.UnixNano()
+		}
+		// токены без iat_ns выданы до обновления; по секундам нельзя понять,
+		// выдан ли токен до отзыва, поэтому отзывается и вся секунда отзыва
+		return c.IssuedAt <= u.RevokedBefore.Unix()
+	}
+	return false
+}
+
+func (l *RevocationList) pruneLocked() {
+	now := l.now()
+	for jti, exp := range l.tokens {
+		if !exp.After(now) {
+			delete(l.tokens, jti)
+		}
+	}
+	for id, u := range l.users {
+		if !u.ExpiresAt.After(now) {
+			delete(l.users, id)
+		}
+	}
+}
+
+// ============================
+//   Глобальный список отзыва
+// ============================
+
+var (
+	revocationsMu sync.Mutex
+	revocations   *RevocationList
+)
+
+// SetRevocationList задаёт список отзыва приложения (вызывается из main).
+func SetRevocationList(l *RevocationList) {
+	revocationsMu.Lock()
+	revocations = l
+	revocationsMu.Unlock()
+}
+
+// currentRevocationList возвращает список отзыва; без main — список только в памяти.
+func currentRevocationList() *RevocationList {
+	revocationsMu.Lock()
+	defer revocationsMu.Unlock()
+	if revocations == nil {
+		revocations, _ = NewRevocationList(nil, config.GetJWTConfig())
+	}
+	return revocations
+}
+
+// RevokeAccessToken отзывает конкретный access-токен (например, при выходе).
+func RevokeAccessToken(c *Claims) error {
+	return currentRevocationList().RevokeToken(c.Id, time.Unix(c.ExpiresAt, 0))
+}
+
+// RevokeUserTokens отзывает все выданные пользователю access-токены.
+func RevokeUserTokens(userID int64) error {
+	return currentRevocationList().RevokeUser(userID)
+}
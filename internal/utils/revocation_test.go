@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/config"
+	"rim-router-service-ver-cgo/internal/models"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+// memRevocationStore — хранилище списка отзыва в памяти
+type memRevocationStore struct {
+	data models.Revocations
+}
+
+func newMemRevocationStore() *memRevocationStore {
+	return &memRevocationStore{data: models.Revocations{
+		Tokens: map[string]time.Time{},
+		Users:  map[int64]models.UserRevocation{},
+	}}
+}
+
+func (s *memRevocationStore) RevokeToken(jti string, exp time.Time) error {
+	s.data.Tokens[jti] = exp
+	return nil
+}
+
+func (s *memRevocationStore) RevokeUser(id int64, before, exp time.Time) error {
+	s.data.Users[id] = models.UserRevocation{RevokedBefore: before, ExpiresAt: exp}
+	return nil
+}
+
+func (s *memRevocationStore) LoadRevocations(now time.Time) (models.Revocations, error) {
+	return s.data, nil
+}
+
+func (s *memRevocationStore) DeleteExpiredRevocations(now time.Time) error {
+	for jti, exp := range s.data.Tokens {
+		if !exp.After(now) {
+			delete(s.data.Tokens, jti)
+		}
+	}
+	for id, u := range s.data.Users {
+		if !u.ExpiresAt.After(now) {
+			delete(s.data.Users, id)
+		}
+	}
+	return nil
+}
+
+var testRevocationConfig = config.JWTConfig{AccessExpiration: 15 * time.Minute}
+
+func claimsIssuedAt(userID int64, jti string, iat time.Time) *Claims {
+	return &Claims{UserID: userID, IssuedAtNano: iat.UnixNano(), StandardClaims: jwt.StandardClaims{
+		Id:        jti,
+		IssuedAt:  iat.Unix(),
+		ExpiresAt: iat.Add(15 * time.Minute).Unix(),
+	}}
+}
+
+func TestRevocationList_RevokeToken(t *testing.T) {
+	store := newMemRevocationStore()
+	l, err := NewRevocationList(store, testRevocationConfig)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	c := claimsIssuedAt(1, "jti-1", now)
+	assert.False(t, l.IsRevoked(c))
+
+	assert.NoError(t, l.RevokeToken("jti-1", now.Add(15*time.Minute)))
+	assert.True(t, l.IsRevoked(c))
+	assert.False(t, l.IsRevoked(claimsIssuedAt(1, "jti-2", now)))
+
+	// после перезапуска отзыв сохраняется
+	reloaded, err := NewRevocationList(store, testRevocationConfig)
+	assert.NoError(t, err)
+	assert.True(t, reloaded.IsRevoked(c))
+}
+
+func TestRevocationList_RevokeUser(t *testing.T) {
+	l, _ := NewRevocationList(nil, testRevocationConfig)
+
+	start := time.Now()
+	l.now = func() time.Time { return start }
+	old := claimsIssuedAt(5, "a", start.Add(-time.Minute))
+	other := claimsIssuedAt(6, "b", start.Add(-time.Minute))
+
+	assert.NoError(t, l.RevokeUser(5))
+	assert.True(t, l.IsRevoked(old))
+	assert.False(t, l.IsRevoked(other))
+
+	// токен, выданный после отзыва (например, новая сессия после смены пароля), действует
+	assert.False(t, l.IsRevoked(claimsIssuedAt(5, "c", start.Add(time.Second))))
+}
+
+func TestRevocationList_RevokeUserSameSecond(t *testing.T) {
+	l, _ := NewRevocationList(nil, testRevocationConfig)
+
+	start := time.Date(2026, 1, 10, 12, 0, 0, 500*int(time.Millisecond), time.UTC)
+	l.now = func() time.Time { return start }
+	assert.NoError(t, l.RevokeUser(5))
+
+	// в пределах одной секунды решает время выдачи в наносекундах
+	assert.True(t, l.IsRevoked(claimsIssuedAt(5, "before", start.Add(-time.Millisecond))))
+	assert.False(t, l.IsRevoked(claimsIssuedAt(5, "after", start.Add(time.Millisecond))))
+
+	// у токена без iat_ns отзывается вся секунда отзыва
+	legacy := claimsIssuedAt(5, "legacy", start.Add(time.Millisecond))
+	legacy.IssuedAtNano = 0
+	assert.True(t, l.IsRevoked(legacy))
+}
+
+func TestRevocationList_PrunesExpired(t *testing.T) {
+	l, _ := NewRevocationList(nil, testRevocationConfig)
+
+	start := time.Now()
+	l.now = func() time.Time { return start }
+	assert.NoError(t, l.RevokeToken("short", start.Add(time.Minute)))
+	assert.NoError(t, l.RevokeUser(5))
+
+	l.now = func() time.Time { return start.Add(16 * time.Minute) }
+	assert.NoError(t, l.RevokeToken("fresh", start.Add(30*time.Minute)))
+
+	assert.NotContains(t, l.tokens, "short")
+	assert.NotContains(t, l.users, int64(5))
+	assert.Contains(t, l.tokens, "fresh")
+}
+
+func TestValidateToken_Revoked(t *testing.T) {
+	token, err := GenerateAccessToken(&models.User{ID: 77, Username: "revoked"})
+	assert.NoError(t, err)
+
+	claims, err := ValidateToken(token)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotEmpty(t, claims.Id, "access-токен должен содержать jti")
+
+	assert.NoError(t, RevokeAccessToken(claims))
+	_, err = ValidateToken(token)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+}
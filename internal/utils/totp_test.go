@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"rim-router-service-ver-cgo/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Тестовые векторы RFC 6238 (SHA1), последние 6 цифр
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for ts, want := range vectors {
+		code, err := TOTPCode(secret, time.Unix(ts, 0))
+		assert.NoError(t, err)
+		assert.Equal(t, want, code, "t=%d", ts)
+	}
+}
+
+func TestVerifyTOTP_Skew(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	assert.NoError(t, err)
+
+	now := time.Unix(1700000000, 0)
+	code, _ := TOTPCode(secret, now)
+
+	step, ok := VerifyTOTP(secret, code, now.Add(30*time.Second))
+	assert.True(t, ok, "код предыдущего шага принимается")
+	assert.Equal(t, now.Unix()/30, step)
+
+	_, ok = VerifyTOTP(secret, code, now.Add(90*time.Second))
+	assert.False(t, ok)
+
+	_, ok = VerifyTOTP(secret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := TOTPProvisioningURI("RIM Router", "admin", "ABCDEF")
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/RIM%20Router:admin?"))
+	assert.Contains(t, uri, "secret=ABCDEF")
+	assert.Contains(t, uri, "issuer=RIM+Router")
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	assert.NoError(t, err)
+	assert.Len(t, codes, 10)
+	for _, c := range codes {
+		assert.Len(t, c, 11)
+		assert.Equal(t, c, NormalizeRecoveryCode(strings.ToUpper(strings.ReplaceAll(c, "-", " "))))
+	}
+}
+
+func TestMFAToken_NotAcceptedAsAccessToken(t *testing.T) {
+	user := &models.User{ID: 7, Username: "tester", Role: 1}
+
+	token, err := GenerateMFAToken(user, TokenPurposeMFA, 5*time.Minute)
+	assert.NoError(t, err)
+
+	_, err = ValidateToken(token)
+	assert.ErrorIs(t, err, ErrNotAccessToken)
+
+	claims, err := ValidateMFAToken(token, TokenPurposeMFA)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(7), claims.UserID)
+	}
+
+	_, err = ValidateMFAToken(token, TokenPurposeMFAEnroll)
+	assert.Error(t, err)
+
+	access, _ := GenerateAccessToken(user)
+	_, err = ValidateMFAToken(access, TokenPurposeMFA)
+	assert.Error(t, err, "access-токен не заменяет mfa_token")
+}
@@ -0,0 +1,74 @@
+// Package version — сведения о сборке. Значения подставляются при сборке:
+//
+//	go build -ldflags "-X rim-router-service-ver-cgo/internal/version.Version=1.2.3 \
+//	  -X rim-router-service-ver-cgo/internal/version.Commit=abc1234 \
+//	  -X rim-router-service-ver-cgo/internal/version.BuildDate=2025-01-01T00:00:00Z"
+//
+// Без ldflags (go run, go build) используются данные runtime/debug.ReadBuildInfo.
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Задаются через -ldflags -X
+var (
+	Version   = ""
+	Commit    = ""
+	BuildDate = ""
+)
+
+// Info — сведения о сборке
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+	Modified  bool   `json:"modified,omitempty"` // собрано из рабочей копии с изменениями
+	GoVersion string `json:"go_version"`
+	CGO       bool   `json:"cgo"`
+	Platform  string `json:"platform"`
+}
+
+// readBuildInfo — хук для тестов
+var readBuildInfo = debug.ReadBuildInfo
+
+// Get возвращает сведения о сборке; пустые ldflags дополняются из BuildInfo
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		CGO:       cgoEnabled,
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+
+	if bi, ok := readBuildInfo(); ok {
+		if info.Version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = s.Value
+				}
+			case "vcs.modified":
+				info.Modified = s.Value == "true"
+			}
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	if len(info.Commit) > 12 {
+		info.Commit = info.Commit[:12]
+	}
+	return info
+}
@@ -0,0 +1,45 @@
+package version
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func withBuildInfo(t *testing.T, bi *debug.BuildInfo) {
+	old := readBuildInfo
+	t.Cleanup(func() { readBuildInfo = old })
+	readBuildInfo = func() (*debug.BuildInfo, bool) { return bi, bi != nil }
+}
+
+func TestGet_LdflagsTakePrecedence(t *testing.T) {
+	withBuildInfo(t, &debug.BuildInfo{Settings: []debug.BuildSetting{
+		{Key: "vcs.revision", Value: "ffffffffffffffffffff"},
+	}})
+	Version, Commit, BuildDate = "2.0.1", "abc1234", "2025-03-01T10:00:00Z"
+	defer func() { Version, Commit, BuildDate = "", "", "" }()
+
+	info := Get()
+	assert.Equal(t, "2.0.1", info.Version)
+	assert.Equal(t, "abc1234", info.Commit)
+	assert.Equal(t, "2025-03-01T10:00:00Z", info.BuildDate)
+}
+
+func TestGet_FallsBackToBuildInfo(t *testing.T) {
+	withBuildInfo(t, &debug.BuildInfo{
+		Main: debug.Module{Version: "(devel)"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0123456789abcdef0123"},
+			{Key: "vcs.time", Value: "2025-02-02T02:02:02Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	})
+
+	info := Get()
+	assert.Equal(t, "dev", info.Version)
+	assert.Equal(t, "0123456789ab", info.Commit)
+	assert.Equal(t, "2025-02-02T02:02:02Z", info.BuildDate)
+	assert.True(t, info.Modified)
+	assert.NotEmpty(t, info.GoVersion)
+}